@@ -31,7 +31,7 @@ type ABI struct {
 	Methods     map[string]Method
 	Events      map[string]Event
 	Calls       map[string]Method
-	// Keys        map[string]Key
+	Keys        []Key // Storage variables, in the order of declaration
 }
 
 // JSON returns a parsed ABI interface and error if it failed.
@@ -130,7 +130,6 @@ func (abi *ABI) UnmarshalJSON(data []byte) error {
 	abi.Methods = make(map[string]Method)
 	abi.Events = make(map[string]Event)
 	abi.Calls = make(map[string]Method)
-	// abi.Keys = make(map[string]Key)
 	for _, field := range fields {
 		switch field.Type {
 		case "constructor":
@@ -160,13 +159,13 @@ func (abi *ABI) UnmarshalJSON(data []byte) error {
 				Anonymous: field.Anonymous,
 				Inputs:    field.Inputs,
 			}
-			// case "key":
-			// 	key := Key{
-			// 		Name:   field.Name,
-			// 		Tables: field.Tables,
-			// 	}
-			// 	key.KeyTraversal()
-			// 	abi.Keys[field.Name] = key
+		case "key":
+			key := Key{
+				Name:   field.Name,
+				Tables: field.Tables,
+			}
+			key.KeyTraversal()
+			abi.Keys = append(abi.Keys, key)
 		}
 	}
 
@@ -39,6 +39,10 @@ var (
 	// This error is returned by WaitDeployed if contract creation leaves an
 	// empty contract behind.
 	ErrNoCodeAfterDeploy = errors.New("no contract code after deployment")
+
+	// ErrNotPayable is returned by transact operations which transfer value to
+	// a method of WAVM contract not marked as payable.
+	ErrNotPayable = errors.New("method is not payable")
 )
 
 // ContractCaller defines the methods needed to allow operating with contract on a read
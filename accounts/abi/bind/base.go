@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 
 	vnt "github.com/vntchain/go-vnt"
 	"github.com/vntchain/go-vnt/accounts/abi"
@@ -30,6 +31,15 @@ import (
 	"github.com/vntchain/go-vnt/event"
 )
 
+// payablePrefix is the prefix of the names of WAVM contract methods, which
+// accept value transferred along with the calls.
+const payablePrefix = "$"
+
+// payable reports whether the method accepts value transferred to the contract.
+func payable(method string) bool {
+	return strings.HasPrefix(method, payablePrefix)
+}
+
 // SignerFn is a signer function callback when a contract requires a method to
 // sign the transaction before submission.
 type SignerFn func(types.Signer, common.Address, *types.Transaction) (*types.Transaction, error)
@@ -167,6 +177,11 @@ func (c *BoundContract) Call(opts *CallOpts, result interface{}, method string,
 
 // Transact invokes the (paid) contract method with params as input values.
 func (c *BoundContract) Transact(opts *TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	// WAVM rejects the value transferred to non-payable methods, fail early
+	// instead of the gas estimation
+	if opts.Value != nil && opts.Value.Sign() > 0 && !payable(method) {
+		return nil, ErrNotPayable
+	}
 	// Otherwise pack up the parameters and invoke the contract
 	input, err := c.abi.Pack(method, params...)
 	if err != nil {
@@ -328,13 +343,19 @@ func (c *BoundContract) WatchLogs(opts *WatchOpts, name string, query ...[]inter
 
 // UnpackLog unpacks a retrieved log into the provided output structure.
 func (c *BoundContract) UnpackLog(out interface{}, event string, log types.Log) error {
+	// Unpack by the event directly, WAVM contracts may have a method of the
+	// same name, which is preferred by abi.Unpack
+	ev, ok := c.abi.Events[event]
+	if !ok {
+		return fmt.Errorf("event '%s' not found", event)
+	}
 	if len(log.Data) > 0 {
-		if err := c.abi.Unpack(out, event, log.Data); err != nil {
+		if err := ev.Inputs.Unpack(out, log.Data); err != nil {
 			return err
 		}
 	}
 	var indexed abi.Arguments
-	for _, arg := range c.abi.Events[event].Inputs {
+	for _, arg := range ev.Inputs {
 		if arg.Indexed {
 			indexed = append(indexed, arg)
 		}
@@ -68,33 +68,29 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 			calls     = make(map[string]*tmplMethod)
 			transacts = make(map[string]*tmplMethod)
 			events    = make(map[string]*tmplEvent)
+			callees   = make(map[string]*tmplMethod)
 		)
+		if err := checkNormalized(vmABI.Methods, lang); err != nil {
+			return "", err
+		}
 		for _, original := range vmABI.Methods {
-			// Normalize the method for capital cases and non-anonymous inputs/outputs
-			normalized := original
-			normalized.Name = methodNormalizer[lang](original.Name)
-
-			normalized.Inputs = make([]abi.Argument, len(original.Inputs))
-			copy(normalized.Inputs, original.Inputs)
-			for j, input := range normalized.Inputs {
-				if input.Name == "" {
-					normalized.Inputs[j].Name = fmt.Sprintf("arg%d", j)
-				}
-			}
-			normalized.Outputs = make([]abi.Argument, len(original.Outputs))
-			copy(normalized.Outputs, original.Outputs)
-			for j, output := range normalized.Outputs {
-				if output.Name != "" {
-					normalized.Outputs[j].Name = capitalise(output.Name)
-				}
-			}
-			// Append the methods to the call or transact lists
-			if original.Const {
-				calls[original.Name] = &tmplMethod{Original: original, Normalized: normalized, Structured: structured(original.Outputs)}
+			// Append the methods to the call or transact lists, payable methods
+			// always need a transaction to transfer the value
+			method := normalizeMethod(original, lang)
+			if original.Const && !method.Payable {
+				calls[original.Name] = method
 			} else {
-				transacts[original.Name] = &tmplMethod{Original: original, Normalized: normalized, Structured: structured(original.Outputs)}
+				transacts[original.Name] = method
 			}
 		}
+		// Calls are the methods of other contracts called by the contract, bind
+		// them to call the other contracts directly
+		if err := checkNormalized(vmABI.Calls, lang); err != nil {
+			return "", err
+		}
+		for _, original := range vmABI.Calls {
+			callees[original.Name] = normalizeMethod(original, lang)
+		}
 		for _, original := range vmABI.Events {
 			// Skip anonymous events as they don't support explicit filtering
 			if original.Anonymous {
@@ -125,6 +121,7 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 			Calls:       calls,
 			Transacts:   transacts,
 			Events:      events,
+			Callees:     callees,
 		}
 	}
 	// Generate the contract template data content and render it
@@ -157,6 +154,51 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 	return buffer.String(), nil
 }
 
+// normalizeMethod normalizes the method for capital cases and non-anonymous
+// inputs/outputs.
+func normalizeMethod(original abi.Method, lang Lang) *tmplMethod {
+	normalized := original
+	normalized.Name = methodNormalizer[lang](strings.TrimPrefix(original.Name, payablePrefix))
+
+	normalized.Inputs = make([]abi.Argument, len(original.Inputs))
+	copy(normalized.Inputs, original.Inputs)
+	for j, input := range normalized.Inputs {
+		if input.Name == "" {
+			normalized.Inputs[j].Name = fmt.Sprintf("arg%d", j)
+		}
+	}
+	normalized.Outputs = make([]abi.Argument, len(original.Outputs))
+	copy(normalized.Outputs, original.Outputs)
+	for j, output := range normalized.Outputs {
+		if output.Name != "" {
+			normalized.Outputs[j].Name = capitalise(output.Name)
+		}
+	}
+	return &tmplMethod{
+		Original:   original,
+		Normalized: normalized,
+		Structured: structured(original.Outputs),
+		Payable:    payable(original.Name),
+	}
+}
+
+// checkNormalized checks that the normalized names of methods are not empty
+// and don't collide, e.g. "$deposit" and "deposit".
+func checkNormalized(methods map[string]abi.Method, lang Lang) error {
+	names := make(map[string]string)
+	for name := range methods {
+		normalized := methodNormalizer[lang](strings.TrimPrefix(name, payablePrefix))
+		if normalized == "" {
+			return fmt.Errorf("method %q has no valid binding name", name)
+		}
+		if other, ok := names[normalized]; ok {
+			return fmt.Errorf("methods %q and %q collide in binding name %s", other, name, normalized)
+		}
+		names[normalized] = name
+	}
+	return nil
+}
+
 // bindType is a set of type binders that convert Solidity types to some supported
 // programming language types.
 var bindType = map[Lang]func(kind abi.Type) string{
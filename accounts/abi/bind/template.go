@@ -33,6 +33,7 @@ type tmplContract struct {
 	Calls       map[string]*tmplMethod // Contract calls that only read state data
 	Transacts   map[string]*tmplMethod // Contract calls that write state data
 	Events      map[string]*tmplEvent  // Contract events accessors
+	Callees     map[string]*tmplMethod // Methods of other contracts called by the contract
 }
 
 // tmplMethod is a wrapper around an abi.Method that contains a few preprocessed
@@ -41,6 +42,7 @@ type tmplMethod struct {
 	Original   abi.Method // Original method as parsed by the abi package
 	Normalized abi.Method // Normalized version of the parsed method (capitalized names, non-anonymous args/returns)
 	Structured bool       // Whether the returns should be accumulated into a struct
+	Payable    bool       // Whether the method accepts value, marked by '$' in WAVM
 }
 
 // tmplEvent is a wrapper around an a
@@ -69,7 +71,8 @@ package {{.Package}}
 	const {{.Type}}ABI = "{{.InputABI}}"
 
 	{{if .InputBin}}
-		// {{.Type}}Bin is the compiled bytecode used for deploying new contracts.
+		// {{.Type}}Bin is the compressed WAVM code used for deploying new contracts.
+		// Constructor arguments are appended to it, the same as the create transaction.
 		const {{.Type}}Bin = ` + "`" + `{{.InputBin}}` + "`" + `
 
 		// Deploy{{.Type}} deploys a new VNT contract, binding an instance of {{.Type}} to it.
@@ -264,21 +267,21 @@ package {{.Package}}
 	{{end}}
 
 	{{range .Transacts}}
-		// {{.Normalized.Name}} is a paid mutator transaction binding the contract method 0x{{printf "%x" .Original.Id}}.
+		// {{.Normalized.Name}} is a {{if .Payable}}payable{{else}}paid mutator{{end}} transaction binding the contract method 0x{{printf "%x" .Original.Id}}.
 		//
 		// {{.Original.String}}
 		func (_{{$contract.Type}} *{{$contract.Type}}Transactor) {{.Normalized.Name}}(opts *bind.TransactOpts {{range .Normalized.Inputs}}, {{.Name}} {{bindtype .Type}} {{end}}) (*types.Transaction, error) {
 			return _{{$contract.Type}}.contract.Transact(opts, "{{.Original.Name}}" {{range .Normalized.Inputs}}, {{.Name}}{{end}})
 		}
 
-		// {{.Normalized.Name}} is a paid mutator transaction binding the contract method 0x{{printf "%x" .Original.Id}}.
+		// {{.Normalized.Name}} is a {{if .Payable}}payable{{else}}paid mutator{{end}} transaction binding the contract method 0x{{printf "%x" .Original.Id}}.
 		//
 		// {{.Original.String}}
 		func (_{{$contract.Type}} *{{$contract.Type}}Session) {{.Normalized.Name}}({{range $i, $_ := .Normalized.Inputs}}{{if ne $i 0}},{{end}} {{.Name}} {{bindtype .Type}} {{end}}) (*types.Transaction, error) {
 		  return _{{$contract.Type}}.Contract.{{.Normalized.Name}}(&_{{$contract.Type}}.TransactOpts {{range $i, $_ := .Normalized.Inputs}}, {{.Name}}{{end}})
 		}
 
-		// {{.Normalized.Name}} is a paid mutator transaction binding the contract method 0x{{printf "%x" .Original.Id}}.
+		// {{.Normalized.Name}} is a {{if .Payable}}payable{{else}}paid mutator{{end}} transaction binding the contract method 0x{{printf "%x" .Original.Id}}.
 		//
 		// {{.Original.String}}
 		func (_{{$contract.Type}} *{{$contract.Type}}TransactorSession) {{.Normalized.Name}}({{range $i, $_ := .Normalized.Inputs}}{{if ne $i 0}},{{end}} {{.Name}} {{bindtype .Type}} {{end}}) (*types.Transaction, error) {
@@ -286,6 +289,55 @@ package {{.Package}}
 		}
 	{{end}}
 
+	{{if .Callees}}
+		// {{.Type}}Callee is an auto generated Go binding around the other VNT contracts
+		// called by {{.Type}}, through the methods declared as calls in the ABI.
+		type {{.Type}}Callee struct {
+			contract *bind.BoundContract // Generic contract wrapper for the low level calls
+		}
+
+		// New{{.Type}}Callee creates a new instance of {{.Type}}Callee, bound to a specific
+		// contract called by {{.Type}}.
+		func New{{.Type}}Callee(address common.Address, backend bind.ContractBackend) (*{{.Type}}Callee, error) {
+			parsed, err := abi.JSON(strings.NewReader({{.Type}}ABI))
+			if err != nil {
+				return nil, err
+			}
+			callee := abi.ABI{Methods: parsed.Calls, Events: make(map[string]abi.Event), Calls: make(map[string]abi.Method)}
+			return &{{.Type}}Callee{contract: bind.NewBoundContract(address, callee, backend, backend, backend)}, nil
+		}
+
+		{{range .Callees}}
+			{{if and .Original.Const (not .Payable)}}
+				// {{.Normalized.Name}} is a free data retrieval call binding the called method 0x{{printf "%x" .Original.Id}}.
+				//
+				// {{.Original.String}}
+				func (_{{$contract.Type}} *{{$contract.Type}}Callee) {{.Normalized.Name}}(opts *bind.CallOpts {{range .Normalized.Inputs}}, {{.Name}} {{bindtype .Type}} {{end}}) ({{if .Structured}}struct{ {{range .Normalized.Outputs}}{{.Name}} {{bindtype .Type}};{{end}} },{{else}}{{range .Normalized.Outputs}}{{bindtype .Type}},{{end}}{{end}} error) {
+					{{if .Structured}}ret := new(struct{
+						{{range .Normalized.Outputs}}{{.Name}} {{bindtype .Type}}
+						{{end}}
+					}){{else}}var (
+						{{range $i, $_ := .Normalized.Outputs}}ret{{$i}} = new({{bindtype .Type}})
+						{{end}}
+					){{end}}
+					out := {{if .Structured}}ret{{else}}{{if eq (len .Normalized.Outputs) 1}}ret0{{else}}&[]interface{}{
+						{{range $i, $_ := .Normalized.Outputs}}ret{{$i}},
+						{{end}}
+					}{{end}}{{end}}
+					err := _{{$contract.Type}}.contract.Call(opts, out, "{{.Original.Name}}" {{range .Normalized.Inputs}}, {{.Name}}{{end}})
+					return {{if .Structured}}*ret,{{else}}{{range $i, $_ := .Normalized.Outputs}}*ret{{$i}},{{end}}{{end}} err
+				}
+			{{else}}
+				// {{.Normalized.Name}} is a {{if .Payable}}payable{{else}}paid mutator{{end}} transaction binding the called method 0x{{printf "%x" .Original.Id}}.
+				//
+				// {{.Original.String}}
+				func (_{{$contract.Type}} *{{$contract.Type}}Callee) {{.Normalized.Name}}(opts *bind.TransactOpts {{range .Normalized.Inputs}}, {{.Name}} {{bindtype .Type}} {{end}}) (*types.Transaction, error) {
+					return _{{$contract.Type}}.contract.Transact(opts, "{{.Original.Name}}" {{range .Normalized.Inputs}}, {{.Name}}{{end}})
+				}
+			{{end}}
+		{{end}}
+	{{end}}
+
 	{{range .Events}}
 		// {{$contract.Type}}{{.Normalized.Name}}Iterator is returned from Filter{{.Normalized.Name}} and is used to iterate over the raw logs and unpacked data for {{.Normalized.Name}} events raised by the {{$contract.Type}} contract.
 		type {{$contract.Type}}{{.Normalized.Name}}Iterator struct {
@@ -295,7 +347,7 @@ package {{.Package}}
 			event    string              // Event name to use for unpacking event data
 
 			logs chan types.Log        // Log channel receiving the found contract events
-			sub  hubble.Subscription // Subscription for errors, completion and termination
+			sub  event.Subscription  // Subscription for errors, completion and termination
 			done bool                  // Whether the subscription completed delivering logs
 			fail error                 // Occurred error to stop iteration
 		}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/vntchain/go-vnt/accounts/abi"
 	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/common/math"
 	"github.com/vntchain/go-vnt/crypto"
 )
 
@@ -41,23 +42,23 @@ func makeTopics(query ...[]interface{}) ([][]common.Hash, error) {
 			case common.Address:
 				copy(topic[common.HashLength-common.AddressLength:], rule[:])
 			case *big.Int:
-				blob := rule.Bytes()
+				blob := math.U256(new(big.Int).Set(rule)).Bytes()
 				copy(topic[common.HashLength-len(blob):], blob)
 			case bool:
 				if rule {
 					topic[common.HashLength-1] = 1
 				}
 			case int8:
-				blob := big.NewInt(int64(rule)).Bytes()
+				blob := math.U256(big.NewInt(int64(rule))).Bytes()
 				copy(topic[common.HashLength-len(blob):], blob)
 			case int16:
-				blob := big.NewInt(int64(rule)).Bytes()
+				blob := math.U256(big.NewInt(int64(rule))).Bytes()
 				copy(topic[common.HashLength-len(blob):], blob)
 			case int32:
-				blob := big.NewInt(int64(rule)).Bytes()
+				blob := math.U256(big.NewInt(int64(rule))).Bytes()
 				copy(topic[common.HashLength-len(blob):], blob)
 			case int64:
-				blob := big.NewInt(rule).Bytes()
+				blob := math.U256(big.NewInt(rule)).Bytes()
 				copy(topic[common.HashLength-len(blob):], blob)
 			case uint8:
 				blob := new(big.Int).SetUint64(uint64(rule)).Bytes()
@@ -84,7 +85,7 @@ func makeTopics(query ...[]interface{}) ([][]common.Hash, error) {
 
 				switch {
 				case val.Kind() == reflect.Array && reflect.TypeOf(rule).Elem().Kind() == reflect.Uint8:
-					reflect.Copy(reflect.ValueOf(topic[common.HashLength-val.Len():]), val)
+					reflect.Copy(reflect.ValueOf(topic[:val.Len()]), val)
 
 				default:
 					return nil, fmt.Errorf("unsupported indexed type: %T", rule)
@@ -126,19 +127,19 @@ func parseTopics(out interface{}, fields abi.Arguments, topics []common.Hash) er
 				field.Set(reflect.ValueOf(true))
 			}
 		case reflect.Int8:
-			num := new(big.Int).SetBytes(topics[0][:])
+			num := math.S256(new(big.Int).SetBytes(topics[0][:]))
 			field.Set(reflect.ValueOf(int8(num.Int64())))
 
 		case reflect.Int16:
-			num := new(big.Int).SetBytes(topics[0][:])
+			num := math.S256(new(big.Int).SetBytes(topics[0][:]))
 			field.Set(reflect.ValueOf(int16(num.Int64())))
 
 		case reflect.Int32:
-			num := new(big.Int).SetBytes(topics[0][:])
+			num := math.S256(new(big.Int).SetBytes(topics[0][:]))
 			field.Set(reflect.ValueOf(int32(num.Int64())))
 
 		case reflect.Int64:
-			num := new(big.Int).SetBytes(topics[0][:])
+			num := math.S256(new(big.Int).SetBytes(topics[0][:]))
 			field.Set(reflect.ValueOf(num.Int64()))
 
 		case reflect.Uint8:
@@ -170,13 +171,16 @@ func parseTopics(out interface{}, fields abi.Arguments, topics []common.Hash) er
 
 			case reflectBigInt:
 				num := new(big.Int).SetBytes(topics[0][:])
+				if arg.Type.T == abi.IntTy {
+					num = math.S256(num)
+				}
 				field.Set(reflect.ValueOf(num))
 
 			default:
 				// Ran out of custom types, try the crazies
 				switch {
 				case arg.Type.T == abi.FixedBytesTy:
-					reflect.Copy(field, reflect.ValueOf(topics[0][common.HashLength-arg.Type.Size:]))
+					reflect.Copy(field, reflect.ValueOf(topics[0][:arg.Type.Size]))
 
 				default:
 					return fmt.Errorf("unsupported indexed type: %v", arg.Type)
@@ -0,0 +1,147 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind_test
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vntchain/go-vnt/accounts/abi"
+	"github.com/vntchain/go-vnt/accounts/abi/bind"
+	"github.com/vntchain/go-vnt/accounts/abi/bind/backends"
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core"
+)
+
+const bankABI = `[
+	{"name":"$Bank","constant":false,"inputs":[],"outputs":[],"type":"constructor"},
+	{"name":"$deposit","constant":false,"inputs":[{"name":"memo","type":"string"}],"outputs":[],"type":"function"},
+	{"name":"$donate","constant":true,"inputs":[],"outputs":[],"type":"function"},
+	{"name":"GetBalance","constant":true,"inputs":[{"name":"addr","type":"address"}],"outputs":[{"name":"output","type":"uint256"}],"type":"function"},
+	{"name":"Deposit","anonymous":false,"inputs":[{"name":"from","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}],"type":"event"},
+	{"name":"$pay","constant":false,"inputs":[{"name":"to","type":"address"}],"outputs":[],"type":"call"},
+	{"name":"GetTokenName","constant":false,"inputs":[],"outputs":[{"name":"output","type":"string"}],"type":"call"}
+]`
+
+func TestBindWAVM(t *testing.T) {
+	code, err := bind.Bind([]string{"bank"}, []string{bankABI}, []string{"0161736d"}, "bindtest", bind.LangGo)
+	if err != nil {
+		t.Fatalf("failed to generate binding: %v", err)
+	}
+	for _, want := range []string{
+		// Payable methods are transactions, named without '$'
+		`func (_Bank *BankTransactor) Deposit(opts *bind.TransactOpts, memo string) (*types.Transaction, error)`,
+		`_Bank.contract.Transact(opts, "$deposit", memo)`,
+		`func (_Bank *BankTransactor) Donate(opts *bind.TransactOpts) (*types.Transaction, error)`,
+		`func (_Bank *BankCaller) GetBalance(opts *bind.CallOpts, addr common.Address) (*big.Int, error)`,
+		`func (_Bank *BankFilterer) FilterDeposit(opts *bind.FilterOpts, from []common.Address) (*BankDepositIterator, error)`,
+		// Calls are bound to the other contracts
+		`func NewBankCallee(address common.Address, backend bind.ContractBackend) (*BankCallee, error)`,
+		`func (_Bank *BankCallee) Pay(opts *bind.TransactOpts, to common.Address) (*types.Transaction, error)`,
+		`_Bank.contract.Transact(opts, "$pay", to)`,
+		`func (_Bank *BankCallee) GetTokenName(opts *bind.TransactOpts) (*types.Transaction, error)`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("binding has no %q", want)
+		}
+	}
+	if strings.Contains(code, "func (_Bank *BankCaller) Donate") {
+		t.Errorf("payable method is bound as a call")
+	}
+
+	// Payable and non-payable methods of the same name can't be bound
+	collided := `[
+		{"name":"$deposit","constant":false,"inputs":[],"outputs":[],"type":"function"},
+		{"name":"deposit","constant":false,"inputs":[],"outputs":[],"type":"function"}
+	]`
+	if _, err := bind.Bind([]string{"bank"}, []string{collided}, []string{""}, "bindtest", bind.LangGo); err == nil {
+		t.Errorf("binding of collided methods succeeded")
+	}
+}
+
+func TestBoundContractWAVM(t *testing.T) {
+	dir := filepath.Join("..", "..", "..", "core", "wavm", "tests", "erc20")
+	code, err := ioutil.ReadFile(filepath.Join(dir, "TokenERC20.compress"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	abijson, err := ioutil.ReadFile(filepath.Join(dir, "abi.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := abi.JSON(strings.NewReader(string(abijson)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := bind.NewKeyedTransactor(testKey, chainID)
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		auth.From: {Balance: new(big.Int).Mul(big.NewInt(1e9), big.NewInt(1e18))},
+	})
+
+	// Deploy by the compressed code with constructor arguments
+	addr, _, contract, err := bind.DeployContract(auth, parsed, code, backend, big.NewInt(1000), "bitcoin", "BTC")
+	if err != nil {
+		t.Fatalf("failed to deploy contract: %v", err)
+	}
+	backend.Commit()
+	if code, _ := backend.CodeAt(context.Background(), addr, nil); len(code) == 0 {
+		t.Fatalf("no code at deployed address %x", addr)
+	}
+	var name string
+	if err := contract.Call(nil, &name, "GetTokenName"); err != nil || name != "bitcoin" {
+		t.Fatalf("token name mismatch: have %q, %v, want bitcoin", name, err)
+	}
+
+	to := common.HexToAddress("0x01")
+	if _, err := contract.Transact(auth, "transfer", to, big.NewInt(10)); err != nil {
+		t.Fatalf("failed to transfer: %v", err)
+	}
+	backend.Commit()
+	var amount *big.Int
+	if err := contract.Call(nil, &amount, "GetAmount", to); err != nil || amount.Cmp(big.NewInt(10)) != 0 {
+		t.Fatalf("amount mismatch: have %v, %v, want 10", amount, err)
+	}
+
+	// Value can only be transferred to payable methods
+	valued := *auth
+	valued.Value = big.NewInt(1)
+	if _, err := contract.Transact(&valued, "transfer", to, big.NewInt(10)); err != bind.ErrNotPayable {
+		t.Errorf("transfer value error mismatch: have %v, want %v", err, bind.ErrNotPayable)
+	}
+
+	// Decode the event log emitted by WAVM
+	logs, sub, err := contract.FilterLogs(nil, "Transfer", []interface{}{auth.From})
+	if err != nil {
+		t.Fatalf("failed to filter logs: %v", err)
+	}
+	defer sub.Unsubscribe()
+	event := new(struct {
+		From  common.Address
+		To    common.Address
+		Value *big.Int
+	})
+	if err := contract.UnpackLog(event, "Transfer", <-logs); err != nil {
+		t.Fatalf("failed to unpack log: %v", err)
+	}
+	if event.From != auth.From || event.To != to || event.Value.Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("event mismatch: have %+v", event)
+	}
+}
@@ -25,7 +25,9 @@ import (
 	"strings"
 
 	"github.com/vntchain/go-vnt/accounts/abi/bind"
+	"github.com/vntchain/go-vnt/common"
 	"github.com/vntchain/go-vnt/common/compiler"
+	"github.com/vntchain/go-vnt/core/wavm/utils"
 )
 
 var (
@@ -33,6 +35,9 @@ var (
 	binFlag = flag.String("bin", "", "Path to the VNT contract bytecode (generate deploy method)")
 	typFlag = flag.String("type", "", "Struct name for the binding (default = package name)")
 
+	wasmFlag = flag.String("wasm", "", "Path to the WAVM contract wasm code, compressed with the ABI (--abi) to deploy")
+	codeFlag = flag.String("code", "", "Path to the compressed WAVM contract code with embedded ABI to build and bind")
+
 	solFlag  = flag.String("sol", "", "Path to the VNT contract Solidity source to build and bind")
 	solcFlag = flag.String("solc", "solc", "Solidity compiler to use if source builds are requested")
 	excFlag  = flag.String("exc", "", "Comma separated types to exclude from binding")
@@ -46,11 +51,17 @@ func main() {
 	// Parse and ensure all needed inputs are specified
 	flag.Parse()
 
-	if *abiFlag == "" && *solFlag == "" {
-		fmt.Printf("No contract ABI (--abi) or Solidity source (--sol) specified\n")
+	if *abiFlag == "" && *solFlag == "" && *codeFlag == "" {
+		fmt.Printf("No contract ABI (--abi), Solidity source (--sol) or compressed WAVM code (--code) specified\n")
+		os.Exit(-1)
+	} else if (*abiFlag != "" || *binFlag != "" || *wasmFlag != "" || *typFlag != "") && *solFlag != "" {
+		fmt.Printf("Contract ABI (--abi), bytecode (--bin), wasm (--wasm) and type (--type) flags are mutually exclusive with the Solidity source (--sol) flag\n")
+		os.Exit(-1)
+	} else if (*abiFlag != "" || *binFlag != "" || *wasmFlag != "" || *solFlag != "") && *codeFlag != "" {
+		fmt.Printf("Contract ABI (--abi), bytecode (--bin), wasm (--wasm) and Solidity source (--sol) flags are mutually exclusive with the compressed WAVM code (--code) flag\n")
 		os.Exit(-1)
-	} else if (*abiFlag != "" || *binFlag != "" || *typFlag != "") && *solFlag != "" {
-		fmt.Printf("Contract ABI (--abi), bytecode (--bin) and type (--type) flags are mutually exclusive with the Solidity source (--sol) flag\n")
+	} else if *binFlag != "" && *wasmFlag != "" {
+		fmt.Printf("Contract bytecode (--bin) and wasm (--wasm) flags are mutually exclusive\n")
 		os.Exit(-1)
 	}
 	if *pkgFlag == "" {
@@ -109,6 +120,26 @@ func main() {
 			nameParts := strings.Split(name, ":")
 			types = append(types, nameParts[len(nameParts)-1])
 		}
+	} else if *codeFlag != "" {
+		// Load the ABI embedded in the compressed WAVM code, which is deployed as is
+		code, err := ioutil.ReadFile(*codeFlag)
+		if err != nil {
+			fmt.Printf("Failed to read input compressed code: %v\n", err)
+			os.Exit(-1)
+		}
+		wasmcode, _, err := utils.DecodeContractCode(code)
+		if err != nil {
+			fmt.Printf("Failed to decode input compressed code: %v\n", err)
+			os.Exit(-1)
+		}
+		abis = append(abis, string(wasmcode.Abi))
+		bins = append(bins, common.Bytes2Hex(code))
+
+		kind := *typFlag
+		if kind == "" {
+			kind = *pkgFlag
+		}
+		types = append(types, kind)
 	} else {
 		// Otherwise load up the ABI, optional bytecode and type name from the parameters
 		abi, err := ioutil.ReadFile(*abiFlag)
@@ -125,6 +156,15 @@ func main() {
 				os.Exit(-1)
 			}
 		}
+		// WAVM contracts are deployed by the wasm code compressed with the ABI
+		if *wasmFlag != "" {
+			wasm, err := ioutil.ReadFile(*wasmFlag)
+			if err != nil {
+				fmt.Printf("Failed to read input wasm: %v\n", err)
+				os.Exit(-1)
+			}
+			bin = []byte(common.Bytes2Hex(utils.CompressWasmAndAbi(abi, wasm, nil)))
+		}
 		bins = append(bins, string(bin))
 
 		kind := *typFlag
@@ -156,6 +156,8 @@ func init() {
 		versionCommand,
 		bugCommand,
 		licenseCommand,
+		// See wasmcmd.go:
+		wasmCommand,
 		// See config.go
 		dumpConfigCommand,
 	}
@@ -0,0 +1,360 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of go-vnt.
+//
+// go-vnt is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-vnt is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-vnt. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vntchain/go-vnt/accounts/abi"
+	"github.com/vntchain/go-vnt/cmd/utils"
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/wavm"
+	"github.com/vntchain/go-vnt/core/wavm/contract"
+	"github.com/vntchain/go-vnt/core/wavm/gas"
+	wavmutils "github.com/vntchain/go-vnt/core/wavm/utils"
+	"github.com/vntchain/go-vnt/params"
+	"github.com/vntchain/go-vnt/vntclient"
+	"github.com/vntchain/vnt-wasm/disasm"
+	"github.com/vntchain/vnt-wasm/wasm"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+var (
+	wasmAbiFlag = cli.StringFlag{
+		Name:  "abi",
+		Usage: "Path to the contract ABI json, required for the raw wasm code",
+	}
+	wasmOutFlag = cli.StringFlag{
+		Name:  "out",
+		Usage: "Output file (pack) or directory (unpack)",
+	}
+	wasmNoFloatFlag = cli.BoolFlag{
+		Name:  "nofloat",
+		Usage: "Reject the floating point types and instructions",
+	}
+	wasmBlockFlag = cli.Int64Flag{
+		Name:  "block",
+		Value: -1,
+		Usage: "Block number deciding the available env functions (default = all)",
+	}
+	wasmGasFlag = cli.BoolFlag{
+		Name:  "gas",
+		Usage: "Print the code with the gas counter injected",
+	}
+	wasmRPCFlag = cli.StringFlag{
+		Name:  "rpc",
+		Usage: "API endpoint of the node to fetch the deployed code from",
+	}
+	wasmAddressFlag = cli.StringFlag{
+		Name:  "address",
+		Usage: "Address of the contract to fetch from the node (--rpc)",
+	}
+	wasmCommand = cli.Command{
+		Name:     "wasm",
+		Usage:    "Inspect, validate and package wasm contracts",
+		Category: "CONTRACT COMMANDS",
+		Description: `
+
+Validate the wasm contracts against the WAVM rules, pack the wasm code with the
+ABI into the deployable contract code, unpack the contract code deployed on a
+node, and disassemble the code executed by WAVM.
+
+The contract code is either the raw wasm code with the ABI given by --abi, or
+the packed code containing both, in binary or in hex.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "validate",
+				Usage:     "Validate the contract against the WAVM rules",
+				Action:    utils.MigrateFlags(wasmValidate),
+				ArgsUsage: "<codeFile>",
+				Flags: []cli.Flag{
+					wasmAbiFlag,
+					wasmNoFloatFlag,
+					wasmBlockFlag,
+				},
+				Description: `
+    gvnt wasm validate [--abi <abiFile>] <codeFile>
+
+Checks that the contract imports only the env functions, the memory and table
+are within the limits of WAVM, and the methods of ABI are exported. With
+--nofloat the floating point types and instructions are rejected too.`,
+			},
+			{
+				Name:      "pack",
+				Usage:     "Pack the wasm code and ABI into the contract code",
+				Action:    utils.MigrateFlags(wasmPack),
+				ArgsUsage: "<wasmFile>",
+				Flags: []cli.Flag{
+					wasmAbiFlag,
+					wasmOutFlag,
+					wasmNoFloatFlag,
+					wasmBlockFlag,
+				},
+				Description: `
+    gvnt wasm pack --abi <abiFile> [--out <codeFile>] <wasmFile>
+
+Validates the contract and writes the packed code to <codeFile>, which can be
+deployed with the constructor input appended. Without --out the code is printed
+in hex.`,
+			},
+			{
+				Name:      "unpack",
+				Usage:     "Unpack the contract code into the wasm code and ABI",
+				Action:    utils.MigrateFlags(wasmUnpack),
+				ArgsUsage: "[<codeFile>]",
+				Flags: []cli.Flag{
+					wasmOutFlag,
+					wasmRPCFlag,
+					wasmAddressFlag,
+				},
+				Description: `
+    gvnt wasm unpack [--out <dir>] <codeFile>
+    gvnt wasm unpack [--out <dir>] --rpc <endpoint> --address <address>
+
+Writes the wasm code, ABI and the precompiled code if any into <dir>, which is
+the current directory by default. The contract code is read from <codeFile>, or
+fetched from the node by its address.`,
+			},
+			{
+				Name:      "disasm",
+				Usage:     "Disassemble the functions of the contract",
+				Action:    utils.MigrateFlags(wasmDisasm),
+				ArgsUsage: "<codeFile>",
+				Flags: []cli.Flag{
+					wasmAbiFlag,
+					wasmGasFlag,
+					wasmNoFloatFlag,
+					wasmBlockFlag,
+				},
+				Description: `
+    gvnt wasm disasm [--gas] <codeFile>
+
+Prints the instructions of the functions defined by the contract. With --gas
+the code is printed as executed by WAVM, with the gas counter injected.`,
+			},
+		},
+	}
+)
+
+// readContractCode reads the code file in binary or hex. The wasm code is
+// returned with the ABI, which is read from --abi for the raw wasm code.
+func readContractCode(ctx *cli.Context) (contract.WasmCode, abi.ABI, error) {
+	var code contract.WasmCode
+	if len(ctx.Args()) != 1 {
+		return code, abi.ABI{}, fmt.Errorf("expected the code file as the only argument")
+	}
+	data, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		return code, abi.ABI{}, err
+	}
+	data = decodeHexCode(data)
+	if magic, _ := wavmutils.ReadMagic(data); magic == wavmutils.MAGIC {
+		if code, _, err = wavmutils.DecodeContractCode(data); err != nil {
+			return code, abi.ABI{}, err
+		}
+	} else {
+		code.Code = data
+	}
+	if file := ctx.String(wasmAbiFlag.Name); file != "" {
+		if code.Abi, err = ioutil.ReadFile(file); err != nil {
+			return code, abi.ABI{}, err
+		}
+	}
+	if len(code.Abi) == 0 {
+		return code, abi.ABI{}, fmt.Errorf("ABI of the raw wasm code is required (--abi)")
+	}
+	contractAbi, err := wavm.GetAbi(code.Abi)
+	if err != nil {
+		return code, abi.ABI{}, fmt.Errorf("invalid ABI: %v", err)
+	}
+	return code, contractAbi, nil
+}
+
+// decodeHexCode decodes the code file if it's in hex, as printed by pack.
+func decodeHexCode(data []byte) []byte {
+	text := strings.TrimPrefix(strings.TrimSpace(string(data)), "0x")
+	if code, err := hex.DecodeString(text); err == nil && len(code) > 0 {
+		return code
+	}
+	return data
+}
+
+// readWasmModule reads the module of the contract as WAVM does at the block.
+func readWasmModule(ctx *cli.Context, code contract.WasmCode, contractAbi abi.ABI) (*wasm.Module, error) {
+	number := big.NewInt(math.MaxInt64)
+	if block := ctx.Int64(wasmBlockFlag.Name); block >= 0 {
+		number = big.NewInt(block)
+	}
+	return wavm.ReadModule(code.Code, contractAbi, params.MainnetChainConfig, number)
+}
+
+func validateContract(ctx *cli.Context, code contract.WasmCode, contractAbi abi.ABI) error {
+	module, err := readWasmModule(ctx, code, contractAbi)
+	if err != nil {
+		return err
+	}
+	return wavm.ValidateModule(module, contractAbi, wavm.Config{DisableFloatingPoint: ctx.Bool(wasmNoFloatFlag.Name)})
+}
+
+func wasmValidate(ctx *cli.Context) error {
+	code, contractAbi, err := readContractCode(ctx)
+	if err != nil {
+		utils.Fatalf("Failed to read contract: %v", err)
+	}
+	if err := validateContract(ctx, code, contractAbi); err != nil {
+		utils.Fatalf("Invalid contract: %v", err)
+	}
+	fmt.Println("Contract is valid")
+	return nil
+}
+
+func wasmPack(ctx *cli.Context) error {
+	code, contractAbi, err := readContractCode(ctx)
+	if err != nil {
+		utils.Fatalf("Failed to read contract: %v", err)
+	}
+	if err := validateContract(ctx, code, contractAbi); err != nil {
+		utils.Fatalf("Invalid contract: %v", err)
+	}
+	packed := wavmutils.CompressWasmAndAbi(code.Abi, code.Code, code.Compiled)
+	out := ctx.String(wasmOutFlag.Name)
+	if out == "" {
+		fmt.Println(common.ToHex(packed))
+		return nil
+	}
+	if err := ioutil.WriteFile(out, packed, 0644); err != nil {
+		utils.Fatalf("Failed to write contract code: %v", err)
+	}
+	return nil
+}
+
+func wasmUnpack(ctx *cli.Context) error {
+	var (
+		data []byte
+		err  error
+	)
+	if endpoint := ctx.String(wasmRPCFlag.Name); endpoint != "" {
+		if !common.IsHexAddress(ctx.String(wasmAddressFlag.Name)) {
+			utils.Fatalf("Invalid contract address: %q", ctx.String(wasmAddressFlag.Name))
+		}
+		client, err := vntclient.Dial(endpoint)
+		if err != nil {
+			utils.Fatalf("Failed to attach to node: %v", err)
+		}
+		data, err = client.CodeAt(context.Background(), common.HexToAddress(ctx.String(wasmAddressFlag.Name)), nil)
+		if err != nil {
+			utils.Fatalf("Failed to fetch contract code: %v", err)
+		}
+		if len(data) == 0 {
+			utils.Fatalf("No contract code at %s", ctx.String(wasmAddressFlag.Name))
+		}
+	} else {
+		if len(ctx.Args()) != 1 {
+			utils.Fatalf("This command requires the code file or --rpc and --address")
+		}
+		if data, err = ioutil.ReadFile(ctx.Args().First()); err != nil {
+			utils.Fatalf("Failed to read contract code: %v", err)
+		}
+		data = decodeHexCode(data)
+	}
+	code, input, err := wavmutils.DecodeContractCode(data)
+	if err != nil {
+		utils.Fatalf("Failed to decode contract code: %v", err)
+	}
+
+	dir := ctx.String(wasmOutFlag.Name)
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		utils.Fatalf("Failed to create output directory: %v", err)
+	}
+	files := map[string][]byte{
+		"code.wasm":     code.Code,
+		"abi.json":      code.Abi,
+		"compiled.json": code.Compiled,
+	}
+	for name, content := range files {
+		if len(content) == 0 {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			utils.Fatalf("Failed to write %s: %v", path, err)
+		}
+		fmt.Println("Wrote", path)
+	}
+	// The creation code of contracts is followed by the constructor input
+	if len(input) > 0 {
+		fmt.Println("Input:", common.ToHex(input))
+	}
+	return nil
+}
+
+func wasmDisasm(ctx *cli.Context) error {
+	code, contractAbi, err := readContractCode(ctx)
+	if err != nil {
+		utils.Fatalf("Failed to read contract: %v", err)
+	}
+	module, err := readWasmModule(ctx, code, contractAbi)
+	if err != nil {
+		utils.Fatalf("Failed to read module: %v", err)
+	}
+	names := make(map[uint32]string)
+	if module.Export != nil {
+		for name, entry := range module.Export.Entries {
+			if entry.Kind == wasm.ExternalFunction {
+				names[entry.Index] = name
+			}
+		}
+	}
+	rule := gas.NewGas(ctx.Bool(wasmNoFloatFlag.Name))
+	for i, fn := range module.FunctionIndexSpace {
+		if fn.IsHost() {
+			continue
+		}
+		d, err := disasm.Disassemble(fn, module)
+		if err != nil {
+			utils.Fatalf("Failed to disassemble function %d: %v", i, err)
+		}
+		instrs := d.Code
+		if ctx.Bool(wasmGasFlag.Name) {
+			instrs = gas.InjectCounter(instrs, module, rule)
+		}
+		header := fmt.Sprintf("func[%d]", i)
+		if name, ok := names[uint32(i)]; ok {
+			header += " " + name
+		}
+		fmt.Println(header, fn.Sig)
+		for _, instr := range instrs {
+			fmt.Print("    ", instr.Op.Name)
+			for _, imm := range instr.Immediates {
+				fmt.Print(" ", imm)
+			}
+			fmt.Println()
+		}
+	}
+	return nil
+}
@@ -142,6 +142,42 @@ func (w *wizard) manageGenesis() {
 		fmt.Printf("Which block should Hubble come into effect? (default = %v)\n", w.conf.Genesis.Config.HubbleBlock)
 		w.conf.Genesis.Config.HubbleBlock = w.readDefaultBigInt(w.conf.Genesis.Config.HubbleBlock)
 
+		fmt.Println()
+		fmt.Printf("Which block should Crypto come into effect? (default = %v)\n", w.conf.Genesis.Config.CryptoBlock)
+		w.conf.Genesis.Config.CryptoBlock = w.readDefaultBigInt(w.conf.Genesis.Config.CryptoBlock)
+
+		fmt.Println()
+		fmt.Printf("Which block should Slash come into effect? (default = %v)\n", w.conf.Genesis.Config.SlashBlock)
+		w.conf.Genesis.Config.SlashBlock = w.readDefaultBigInt(w.conf.Genesis.Config.SlashBlock)
+
+		fmt.Println()
+		fmt.Printf("Which block should StaticCall come into effect? (default = %v)\n", w.conf.Genesis.Config.StaticCallBlock)
+		w.conf.Genesis.Config.StaticCallBlock = w.readDefaultBigInt(w.conf.Genesis.Config.StaticCallBlock)
+
+		fmt.Println()
+		fmt.Printf("Which block should Handover come into effect? (default = %v)\n", w.conf.Genesis.Config.HandoverBlock)
+		w.conf.Genesis.Config.HandoverBlock = w.readDefaultBigInt(w.conf.Genesis.Config.HandoverBlock)
+
+		fmt.Println()
+		fmt.Printf("Which block should CreateContract come into effect? (default = %v)\n", w.conf.Genesis.Config.CreateContractBlock)
+		w.conf.Genesis.Config.CreateContractBlock = w.readDefaultBigInt(w.conf.Genesis.Config.CreateContractBlock)
+
+		fmt.Println()
+		fmt.Printf("Which block should RichEvent come into effect? (default = %v)\n", w.conf.Genesis.Config.RichEventBlock)
+		w.conf.Genesis.Config.RichEventBlock = w.readDefaultBigInt(w.conf.Genesis.Config.RichEventBlock)
+
+		fmt.Println()
+		fmt.Printf("Which block should Unbonding come into effect? (default = %v)\n", w.conf.Genesis.Config.UnbondingBlock)
+		w.conf.Genesis.Config.UnbondingBlock = w.readDefaultBigInt(w.conf.Genesis.Config.UnbondingBlock)
+
+		fmt.Println()
+		fmt.Printf("Which block should Operator come into effect? (default = %v)\n", w.conf.Genesis.Config.OperatorBlock)
+		w.conf.Genesis.Config.OperatorBlock = w.readDefaultBigInt(w.conf.Genesis.Config.OperatorBlock)
+
+		fmt.Println()
+		fmt.Printf("Which block should ElectionQuery come into effect? (default = %v)\n", w.conf.Genesis.Config.ElectionQueryBlock)
+		w.conf.Genesis.Config.ElectionQueryBlock = w.readDefaultBigInt(w.conf.Genesis.Config.ElectionQueryBlock)
+
 		out, _ := json.MarshalIndent(w.conf.Genesis.Config, "", "  ")
 		fmt.Printf("Chain configuration updated:\n\n%s\n", out)
 
@@ -72,10 +72,10 @@ type Engine interface {
 	VerifySeal(chain ChainReader, header *types.Header) error
 
 	// VerifyWitnesses verify witnesses list for DPos
-	VerifyWitnesses(header *types.Header, db *state.StateDB, parent *types.Header) error
+	VerifyWitnesses(chain ChainReader, header *types.Header, db *state.StateDB, parent *types.Header) error
 
 	// VerifyBftSig verify the given block's commit message
-	VerifyCommitMsg(block *types.Block) error
+	VerifyCommitMsg(chain ChainReader, block *types.Block) error
 
 	// Prepare initializes the consensus fields of a block header according to the
 	// rules of a particular engine. The changes are executed inline.
@@ -99,6 +99,10 @@ type Engine interface {
 	// HandleBftMsg is doing bft consensus
 	HandleBftMsg(chain ChainReader, msg types.ConsensusMsg)
 
+	// Rewards returns the record of rewards granted when the given header was
+	// finalized, nil if unknown.
+	Rewards(header *types.Header) *types.BlockRewards
+
 	// APIs returns the RPC APIs this consensus engine provides.
 	APIs(chain ChainReader) []rpc.API
 }
@@ -17,11 +17,16 @@
 package dpos
 
 import (
+	"fmt"
+	"math/big"
+
 	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/common/hexutil"
 	"github.com/vntchain/go-vnt/consensus"
+	"github.com/vntchain/go-vnt/core/rawdb"
 	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/rlp"
 	"github.com/vntchain/go-vnt/rpc"
-	"math/big"
 )
 
 // API is a user facing RPC API to allow controlling the signer and voting
@@ -58,6 +63,151 @@ func (api *API) GetSignersAtHash(hash common.Hash) ([]common.Address, error) {
 	return header.Witnesses, nil
 }
 
+// GetWitnessRewards retrieves the witness list and the rewards granted at the
+// specified block.
+func (api *API) GetWitnessRewards(number *rpc.BlockNumber) (*rpc.WitnessRewards, error) {
+	header := api.headerByNumber(number)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.witnessRewards(header)
+}
+
+// GetWitnessRewardsAtHash retrieves the witness list and the rewards granted
+// at the given block.
+func (api *API) GetWitnessRewardsAtHash(hash common.Hash) (*rpc.WitnessRewards, error) {
+	header := api.chain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.witnessRewards(header)
+}
+
+// witnessRewards returns the rewards granted in header from the record of
+// rewards, which is written when the block is inserted.
+func (api *API) witnessRewards(header *types.Header) (*rpc.WitnessRewards, error) {
+	result := &rpc.WitnessRewards{
+		Number:    (*hexutil.Big)(header.Number),
+		Hash:      header.Hash(),
+		Producer:  header.Coinbase,
+		Witnesses: header.Witnesses,
+		Rewards:   make(map[common.Address]*hexutil.Big),
+	}
+	// No reward in genesis
+	if header.Number.Sign() == 0 {
+		return result, nil
+	}
+
+	record := api.readRewards(header)
+	if record == nil {
+		return nil, errUnknownRewards
+	}
+	for _, r := range record.Rewards {
+		total := new(big.Int).Set(r.Amount)
+		if prev, ok := result.Rewards[r.Candidate]; ok {
+			total.Add(total, prev.ToInt())
+		}
+		result.Rewards[r.Candidate] = (*hexutil.Big)(total)
+	}
+	return result, nil
+}
+
+// maxRewardsRange is the maximum number of blocks queried by GetRewardsRange.
+const maxRewardsRange = 1024
+
+// GetRewards retrieves the record of rewards granted at the specified block,
+// including who got how much for which reason, and the rest bounty.
+func (api *API) GetRewards(number *rpc.BlockNumber) (*rpc.BlockRewards, error) {
+	header := api.headerByNumber(number)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.blockRewards(header), nil
+}
+
+// GetRewardsAtHash retrieves the record of rewards granted at the given block.
+func (api *API) GetRewardsAtHash(hash common.Hash) (*rpc.BlockRewards, error) {
+	header := api.chain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.blockRewards(header), nil
+}
+
+// GetRewardsRange retrieves the records of rewards granted in the blocks from
+// number from to number to, both included. The blocks without record are
+// skipped.
+func (api *API) GetRewardsRange(from rpc.BlockNumber, to rpc.BlockNumber) ([]*rpc.BlockRewards, error) {
+	last := api.headerByNumber(&to)
+	if last == nil {
+		return nil, errUnknownBlock
+	}
+	first := api.headerByNumber(&from)
+	if first == nil {
+		return nil, errUnknownBlock
+	}
+	begin, end := first.Number.Uint64(), last.Number.Uint64()
+	if begin > end {
+		return nil, fmt.Errorf("invalid range: from %d is greater than to %d", begin, end)
+	}
+	if end-begin >= maxRewardsRange {
+		return nil, fmt.Errorf("range is too large: %d blocks, max %d", end-begin+1, maxRewardsRange)
+	}
+
+	result := make([]*rpc.BlockRewards, 0, end-begin+1)
+	for n := begin; n <= end; n++ {
+		header := api.chain.GetHeaderByNumber(n)
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		if rewards := api.blockRewards(header); rewards != nil {
+			result = append(result, rewards)
+		}
+	}
+	return result, nil
+}
+
+// headerByNumber retrieves the header of the requested block number, or the
+// current header if none requested.
+func (api *API) headerByNumber(number *rpc.BlockNumber) *types.Header {
+	if number == nil || *number == rpc.LatestBlockNumber || *number == rpc.PendingBlockNumber {
+		return api.chain.CurrentHeader()
+	}
+	return api.chain.GetHeaderByNumber(uint64(number.Int64()))
+}
+
+// readRewards reads the record of rewards granted in header from database.
+func (api *API) readRewards(header *types.Header) *types.BlockRewards {
+	if api.dpos.db == nil {
+		return nil
+	}
+	return rawdb.ReadRewards(api.dpos.db, header.Hash(), header.Number.Uint64())
+}
+
+// blockRewards returns the record of rewards granted in header for rpc, nil if
+// not recorded.
+func (api *API) blockRewards(header *types.Header) *rpc.BlockRewards {
+	record := api.readRewards(header)
+	if record == nil {
+		return nil
+	}
+	result := &rpc.BlockRewards{
+		Number:     (*hexutil.Big)(header.Number),
+		Hash:       header.Hash(),
+		Rewards:    make([]rpc.Reward, len(record.Rewards)),
+		RestBounty: (*hexutil.Big)(record.RestBounty),
+	}
+	for i, r := range record.Rewards {
+		result.Rewards[i] = rpc.Reward{
+			Candidate:   r.Candidate,
+			Beneficiary: r.Beneficiary,
+			Type:        r.Type.String(),
+			Amount:      (*hexutil.Big)(r.Amount),
+		}
+	}
+	return result
+}
+
 func (api *API) GetAllMessage() []types.ConsensusMsg {
 	msgs := api.dpos.bft.roundMp.getAllMsgOf(api.dpos.bft.h, api.dpos.bft.r)
 	return msgs
@@ -93,6 +243,21 @@ func (api *API) GetCommitMsgs() []*types.CommitMsg {
 	return result
 }
 
+// GetEquivocations returns the rlp encoded evidences of witness equivocation, which
+// can be reported to the election contract by reportEquivocation.
+func (api *API) GetEquivocations() ([]hexutil.Bytes, error) {
+	evidences := api.dpos.Equivocations()
+	result := make([]hexutil.Bytes, 0, len(evidences))
+	for _, e := range evidences {
+		enc, err := rlp.EncodeToBytes(e)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, enc)
+	}
+	return result, nil
+}
+
 func (api *API) GetCurrentStep() uint32 {
 	return api.dpos.bft.step
 }
@@ -91,7 +91,7 @@ func (b *BftManager) startPrePrepare(block *types.Block) {
 
 	// startPrePrepare may be execute before newRound, so calling handleBftMsg
 	// to check round
-	go b.handleBftMsg(prePreMsg)
+	b.dp.spawn(func() { b.handleBftMsg(prePreMsg) })
 }
 
 func (b *BftManager) handleBftMsg(msg types.ConsensusMsg) error {
@@ -127,7 +127,7 @@ func (b *BftManager) handleBftMsg(msg types.ConsensusMsg) error {
 			return err
 		}
 		if prePrepareMsg, ok := msg.(*types.PreprepareMsg); ok {
-			go b.startSync(prePrepareMsg.Block)
+			b.dp.spawn(func() { b.startSync(prePrepareMsg.Block) })
 		}
 		return nil
 	} else if blkNumCmp < 0 {
@@ -139,6 +139,10 @@ func (b *BftManager) handleBftMsg(msg types.ConsensusMsg) error {
 	if msgRound < b.r {
 		return fmt.Errorf("the round of msg is lower than current round, msg round :%d, current round : %d", msgRound, b.r)
 	} else if msgRound > b.r {
+		// Round change msg of higher round is verified and counted right now
+		if rcMsg, ok := msg.(*types.RoundChangeMsg); ok {
+			return b.handleRoundChangeMsg(rcMsg)
+		}
 		if err := b.mp.addMsg(msg); err != nil {
 			log.Error("add msg to msg pool error", "err", err)
 			return err
@@ -153,6 +157,9 @@ func (b *BftManager) handleBftMsg(msg types.ConsensusMsg) error {
 		return b.handlePrepareMsg(msg.(*types.PrepareMsg))
 	case types.BftCommitMessage:
 		return b.handleCommitMsg(msg.(*types.CommitMsg))
+	case types.BftRoundChangeMessage:
+		// Already in this round
+		return nil
 	default:
 		log.Error("unknown bft message", "type", msgType.String())
 		return fmt.Errorf("unknown bft message type: %s", msgType.String())
@@ -348,6 +355,62 @@ func (b *BftManager) handleCommitMsg(msg *types.CommitMsg) error {
 	return b.tryWriteBlockStep()
 }
 
+// handleRoundChangeMsg save the round change msg of a higher round, and switch to
+// that round if a quorum of witnesses have abandoned the current round.
+// Caller make sure has the newRoundRWLock.
+func (b *BftManager) handleRoundChangeMsg(msg *types.RoundChangeMsg) error {
+	if err := b.verifyRoundChangeMsg(msg); err != nil {
+		log.Debug("Round change msg is invalid", "error", err)
+		return err
+	}
+	if err := b.mp.addMsg(msg); err != nil {
+		log.Debug("failed to add round change msg", "height", b.h, "round", msg.Round, "err", err)
+		return err
+	}
+
+	b.tryRoundChange(msg.Round)
+	return nil
+}
+
+// tryRoundChange switch to round r of current height, if there are round change msg
+// of a quorum of witnesses. The round change msg in msg pool may be saved before
+// switching to current height, so verify them again.
+// Caller make sure has the newRoundRWLock.
+func (b *BftManager) tryRoundChange(r uint32) bool {
+	senders := make(map[common.Address]struct{})
+	for _, m := range b.mp.getRoundChangeMsgs(b.h, r) {
+		if err := b.verifyRoundChangeMsg(m); err == nil {
+			senders[m.Sender] = struct{}{}
+		}
+	}
+	if len(senders) < b.quorum {
+		return false
+	}
+
+	log.Debug("Witnesses agree to change round", "h", b.h.String(), "from", b.r, "to", r)
+	// Witness list is not changed in the same height
+	h := b.h
+	b.dp.spawn(func() { b.newRound(h, r, nil) })
+	return true
+}
+
+// sendRoundChange tell other witnesses, the previous round of height h is abandoned, and
+// switched to round r.
+func (b *BftManager) sendRoundChange(h *big.Int, r uint32) {
+	b.newRoundRWLock.RLock()
+	defer b.newRoundRWLock.RUnlock()
+
+	// Switched again, or not witness
+	if b.h.Cmp(h) != 0 || b.r != r || !b.validWitness(b.coinBase) {
+		return
+	}
+	msg, err := b.makeRoundChangeMsg(h, r)
+	if err != nil {
+		return
+	}
+	b.sendMsg(msg)
+}
+
 // writeBlock to block chain
 func (b *BftManager) writeBlockWithSig(msg *types.PreprepareMsg, cmtMsg []*types.CommitMsg) error {
 	block := msg.Block
@@ -366,8 +429,19 @@ func (b *BftManager) newRound(h *big.Int, r uint32, witList []common.Address) {
 	log.Trace("New round switch start")
 	b.newRoundRWLock.Lock()
 
+	// Round of the same height may be switched by round change msg before the
+	// local timer, never go back
+	sameHeight := b.h.Cmp(h) == 0
+	if sameHeight && r <= b.r {
+		b.newRoundRWLock.Unlock()
+		log.Trace("New round switch skipped", "h", h.String(), "r", r, "current round", b.r)
+		return
+	}
+	// Current round is abandoned before writing block
+	abandoned := sameHeight && atomic.LoadUint32(&b.step) != done
+
 	// Update witness list
-	if b.h.Cmp(h) != 0 {
+	if !sameHeight {
 		b.witnessList = make(map[common.Address]struct{})
 		for _, wit := range witList {
 			b.witnessList[wit] = struct{}{}
@@ -388,17 +462,27 @@ func (b *BftManager) newRound(h *big.Int, r uint32, witList []common.Address) {
 	log.Trace("New round switch finish", "h", b.h.String(), "r", b.r, "time", time.Now().Unix())
 
 	// New round switch finished, must return right now
-	go b.importCurRoundMsg()
+	if abandoned {
+		b.dp.spawn(func() { b.sendRoundChange(h, r) })
+	}
+	b.dp.spawn(b.importCurRoundMsg)
 }
 
-// importCurRoundMsg import consensus messages, but can not directly import to round msg pool
+// importCurRoundMsg import consensus messages, but can not directly import to round msg pool.
+// And switch to the highest round, which witnesses have agreed to change to.
 func (b *BftManager) importCurRoundMsg() {
 	b.newRoundRWLock.RLock()
 	msg := b.mp.getAllMsgOf(b.h, b.r)
+	for _, r := range b.mp.getRoundChangeRounds(b.h, b.r) {
+		if b.tryRoundChange(r) {
+			break
+		}
+	}
 	b.newRoundRWLock.RUnlock()
 	for _, m := range msg {
 		log.Trace("Import Msg", "type", m.Type(), "hash", m.Hash())
-		go b.handleBftMsg(m)
+		m := m
+		b.dp.spawn(func() { b.handleBftMsg(m) })
 	}
 }
 
@@ -422,6 +506,26 @@ func (b *BftManager) validWitness(wit common.Address) bool {
 	return ok
 }
 
+// equivocations returns the evidences of equivocation found in message pools.
+func (b *BftManager) equivocations() []*types.Equivocation {
+	evidences := b.roundMp.getEvidences()
+	seen := make(map[common.Hash]struct{}, len(evidences))
+	for _, e := range evidences {
+		seen[e.Hash()] = struct{}{}
+	}
+	for _, e := range b.mp.getEvidences() {
+		if _, ok := seen[e.Hash()]; !ok {
+			evidences = append(evidences, e)
+		}
+	}
+	return evidences
+}
+
+func (b *BftManager) removeEquivocation(hash common.Hash) {
+	b.roundMp.removeEvidence(hash)
+	b.mp.removeEvidence(hash)
+}
+
 // cleanOldMsg clean msg pool and keep future message. cleaning only
 // on height % 100 == 0.
 func (b *BftManager) cleanOldMsg(h *big.Int) {
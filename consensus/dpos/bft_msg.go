@@ -19,11 +19,14 @@ package dpos
 import (
 	"errors"
 	"fmt"
+	"math/big"
+
 	"github.com/vntchain/go-vnt/accounts"
 	"github.com/vntchain/go-vnt/common"
 	"github.com/vntchain/go-vnt/core/types"
 	"github.com/vntchain/go-vnt/crypto"
 	"github.com/vntchain/go-vnt/log"
+	"github.com/vntchain/go-vnt/params"
 )
 
 func (bft *BftManager) makePrePrepareMsg(block *types.Block, round uint32) *types.PreprepareMsg {
@@ -72,6 +75,24 @@ func (bft *BftManager) makeCommitMsg(prePreMsg *types.PreprepareMsg) (*types.Com
 	}
 }
 
+func (bft *BftManager) makeRoundChangeMsg(h *big.Int, r uint32) (*types.RoundChangeMsg, error) {
+	msg := &types.RoundChangeMsg{
+		Round:       r,
+		Sender:      bft.coinBase,
+		BlockNumber: new(big.Int).Set(h),
+		Sig:         nil,
+	}
+
+	if sig, err := bft.dp.signFn(accounts.Account{Address: bft.coinBase}, msg.Hash().Bytes()); err != nil {
+		log.Error("Make round change msg failed", "error", err)
+		return nil, fmt.Errorf("makeRoundChangeMsg, error: %s", err)
+	} else {
+		msg.Sig = make([]byte, len(sig))
+		copy(msg.Sig, sig)
+		return msg, nil
+	}
+}
+
 func (bft *BftManager) verifyPrePrepareMsg(msg *types.PreprepareMsg) error {
 	// Nothing to verify
 	return nil
@@ -118,29 +139,65 @@ func (bft *BftManager) verifyCommitMsg(msg *types.CommitMsg) error {
 	return nil
 }
 
-func (bft *BftManager) VerifyCmtMsgOf(block *types.Block) error {
-	cmtMsges := block.CmtMsges()
-	if len(cmtMsges) < bft.quorum {
+func (bft *BftManager) verifyRoundChangeMsg(msg *types.RoundChangeMsg) error {
+	// Sender is witness
+	if !bft.validWitness(msg.Sender) {
+		return fmt.Errorf("round change sender is not witness: %s", msg.Sender.String())
+	}
+
+	// Verify signature
+	data := msg.Hash().Bytes()
+	if !bft.verifySig(msg.Sender, data, msg.Sig) {
+		return fmt.Errorf("round change msg signature is invalid")
+	}
+
+	return nil
+}
+
+// VerifyCmtMsgOf checks the block is committed by the quorum of witnesses.
+func (bft *BftManager) VerifyCmtMsgOf(config *params.ChainConfig, block *types.Block, witnesses []common.Address) error {
+	return verifyCmtMsges(config, block.Header(), witnesses, bft.quorum)
+}
+
+// verifyCmtMsges checks the header carries commit msges of at least quorum
+// witnesses in the given witness list, and all of them are signed for this
+// header. Since the handover fork, the committers must be distinct and the
+// block number of commit msges must match the header.
+func verifyCmtMsges(config *params.ChainConfig, header *types.Header, witnesses []common.Address, quorum int) error {
+	cmtMsges := header.CmtMsges
+	if len(cmtMsges) < quorum {
 		return fmt.Errorf("too less commit msg, len = %d", len(cmtMsges))
 	}
 
 	// Build witness cache
 	witCaches := make(map[common.Address]struct{})
-	for _, wit := range block.Witnesses() {
+	for _, wit := range witnesses {
 		witCaches[wit] = struct{}{}
 	}
 
 	// Check each commit msg
+	strict := config.IsHandover(header.Number)
+	hash := header.Hash()
+	committers := make(map[common.Address]struct{}, len(cmtMsges))
 	for _, m := range cmtMsges {
-		if block.Hash() != m.BlockHash {
+		if m == nil || hash != m.BlockHash {
 			return errors.New("commit msg hash not match with block hash")
 		}
 
+		if strict && (m.BlockNumber == nil || m.BlockNumber.Cmp(header.Number) != 0) {
+			return errors.New("commit msg number not match with block number")
+		}
+
 		if _, ok := witCaches[m.Commiter]; !ok {
 			return errors.New("committer is not a valid witness")
 		}
 
-		if !bft.verifySig(m.Commiter, m.Hash().Bytes(), m.CommitSig) {
+		if _, ok := committers[m.Commiter]; ok && strict {
+			return errors.New("duplicate commit msg of committer")
+		}
+		committers[m.Commiter] = struct{}{}
+
+		if !verifySig(m.Commiter, m.Hash().Bytes(), m.CommitSig) {
 			return errors.New("commit msg's signature is error")
 		}
 	}
@@ -149,6 +206,10 @@ func (bft *BftManager) VerifyCmtMsgOf(block *types.Block) error {
 }
 
 func (bft *BftManager) verifySig(sender common.Address, data []byte, sig []byte) bool {
+	return verifySig(sender, data, sig)
+}
+
+func verifySig(sender common.Address, data []byte, sig []byte) bool {
 	pubkey, err := crypto.Ecrecover(data, sig)
 	if err != nil {
 		return false
@@ -17,11 +17,17 @@
 package dpos
 
 import (
+	"crypto/ecdsa"
 	"math/big"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/vntchain/go-vnt/accounts"
 	"github.com/vntchain/go-vnt/common"
 	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/crypto"
 	"github.com/vntchain/go-vnt/params"
 )
 
@@ -182,3 +188,118 @@ func Test_ValidWitness(t *testing.T) {
 		}
 	}
 }
+
+// newTestRoundChangeBft create a producing bft manager at round (h, r), and the first
+// witness is local node.
+func newTestRoundChangeBft(h int64, r uint32) (*BftManager, []*ecdsa.PrivateKey, []common.Address) {
+	keys, wits := newTestWitnesses(4)
+	bft := newDefaultBft()
+	bft.h = big.NewInt(h)
+	bft.r = r
+	bft.producing = 1
+	bft.coinBase = wits[0]
+	for _, wit := range wits {
+		bft.witnessList[wit] = struct{}{}
+	}
+	bft.dp.signFn = func(account accounts.Account, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, keys[0])
+	}
+	bft.sendBftMsg = func(types.ConsensusMsg) {}
+	return bft, keys, wits
+}
+
+func signTestRoundChangeMsg(t *testing.T, key *ecdsa.PrivateKey, h int64, r uint32) *types.RoundChangeMsg {
+	msg := &types.RoundChangeMsg{
+		Round:       r,
+		Sender:      crypto.PubkeyToAddress(key.PublicKey),
+		BlockNumber: big.NewInt(h),
+	}
+	sig, err := crypto.Sign(msg.Hash().Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Sig = sig
+	return msg
+}
+
+// waitRound wait the round switching by routine.
+func waitRound(bft *BftManager, h int64, r uint32) bool {
+	for i := 0; i < 100; i++ {
+		bft.newRoundRWLock.RLock()
+		ok := bft.h.Int64() == h && bft.r == r
+		bft.newRoundRWLock.RUnlock()
+		if ok {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+// The producer of round 0 is offline, the other witnesses abandon round 0 and
+// switch to round 1.
+func TestRoundChange_OfflineProducer(t *testing.T) {
+	bft, keys, _ := newTestRoundChangeBft(10, 0)
+	outsiders, _ := newTestWitnesses(1)
+
+	// Invalid round change msges are not counted
+	invalid := signTestRoundChangeMsg(t, keys[1], 10, 1)
+	invalid.Sig = signTestRoundChangeMsg(t, keys[2], 10, 1).Sig
+	assert.NotNil(t, bft.handleBftMsg(invalid))
+	assert.NotNil(t, bft.handleBftMsg(signTestRoundChangeMsg(t, outsiders[0], 10, 1)))
+
+	// Less than quorum, keep waiting the producer
+	for _, key := range keys[1:bft.quorum] {
+		assert.Nil(t, bft.handleBftMsg(signTestRoundChangeMsg(t, key, 10, 1)))
+	}
+	assert.False(t, waitRound(bft, 10, 1))
+
+	assert.Nil(t, bft.handleBftMsg(signTestRoundChangeMsg(t, keys[bft.quorum], 10, 1)))
+	assert.True(t, waitRound(bft, 10, 1))
+	assert.Equal(t, newRound, atomic.LoadUint32(&bft.step))
+
+	// Local timer of round 0 comes later, never go back
+	bft.newRound(big.NewInt(10), 0, nil)
+	assert.Equal(t, uint32(1), bft.r)
+}
+
+// The local round times out, round change msg is sent to other witnesses.
+func TestRoundChange_Timeout(t *testing.T) {
+	bft, _, wits := newTestRoundChangeBft(0, 0)
+	sent := make(chan types.ConsensusMsg, 1)
+	bft.sendBftMsg = func(msg types.ConsensusMsg) {
+		sent <- msg
+	}
+
+	// Switch to a new height, no need round change
+	bft.newRound(big.NewInt(10), 0, wits)
+	bft.newRound(big.NewInt(10), 1, wits)
+
+	select {
+	case msg := <-sent:
+		rcMsg, ok := msg.(*types.RoundChangeMsg)
+		if !ok {
+			t.Fatalf("want round change msg, got: %s", msg.Type().String())
+		}
+		assert.Equal(t, uint32(1), rcMsg.Round)
+		assert.Equal(t, int64(10), rcMsg.BlockNumber.Int64())
+		assert.Equal(t, wits[0], rcMsg.Sender)
+		assert.Nil(t, bft.verifyRoundChangeMsg(rcMsg))
+	case <-time.After(time.Second):
+		t.Fatal("round change msg is not sent")
+	}
+}
+
+// The round change msges are received before reaching the height, switch to the
+// agreed round after reaching the height.
+func TestRoundChange_FutureHeight(t *testing.T) {
+	bft, keys, wits := newTestRoundChangeBft(10, 0)
+	for _, key := range keys[1:] {
+		assert.Nil(t, bft.handleBftMsg(signTestRoundChangeMsg(t, key, 11, 2)))
+	}
+	assert.Len(t, bft.mp.getRoundChangeMsgs(big.NewInt(11), 2), len(keys)-1)
+	assert.Equal(t, []uint32{2}, bft.mp.getRoundChangeRounds(big.NewInt(11), 0))
+
+	bft.newRound(big.NewInt(11), 0, wits)
+	assert.True(t, waitRound(bft, 11, 2))
+}
@@ -46,6 +46,7 @@ import (
 
 const (
 	inMemorySignatures = 4096 // Number of recent block signatures to keep in memory
+	inMemoryRewards    = 128  // Number of recent finalized blocks' rewards to keep in memory
 	updateTimeLen      = 8    // Number of bytes the witnesses list update time take up
 )
 
@@ -67,6 +68,10 @@ var (
 	// that is not part of the local blockchain.
 	errUnknownBlock = errors.New("unknown block")
 
+	// errUnknownRewards is returned when the rewards are requested for a block
+	// whose rewards are not recorded.
+	errUnknownRewards = errors.New("rewards not recorded")
+
 	// block has a beneficiary set to non-zeroes.
 	errInvalidCoinBase = errors.New("coinbase in block non-zero")
 
@@ -98,13 +103,17 @@ type getHeaderFromParentsFn func(hash common.Hash, num uint64) *types.Header
 type Dpos struct {
 	config         *params.DposConfig
 	bft            *BftManager
-	db             vntdb.Database // Database to store and retrieve dpos temp data, current not used
-	signatures     *lru.ARCCache  // Signatures of recent blocks to speed up block producing
-	signer         common.Address // VNT address of the signing key
-	signFn         SignerFn       // Signer function to authorize hashes with
-	lock           sync.RWMutex   // Protects the signer fields
-	updateInterval *big.Int       // Duration of update witnesses list
-	lastBounty     lastBountyInfo // 上次发放激励的信息
+	db             vntdb.Database   // Database to store and retrieve dpos temp data, current not used
+	signatures     *lru.ARCCache    // Signatures of recent blocks to speed up block producing
+	rewards        *lru.ARCCache    // Rewards of recent finalized blocks, which are not written yet
+	signer         common.Address   // VNT address of the signing key
+	signFn         SignerFn         // Signer function to authorize hashes with
+	lock           sync.RWMutex     // Protects the signer fields
+	updateInterval *big.Int         // Duration of update witnesses list
+	lightVerify    bool             // Verify witnesses and commit msges by headers only, for light client
+	clock          func() time.Time // Current time used for producing, replaced by simulations
+	spawn          func(func())     // Starts the routines of bft, replaced by simulations
+	lastBounty     lastBountyInfo   // 上次发放激励的信息
 
 	sendBftPeerUpdateFn func(urls []string)
 }
@@ -178,13 +187,17 @@ func ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, er
 // signers set to the ones provided by the user.
 func New(config *params.DposConfig, db vntdb.Database) *Dpos {
 	signatures, _ := lru.NewARC(inMemorySignatures)
+	rewards, _ := lru.NewARC(inMemoryRewards)
 
 	d := &Dpos{
 		config:         config,
 		bft:            nil,
 		db:             db,
 		signatures:     signatures,
+		rewards:        rewards,
 		updateInterval: nil,
+		clock:          time.Now,
+		spawn:          func(fn func()) { go fn() },
 
 		lastBounty: lastBountyInfo{
 			bountyHeight: big.NewInt(0),
@@ -212,6 +225,18 @@ func (d *Dpos) InitBft(sendBftMsg func(types.ConsensusMsg), SendPeerUpdate func(
 	d.bft.producingStart()
 }
 
+// SetClock replaces the clock used for producing blocks, which is used by
+// simulations running with a fake clock.
+func (d *Dpos) SetClock(clock func() time.Time) {
+	d.clock = clock
+}
+
+// SetSpawn replaces the way of starting the routines of bft, which is used by
+// simulations tracking the routines to know when the engine is idle.
+func (d *Dpos) SetSpawn(spawn func(func())) {
+	d.spawn = spawn
+}
+
 // Author implements consensus.Engine, returning the VNT address recovered
 // from the signature in the header's extra-data section.
 func (d *Dpos) Author(header *types.Header) (common.Address, error) {
@@ -331,8 +356,16 @@ func (d *Dpos) verifyCascadingFields(chain consensus.ChainReader, header *types.
 		return errWitnesses
 	}
 
-	// All basic checks passed, verify the seal and return
-	return d.verifySeal(chain, header, parents)
+	// All basic checks passed, verify the seal
+	if err := d.verifySeal(chain, header, parents); err != nil {
+		return err
+	}
+
+	// Light client can not verify witnesses by state, verify it by header chain
+	if d.lightVerify {
+		return d.verifyLightHeader(chain.Config(), header, parent)
+	}
+	return nil
 }
 
 // VerifySeal implements consensus.Engine, checking whether the signature contained
@@ -357,6 +390,8 @@ func (d *Dpos) verifySeal(chain consensus.ChainReader, header *types.Header, par
 		return err
 	}
 
+	// The witnesses are the operators of candidates, so the block must be
+	// signed by the operator key rather than the owner of candidate.
 	if signer != header.Coinbase {
 		return errInvalidCoinBase
 	}
@@ -369,8 +404,8 @@ func (d *Dpos) verifySeal(chain consensus.ChainReader, header *types.Header, par
 }
 
 // VerifyWitnesses Verify witness list and update time(header.Extra) for DPoS
-func (d *Dpos) VerifyWitnesses(header *types.Header, db *state.StateDB, parent *types.Header) error {
-	updated, localWitnesses := d.getWitnesses(header, db, parent)
+func (d *Dpos) VerifyWitnesses(chain consensus.ChainReader, header *types.Header, db *state.StateDB, parent *types.Header) error {
+	updated, localWitnesses := d.getWitnesses(chain.Config(), header, db, parent)
 	if len(localWitnesses) != len(header.Witnesses) {
 		return fmt.Errorf("witnesses length not match")
 	}
@@ -437,10 +472,15 @@ func (d *Dpos) Prepare(chain consensus.ChainReader, header *types.Header) error
 		return err
 	}
 
-	// Start a new round of bft
+	// Start a new round of bft, the block updating the witnesses list is
+	// committed by the parent's witnesses since the handover fork
+	bftWitnesses := header.Witnesses
+	if updated && chain.Config().IsHandover(header.Number) {
+		bftWitnesses = parent.Witnesses
+	}
 	r := uint32(nPeriod.Uint64()) - 1
 	d.bft.blockRound = r
-	go d.bft.newRound(header.Number, r, header.Witnesses)
+	d.spawn(func() { d.bft.newRound(header.Number, r, bftWitnesses) })
 
 	// Make sure self is the current block producer before produce
 	witness := header.Coinbase
@@ -470,14 +510,23 @@ func needSetUpdateTime(update bool, number uint64) bool {
 
 // Finalize implements consensus.Engine,  grants reward and returns the final block.
 func (d *Dpos) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, receipts []*types.Receipt) (*types.Block, error) {
+	// Punish the witnesses missed too many slots
+	if err := d.recordMissedSlots(chain, header, state); err != nil {
+		return nil, err
+	}
+
 	// Granting bounty, if any left
-	if err := d.grantingReward(chain, header, state); err != nil {
+	rewards, err := d.grantingReward(chain, header, state)
+	if err != nil {
 		return nil, err
 	}
 
 	// Commit db
 	header.Root = state.IntermediateRoot(true)
 
+	// Keep the record of rewards until the block is written
+	d.cacheRewards(header, rewards)
+
 	// Assemble and return the final block for sealing
 	return types.NewBlock(header, txs, receipts), nil
 }
@@ -486,43 +535,80 @@ func (d *Dpos) Finalize(chain consensus.ChainReader, header *types.Header, state
 // and granting vote reward to all the active witness candidates. the vote reward, which each witness
 // earned, in direct proportion to it's vote percentage.
 // WARN: There is no reward if no VNT bounty left.
-func (d *Dpos) grantingReward(chain consensus.ChainReader, header *types.Header, state *state.StateDB) error {
+func (d *Dpos) grantingReward(chain consensus.ChainReader, header *types.Header, state *state.StateDB) (*types.BlockRewards, error) {
+	var (
+		producing *big.Int
+		granted   map[common.Address]*big.Int
+	)
+	// The coinbase is the operator signing blocks for the candidate since the
+	// operator fork
+	producer := header.Coinbase
+	if chain.Config().IsOperator(header.Number) {
+		producer = election.CandidateOf(state, header.Coinbase)
+	}
 	if restBounty := election.QueryRestReward(state); restBounty.Cmp(common.Big0) > 0 {
-		var err error
-		// Reward BP for producing this block
-		reward := curHeightBonus(header.Number, VortexBlockReward)
-		if restBounty.Cmp(reward) < 0 {
-			reward = restBounty
-		}
-		rewards := make(map[common.Address]*big.Int)
-		rewards[header.Coinbase] = reward
-		restBounty.Sub(restBounty, reward)
-
-		// 计算投票激励
-		// Reward all witness candidates, when update witness list, if has any bounty
-		if d.updatedWitnessCheckByTime(header) && restBounty.Cmp(common.Big0) > 0 {
-			candis, allBonus, err := d.voteBonusPreWork(chain, header, state)
-			if err != nil {
-				return err
+		lastBountyBlkNr := func() (*big.Int, error) {
+			bc, ok := chain.(*core.BlockChain)
+			if !ok {
+				return nil, fmt.Errorf("voteBonusPreWork, get block chain instance error")
 			}
-
-			// the amount of bounty granted must not greater than the left bounty
-			actualBonus := math.BigMin(allBonus, restBounty)
-			log.Debug("Vote bounty", "bounty(wei)", actualBonus.String())
-			d.calcVoteBounty(candis, actualBonus, rewards)
+			return d.lastBountyBlkNr(header, bc), nil
 		}
+		rewards, reward, err := d.calcRewards(header, state, producer, restBounty, lastBountyBlkNr)
+		if err != nil {
+			return nil, err
+		}
+		producing = reward
 
 		// 统一发放激励
-		if err = election.GrantReward(state, rewards); err != nil {
+		if granted, err = election.GrantReward(state, rewards); err != nil {
 			log.Warn("Granting reward failed", "error", err.Error())
-			return err
+			return nil, err
 		}
 	}
-	return nil
+	return newBlockRewards(producer, state, producing, granted), nil
+}
+
+// calcRewards returns the rewards of producing and voting granted in block
+// header, which is limited by restBounty, and the producing reward of the
+// producer, the candidate producing the block. lastBountyBlkNr returns the block number of last vote reward, it's
+// only called when updating witness list.
+func (d *Dpos) calcRewards(header *types.Header, state *state.StateDB, producer common.Address, restBounty *big.Int,
+	lastBountyBlkNr func() (*big.Int, error)) (map[common.Address]*big.Int, *big.Int, error) {
+	restBounty = new(big.Int).Set(restBounty)
+	rewards := make(map[common.Address]*big.Int)
+	if restBounty.Cmp(common.Big0) <= 0 {
+		return rewards, big.NewInt(0), nil
+	}
+
+	// Reward BP for producing this block
+	reward := curHeightBonus(header.Number, VortexBlockReward)
+	if restBounty.Cmp(reward) < 0 {
+		reward = restBounty
+	}
+	rewards[producer] = reward
+	restBounty.Sub(restBounty, reward)
+	producing := new(big.Int).Set(reward)
+
+	// 计算投票激励
+	// Reward all witness candidates, when update witness list, if has any bounty
+	if d.updatedWitnessCheckByTime(header) && restBounty.Cmp(common.Big0) > 0 {
+		candis, allBonus, err := d.voteBonusPreWork(header, state, lastBountyBlkNr)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// the amount of bounty granted must not greater than the left bounty
+		actualBonus := math.BigMin(allBonus, restBounty)
+		log.Debug("Vote bounty", "bounty(wei)", actualBonus.String())
+		d.calcVoteBounty(candis, actualBonus, rewards)
+	}
+	return rewards, producing, nil
 }
 
 // Authorize injects a private key into the consensus engine to mint new blocks
-// with.
+// with. The signer is the operator set by the witness candidate, or the owner
+// of candidate if no operator is set.
 func (d *Dpos) Authorize(signer common.Address, signFn SignerFn) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
@@ -591,7 +677,7 @@ func (d *Dpos) APIs(chain consensus.ChainReader) []rpc.API {
 // 		nPeriod++
 // 		return parent_time + diff_index * interval
 func (d *Dpos) nextProduceTime(preBlockTime *big.Int) (produceTime *big.Int, nPeriod *big.Int, err error) {
-	now := time.Now().Unix()
+	now := d.clock().Unix()
 	dur := new(big.Int).Sub(new(big.Int).SetInt64(now), preBlockTime)
 	period := new(big.Int).SetUint64(d.config.Period)
 	// the unit is second, even no left of DivMod, but current time is in new period
@@ -712,31 +798,33 @@ func (d *Dpos) getWitnessesForProduce(header *types.Header, chain consensus.Chai
 		return false, nil, err
 	}
 
-	updated, witnesses := d.getWitnesses(header, db, parent)
+	updated, witnesses := d.getWitnesses(chain.Config(), header, db, parent)
 	return updated, witnesses, nil
 }
 
+// lastUpdateTime returns the time of the last witnesses list update, which is
+// recorded in the extra of every block except genesis.
+func lastUpdateTime(header *types.Header) *big.Int {
+	if header.Number.Int64() == 0 {
+		return header.Time
+	}
+	var upTime updateTime
+	copy(upTime[:], header.Extra[:updateTimeLen])
+	return upTime.bigInt()
+}
+
 // getWitnesses 根据当前情况，判断从指定的state db读取或者使用前一个区块的
-func (d *Dpos) getWitnesses(header *types.Header, db *state.StateDB, parent *types.Header) (bool, []common.Address) {
+func (d *Dpos) getWitnesses(config *params.ChainConfig, header *types.Header, db *state.StateDB, parent *types.Header) (bool, []common.Address) {
 	var (
-		witnesses      []common.Address
-		lastUpdateTime *big.Int
-		urls           []string
+		witnesses []common.Address
+		urls      []string
 	)
 
 	// Get last update witnesses list time from parent block
-	if parent.Number.Int64() == 0 {
-		lastUpdateTime = parent.Time
-	} else {
-		var upTime updateTime
-		copy(upTime[:], parent.Extra[:updateTimeLen])
-		lastUpdateTime = upTime.bigInt()
-	}
-
-	need := d.needUpdateWitnesses(header.Time, lastUpdateTime)
+	need := d.needUpdateWitnesses(header.Time, lastUpdateTime(parent))
 	if need {
 		log.Debug("Get new witness from db", "height", header.Number.String())
-		witnesses, urls = d.GetWitnessesFromStateDB(db)
+		witnesses, urls = d.GetWitnessesFromStateDB(db, config.IsOperator(header.Number))
 	}
 
 	// Using parent's witnesses, when update failed or No need update
@@ -752,13 +840,14 @@ func (d *Dpos) getWitnesses(header *types.Header, db *state.StateDB, parent *typ
 }
 
 // GetWitnessesFromStateDB Get the first N candidates as witnesses from stateDB
-// It's can be used for get produce block and verify witnesses
-func (d *Dpos) GetWitnessesFromStateDB(stateDB *state.StateDB) ([]common.Address, []string) {
+// It's can be used for get produce block and verify witnesses, the witnesses are
+// the operators of candidates if operator is true
+func (d *Dpos) GetWitnessesFromStateDB(stateDB *state.StateDB, operator bool) ([]common.Address, []string) {
 	if stateDB == nil {
 		log.Error("GetWitnessesFromStateDB, stateDB is nil")
 	}
 
-	return election.GetFirstNCandidates(stateDB, d.config.WitnessesNum)
+	return election.GetFirstNCandidates(stateDB, d.config.WitnessesNum, operator)
 }
 
 // needUpdateWitnesses weather current time needs update witnesses list
@@ -783,15 +872,20 @@ func (d *Dpos) coinBase() (cb common.Address) {
 
 // HandleBftMsg handle the bft message received from peer.
 func (d *Dpos) HandleBftMsg(chain consensus.ChainReader, msg types.ConsensusMsg) {
-	go d.bft.handleBftMsg(msg)
+	d.spawn(func() { d.bft.handleBftMsg(msg) })
 }
 
 func (d *Dpos) CleanOldMsg(h *big.Int) {
 	d.bft.cleanOldMsg(h)
 }
 
-func (d *Dpos) VerifyCommitMsg(block *types.Block) error {
-	return d.bft.VerifyCmtMsgOf(block)
+func (d *Dpos) VerifyCommitMsg(chain consensus.ChainReader, block *types.Block) error {
+	header := block.Header()
+	parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	return d.bft.VerifyCmtMsgOf(chain.Config(), block, d.commitWitnesses(chain.Config(), header, parent))
 }
 
 func (d *Dpos) ProducingStop() {
@@ -847,25 +941,19 @@ func (d *Dpos) calcVoteBounty(candis election.CandidateList, allBonus *big.Int,
 // voteBonusPreWork
 // 1) calculate vote bonus
 // 2) get witness candidates of last update witness
-func (d *Dpos) voteBonusPreWork(chain consensus.ChainReader, header *types.Header,
-	curStateDB *state.StateDB) (election.CandidateList, *big.Int, error) {
-	var (
-		bc *core.BlockChain
-		ok bool
-	)
+func (d *Dpos) voteBonusPreWork(header *types.Header, curStateDB *state.StateDB,
+	lastBountyBlkNr func() (*big.Int, error)) (election.CandidateList, *big.Int, error) {
 	// Block 1, no need bonus, it's no error
 	if header.Number.Cmp(common.Big1) <= 0 {
 		return make(election.CandidateList, 0), big.NewInt(0), nil
 	}
 
-	// Get state db from previous block
-	if bc, ok = chain.(*core.BlockChain); !ok {
-		return nil, nil, fmt.Errorf("voteBonusPreWork, get block chain instance error")
-	}
-
 	// Calc all vote bonus
 	// the last block number of calculate vote reward is the last block number of updating witness list
-	lastCalcBountyBlkNr := d.lastBountyBlkNr(header, bc)
+	lastCalcBountyBlkNr, err := lastBountyBlkNr()
+	if err != nil {
+		return nil, nil, err
+	}
 	log.Debug("Bounus", "lastCalcBountyBlkNr", lastCalcBountyBlkNr.String())
 	allBonus := big.NewInt(0).Sub(header.Number, lastCalcBountyBlkNr)
 	if allBonus.Sign() <= 0 {
@@ -21,9 +21,11 @@ import (
 	"testing"
 
 	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/state"
 	"github.com/vntchain/go-vnt/core/types"
 	"github.com/vntchain/go-vnt/core/vm/election"
 	"github.com/vntchain/go-vnt/params"
+	"github.com/vntchain/go-vnt/vntdb"
 )
 
 func TestUpdateTime(t *testing.T) {
@@ -217,3 +219,42 @@ func TestCalcVoteBounty(t *testing.T) {
 
 	}
 }
+
+func TestCalcRewards(t *testing.T) {
+	dp := New(&params.DposConfig{WitnessesNum: 4, Period: 2}, nil)
+	coinbase := common.BytesToAddress([]byte{1})
+	restBounty := new(big.Int).Mul(VortexBlockReward, big.NewInt(10))
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(vntdb.NewMemDatabase()))
+
+	// Not update witness list, only the producer is rewarded
+	header := &types.Header{Number: big.NewInt(10), Time: big.NewInt(120), Coinbase: coinbase, Extra: encodeUpdateTime(big.NewInt(100))}
+	called := false
+	lastBountyBlkNr := func() (*big.Int, error) {
+		called = true
+		return new(big.Int).Set(header.Number), nil
+	}
+	rewards, producing, err := dp.calcRewards(header, statedb, coinbase, restBounty, lastBountyBlkNr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rewards) != 1 || rewards[coinbase].Cmp(VortexBlockReward) != 0 || producing.Cmp(VortexBlockReward) != 0 || called {
+		t.Errorf("producing reward mismatch, got: %v, called: %v", rewards, called)
+	}
+	if restBounty.Cmp(new(big.Int).Mul(VortexBlockReward, big.NewInt(10))) != 0 {
+		t.Errorf("rest bounty should not be modified, got: %v", restBounty)
+	}
+
+	// Update witness list, no vote reward since the last bounty
+	header.Extra = encodeUpdateTime(header.Time)
+	if rewards, _, err = dp.calcRewards(header, statedb, coinbase, restBounty, lastBountyBlkNr); err != nil {
+		t.Fatal(err)
+	}
+	if len(rewards) != 1 || !called {
+		t.Errorf("vote reward mismatch, got: %v, called: %v", rewards, called)
+	}
+
+	// No bounty left
+	if rewards, _, err = dp.calcRewards(header, statedb, coinbase, big.NewInt(0), lastBountyBlkNr); err != nil || len(rewards) != 0 {
+		t.Errorf("want no reward, got: %v, err: %v", rewards, err)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package dpos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/params"
+)
+
+var (
+	// errWitnessesChanged is returned if the witnesses list is changed without
+	// updating, or updated before the update interval.
+	errWitnessesChanged = errors.New("witnesses list changed without update")
+
+	// errInvalidUpdateTime is returned if the update time in extra is mismatch
+	// with the parent's or the header's time.
+	errInvalidUpdateTime = errors.New("invalid witnesses list update time")
+)
+
+// EnableLightVerify makes the engine verify the witnesses list and the commit
+// msges of headers by the header chain, instead of by the state, which light
+// client does not have.
+func (d *Dpos) EnableLightVerify() {
+	d.lightVerify = true
+}
+
+// verifyLightHeader verifies the fields of header, which full node verifies
+// after executing the parent block: the witnesses list should be the same as
+// the parent's, unless it is updated in this block, and the header should be
+// committed by the quorum of its committing witnesses.
+//
+// Light client can not check the updated witnesses list is the elected one.
+// Since the handover fork, the new list is trusted by the commit msges of the
+// parent's witnesses, before it by the commit msges of the new witnesses.
+func (d *Dpos) verifyLightHeader(config *params.ChainConfig, header *types.Header, parent *types.Header) error {
+	if err := d.verifyWitnessesByParent(header, parent); err != nil {
+		return err
+	}
+	if err := verifyCmtMsges(config, header, d.commitWitnesses(config, header, parent), d.bft.quorum); err != nil {
+		return fmt.Errorf("commit msg error: %s", err)
+	}
+	return nil
+}
+
+// commitWitnesses returns the witnesses who should commit the header. The
+// block updating the witnesses list is committed by the parent's witnesses
+// since the handover fork, other blocks by the witnesses of itself.
+func (d *Dpos) commitWitnesses(config *params.ChainConfig, header *types.Header, parent *types.Header) []common.Address {
+	if config.IsHandover(header.Number) && d.updatedWitnesses(header, parent) {
+		return parent.Witnesses
+	}
+	return header.Witnesses
+}
+
+// updatedWitnesses returns whether the witnesses list is updated in header.
+func (d *Dpos) updatedWitnesses(header *types.Header, parent *types.Header) bool {
+	return d.updatedWitnessCheckByTime(header) && d.needUpdateWitnesses(header.Time, lastUpdateTime(parent))
+}
+
+// verifyWitnessesByParent verifies the witnesses list and the update time in
+// extra of header, tracking the witnesses list from parent.
+func (d *Dpos) verifyWitnessesByParent(header *types.Header, parent *types.Header) error {
+	// Witnesses list updated in this block
+	if d.updatedWitnesses(header, parent) {
+		return nil
+	}
+
+	// Not updated, block 1 always set update time with its time
+	if needSetUpdateTime(false, header.Number.Uint64()) {
+		if !d.updatedWitnessCheckByTime(header) {
+			return errInvalidUpdateTime
+		}
+	} else if !bytes.Equal(header.Extra, parent.Extra) {
+		return errInvalidUpdateTime
+	}
+
+	if len(header.Witnesses) != len(parent.Witnesses) {
+		return errWitnessesChanged
+	}
+	for i, wit := range header.Witnesses {
+		if wit != parent.Witnesses[i] {
+			return errWitnessesChanged
+		}
+	}
+	return nil
+}
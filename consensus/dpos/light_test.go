@@ -0,0 +1,188 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package dpos
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/crypto"
+	"github.com/vntchain/go-vnt/params"
+)
+
+func newLightTestDpos() *Dpos {
+	d := New(&params.DposConfig{WitnessesNum: 4, Period: 2}, nil)
+	d.EnableLightVerify()
+	return d
+}
+
+func newTestWitnesses(n int) ([]*ecdsa.PrivateKey, []common.Address) {
+	keys := make([]*ecdsa.PrivateKey, n)
+	addrs := make([]common.Address, n)
+	for i := 0; i < n; i++ {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	return keys, addrs
+}
+
+func newTestLightHeader(number int64, time int64, updateTime int64, witnesses []common.Address) *types.Header {
+	return &types.Header{
+		Number:     big.NewInt(number),
+		Time:       big.NewInt(time),
+		Difficulty: big.NewInt(1),
+		Extra:      encodeUpdateTime(big.NewInt(updateTime)),
+		Witnesses:  witnesses,
+	}
+}
+
+func signTestCmtMsg(t *testing.T, key *ecdsa.PrivateKey, header *types.Header) *types.CommitMsg {
+	msg := &types.CommitMsg{
+		Commiter:    crypto.PubkeyToAddress(key.PublicKey),
+		BlockNumber: header.Number,
+		BlockHash:   header.Hash(),
+	}
+	sig, err := crypto.Sign(msg.Hash().Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.CommitSig = sig
+	return msg
+}
+
+func TestVerifyWitnessesByParent(t *testing.T) {
+	d := newLightTestDpos()
+	_, wits := newTestWitnesses(4)
+	_, others := newTestWitnesses(4)
+	// update interval is 3 * 4 * 2 = 24 seconds
+	parent := newTestLightHeader(10, 100, 80, wits)
+
+	tests := []struct {
+		header *types.Header
+		err    error
+	}{
+		{newTestLightHeader(11, 102, 80, wits), nil},
+		{newTestLightHeader(11, 102, 80, others), errWitnessesChanged},
+		{newTestLightHeader(11, 102, 90, wits), errInvalidUpdateTime},
+		// Updated before the update interval
+		{newTestLightHeader(11, 102, 102, others), errInvalidUpdateTime},
+		{newTestLightHeader(11, 104, 104, others), nil},
+		// Update time should be the header's time
+		{newTestLightHeader(11, 104, 102, others), errInvalidUpdateTime},
+	}
+	for i, test := range tests {
+		assert.Equal(t, test.err, d.verifyWitnessesByParent(test.header, parent), "case %d", i)
+	}
+
+	// Block 1 always set update time with its time
+	genesis := newTestLightHeader(0, 100, 0, wits)
+	assert.Nil(t, d.verifyWitnessesByParent(newTestLightHeader(1, 102, 102, wits), genesis))
+	assert.Equal(t, errInvalidUpdateTime, d.verifyWitnessesByParent(newTestLightHeader(1, 102, 0, wits), genesis))
+}
+
+func TestVerifyCmtMsges(t *testing.T) {
+	keys, wits := newTestWitnesses(4)
+	outsiders, _ := newTestWitnesses(1)
+	quorum := newLightTestDpos().bft.quorum
+
+	header := newTestLightHeader(11, 102, 80, wits)
+	other := newTestLightHeader(12, 104, 80, wits)
+	msgs := make([]*types.CommitMsg, len(keys))
+	for i, key := range keys {
+		msgs[i] = signTestCmtMsg(t, key, header)
+	}
+
+	// Commit msg of other block number for this block
+	misnumbered := types.CopyCmtMsg(msgs[3])
+	misnumbered.BlockNumber = big.NewInt(12)
+	sig, err := crypto.Sign(misnumbered.Hash().Bytes(), keys[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	misnumbered.CommitSig = sig
+
+	handover := &params.ChainConfig{HandoverBlock: big.NewInt(11)}
+	legacy := &params.ChainConfig{HandoverBlock: big.NewInt(12)}
+	tests := []struct {
+		cmtMsges []*types.CommitMsg
+		valid    bool
+		legacy   bool
+	}{
+		{msgs[:quorum], true, true},
+		{msgs, true, true},
+		{msgs[:quorum-1], false, false},
+		// Duplicate committers is not a quorum since the handover fork
+		{append(msgs[:quorum-1:quorum-1], msgs[0]), false, true},
+		// Committer is not witness
+		{append(msgs[:quorum-1:quorum-1], signTestCmtMsg(t, outsiders[0], header)), false, false},
+		// Commit msg for other block
+		{append(msgs[:quorum-1:quorum-1], signTestCmtMsg(t, keys[3], other)), false, false},
+		// Block number mismatch since the handover fork
+		{append(msgs[:quorum-1:quorum-1], misnumbered), false, true},
+	}
+	for i, test := range tests {
+		header.CmtMsges = test.cmtMsges
+		err := verifyCmtMsges(handover, header, wits, quorum)
+		assert.Equal(t, test.valid, err == nil, "case %d: %v", i, err)
+		err = verifyCmtMsges(legacy, header, wits, quorum)
+		assert.Equal(t, test.legacy, err == nil, "legacy case %d: %v", i, err)
+	}
+
+	// Signature of other committer
+	forged := types.CopyCmtMsg(msgs[3])
+	forged.Commiter = wits[0]
+	header.CmtMsges = append(msgs[1:quorum:quorum], forged)
+	assert.NotNil(t, verifyCmtMsges(handover, header, wits, quorum))
+}
+
+func TestVerifyLightUpdateHeader(t *testing.T) {
+	d := newLightTestDpos()
+	oldKeys, oldWits := newTestWitnesses(4)
+	newKeys, newWits := newTestWitnesses(4)
+	quorum := d.bft.quorum
+	parent := newTestLightHeader(10, 100, 80, oldWits)
+
+	commit := func(header *types.Header, keys []*ecdsa.PrivateKey) *types.Header {
+		header.CmtMsges = nil
+		for _, key := range keys[:quorum] {
+			header.CmtMsges = append(header.CmtMsges, signTestCmtMsg(t, key, header))
+		}
+		return header
+	}
+	handover := &params.ChainConfig{HandoverBlock: big.NewInt(11)}
+	legacy := &params.ChainConfig{HandoverBlock: big.NewInt(12)}
+
+	// Forged update block committed only by the new witnesses
+	forged := commit(newTestLightHeader(11, 104, 104, newWits), newKeys)
+	assert.NotNil(t, d.verifyLightHeader(handover, forged, parent))
+	assert.Nil(t, d.verifyLightHeader(legacy, forged, parent))
+
+	// Update block committed by the parent's witnesses
+	updated := commit(newTestLightHeader(11, 104, 104, newWits), oldKeys)
+	assert.Nil(t, d.verifyLightHeader(handover, updated, parent))
+	assert.NotNil(t, d.verifyLightHeader(legacy, updated, parent))
+
+	// Block not updating the witnesses list is committed by its witnesses
+	next := commit(newTestLightHeader(12, 106, 104, newWits), newKeys)
+	assert.Nil(t, d.verifyLightHeader(handover, next, updated))
+	next = commit(next, oldKeys)
+	assert.NotNil(t, d.verifyLightHeader(handover, next, updated))
+}
@@ -92,6 +92,42 @@ func (m *Manager) inTurn(witness, pWitness common.Address, witTime, pWitTime *bi
 	return false
 }
 
+// missedWitnesses get the witnesses whose slots are in (pWitTime, witTime). Each witness is
+// returned once at most, and witness and pWitness are never returned.
+func (m *Manager) missedWitnesses(witness, pWitness common.Address, witTime, pWitTime *big.Int) []common.Address {
+	pIndex := m.indexOf(pWitness)
+	if pIndex == -1 || witTime.Cmp(pWitTime) <= 0 {
+		return nil
+	}
+
+	// calc the number of periods, same as inTurn
+	dur := new(big.Int).Sub(witTime, pWitTime)
+	period := new(big.Int).SetUint64(m.blockPeriod)
+	left := big.NewInt(0)
+	nPeriod, left := new(big.Int).DivMod(dur, period, left)
+	if left.Sign() != 0 {
+		nPeriod.Add(nPeriod, big.NewInt(1))
+	}
+
+	// every witness missed at most one slot
+	nMissed := len(m.Witnesses) - 1
+	if nPeriod.Cmp(big.NewInt(int64(nMissed+1))) <= 0 {
+		nMissed = int(nPeriod.Int64()) - 1
+	}
+
+	var missed []common.Address
+	for off := 1; off <= nMissed; off++ {
+		idx, err := m.moveIndex(pIndex, off)
+		if err != nil {
+			return missed
+		}
+		if wit := m.Witnesses[idx]; wit != witness && wit != pWitness {
+			missed = append(missed, wit)
+		}
+	}
+	return missed
+}
+
 // indexOf get the index of witness in witness list
 func (m *Manager) indexOf(witness common.Address) int {
 	for i := 0; i < len(m.Witnesses); i++ {
@@ -23,6 +23,7 @@ import (
 	"github.com/vntchain/go-vnt/common"
 	"github.com/vntchain/go-vnt/crypto"
 	"github.com/vntchain/go-vnt/params"
+	"math/big"
 	"testing"
 )
 
@@ -108,3 +109,31 @@ func (ap *testerAccountPool) stringToAddressSorted(accounts []string) []common.A
 
 	return address
 }
+
+func TestManager_MissedWitnesses(t *testing.T) {
+	ap := newTesterAccountPool()
+	ws := ap.stringToAddressSorted([]string{"A", "B", "C", "D"})
+	m := NewManager(2, ws)
+
+	tests := []struct {
+		witness  int   // index of producer
+		pWitness int   // index of previous producer
+		dur      int64 // time between two blocks
+		missed   []common.Address
+	}{
+		// no slot missed
+		{1, 0, 2, nil},
+		// B and C missed their slots
+		{3, 0, 6, []common.Address{ws[1], ws[2]}},
+		// time is not an integral multiple of period
+		{2, 0, 3, []common.Address{ws[1]}},
+		// long time passed, every witness missed at most once
+		{1, 0, 20, []common.Address{ws[2], ws[3]}},
+	}
+	for i, tt := range tests {
+		pTime := big.NewInt(100)
+		wTime := big.NewInt(100 + tt.dur)
+		missed := m.missedWitnesses(ws[tt.witness], ws[tt.pWitness], wTime, pTime)
+		assert.Equal(t, tt.missed, missed, "test: %d", i)
+	}
+}
@@ -23,12 +23,14 @@ import (
 	"github.com/vntchain/go-vnt/core/types"
 	"github.com/vntchain/go-vnt/log"
 	"math/big"
+	"sort"
 	"sync"
 )
 
 const (
 	bftMsgBufSize    = 30
 	msgCleanInterval = 100
+	maxEvidences     = 64 // Max number of equivocation evidences kept in msg pool
 )
 
 // msgPool store all bft consensus message of each height, and these message grouped by height.
@@ -37,7 +39,8 @@ type msgPool struct {
 	pool       map[uint64]*heightMsgPool
 	quorum     int // 2f+1
 	lock       sync.RWMutex
-	msgHashSet map[common.Hash]uint64 //value为高度方便按高度进行删除
+	msgHashSet map[common.Hash]uint64              //value为高度方便按高度进行删除
+	evidences  map[common.Hash]*types.Equivocation // 见证人双签的证据，不随消息清理
 }
 
 func newMsgPool(q int, n string) *msgPool {
@@ -46,6 +49,7 @@ func newMsgPool(q int, n string) *msgPool {
 		pool:       make(map[uint64]*heightMsgPool),
 		quorum:     q,
 		msgHashSet: make(map[common.Hash]uint64),
+		evidences:  make(map[common.Hash]*types.Equivocation),
 	}
 	return mp
 }
@@ -67,6 +71,10 @@ func (mp *msgPool) addMsg(msg types.ConsensusMsg) error {
 
 	rmp := mp.getOrNewRoundMsgPool(h, r)
 
+	if e := rmp.equivocationOf(msg); e != nil {
+		mp.addEvidence(e)
+	}
+
 	if err := rmp.addMsg(msg); err != nil {
 		log.Warn("Msg pool add msg failed", "pool name", mp.name, "msg type", msg.Type().String(), "error", err)
 		return err
@@ -76,6 +84,41 @@ func (mp *msgPool) addMsg(msg types.ConsensusMsg) error {
 	return nil
 }
 
+// addEvidence save the verified evidence, the evidences exceed maxEvidences are dropped.
+// WARN: caller should lock the msg pool
+func (mp *msgPool) addEvidence(e *types.Equivocation) {
+	if len(mp.evidences) >= maxEvidences {
+		log.Warn("Msg pool drop equivocation evidence", "pool name", mp.name, "evidence", e.String())
+		return
+	}
+	if err := e.Verify(); err != nil {
+		log.Debug("Msg pool find invalid equivocation evidence", "pool name", mp.name, "error", err)
+		return
+	}
+	log.Warn("Find witness equivocation", "pool name", mp.name, "evidence", e.String())
+	mp.evidences[e.Hash()] = e
+}
+
+// getEvidences returns all the equivocation evidences found by msg pool.
+func (mp *msgPool) getEvidences() []*types.Equivocation {
+	mp.lock.RLock()
+	defer mp.lock.RUnlock()
+
+	evidences := make([]*types.Equivocation, 0, len(mp.evidences))
+	for _, e := range mp.evidences {
+		evidences = append(evidences, e)
+	}
+	return evidences
+}
+
+// removeEvidence remove the evidence, after it's been reported.
+func (mp *msgPool) removeEvidence(hash common.Hash) {
+	mp.lock.Lock()
+	defer mp.lock.Unlock()
+
+	delete(mp.evidences, hash)
+}
+
 func (mp *msgPool) getPrePrepareMsg(h *big.Int, r uint32) (*types.PreprepareMsg, error) {
 	mp.lock.RLock()
 	defer mp.lock.RUnlock()
@@ -92,7 +135,7 @@ func (mp *msgPool) getPrePrepareMsg(h *big.Int, r uint32) (*types.PreprepareMsg,
 }
 
 func (mp *msgPool) getAllMsgOf(h *big.Int, r uint32) []types.ConsensusMsg {
-	msg := make([]types.ConsensusMsg, 0, bftMsgBufSize*2+1)
+	msg := make([]types.ConsensusMsg, 0, bftMsgBufSize*3+1)
 
 	mp.lock.RLock()
 	defer mp.lock.RUnlock()
@@ -111,9 +154,46 @@ func (mp *msgPool) getAllMsgOf(h *big.Int, r uint32) []types.ConsensusMsg {
 	for _, m := range rmp.commitMsgs {
 		msg = append(msg, m)
 	}
+	for _, m := range rmp.roundChangeMsgs {
+		msg = append(msg, m)
+	}
 	return msg
 }
 
+// getRoundChangeMsgs get the round change message of round (h, r), which are not verified.
+func (mp *msgPool) getRoundChangeMsgs(h *big.Int, r uint32) []*types.RoundChangeMsg {
+	mp.lock.RLock()
+	defer mp.lock.RUnlock()
+
+	rmp, _ := mp.getRoundMsgPool(h, r)
+	if rmp == nil {
+		return nil
+	}
+	msgs := make([]*types.RoundChangeMsg, len(rmp.roundChangeMsgs))
+	copy(msgs, rmp.roundChangeMsgs)
+	return msgs
+}
+
+// getRoundChangeRounds get the rounds of height h, which are higher than r and have round
+// change message, the rounds are in descending order.
+func (mp *msgPool) getRoundChangeRounds(h *big.Int, r uint32) []uint32 {
+	mp.lock.RLock()
+	defer mp.lock.RUnlock()
+
+	hmp, ok := mp.pool[h.Uint64()]
+	if !ok {
+		return nil
+	}
+	rounds := make([]uint32, 0, len(hmp.pool))
+	for round, rmp := range hmp.pool {
+		if round > r && len(rmp.roundChangeMsgs) > 0 {
+			rounds = append(rounds, round)
+		}
+	}
+	sort.Slice(rounds, func(i, j int) bool { return rounds[i] > rounds[j] })
+	return rounds
+}
+
 // getTwoThirdMajorityPrepareMsg get the majority prepare message, and the count of these
 // message must is bigger than 2f. otherwise, return nil, nil
 func (mp *msgPool) getTwoThirdMajorityPrepareMsg(h *big.Int, r uint32) ([]*types.PrepareMsg, error) {
@@ -314,16 +394,18 @@ func (hmp *heightMsgPool) addMsg(msg types.ConsensusMsg) error {
 // roundMsgPool store all bft message of each round, and these message grouped by message type.
 // WARN: heightMsgPool do not support lock, but MsgPool support lock
 type roundMsgPool struct {
-	prePreMsg  *types.PreprepareMsg
-	preMsgs    []*types.PrepareMsg
-	commitMsgs []*types.CommitMsg
+	prePreMsg       *types.PreprepareMsg
+	preMsgs         []*types.PrepareMsg
+	commitMsgs      []*types.CommitMsg
+	roundChangeMsgs []*types.RoundChangeMsg
 }
 
 func newRoundMsgPool() *roundMsgPool {
 	return &roundMsgPool{
-		prePreMsg:  nil,
-		preMsgs:    make([]*types.PrepareMsg, 0, bftMsgBufSize),
-		commitMsgs: make([]*types.CommitMsg, 0, bftMsgBufSize),
+		prePreMsg:       nil,
+		preMsgs:         make([]*types.PrepareMsg, 0, bftMsgBufSize),
+		commitMsgs:      make([]*types.CommitMsg, 0, bftMsgBufSize),
+		roundChangeMsgs: make([]*types.RoundChangeMsg, 0, bftMsgBufSize),
 	}
 }
 
@@ -344,14 +426,38 @@ func (rmp *roundMsgPool) addMsg(msg types.ConsensusMsg) error {
 	case types.BftCommitMessage:
 		rmp.commitMsgs = append(rmp.commitMsgs, msg.(*types.CommitMsg))
 
+	case types.BftRoundChangeMessage:
+		rmp.roundChangeMsgs = append(rmp.roundChangeMsgs, msg.(*types.RoundChangeMsg))
+
 	default:
 		return fmt.Errorf("unknow bft message type: %d, hash: %s", msg.Type(), msg.Hash().Hex())
 	}
 	return nil
 }
 
+// equivocationOf find the message in round msg pool, which has the same sender
+// with msg, but for a different block.
+func (rmp *roundMsgPool) equivocationOf(msg types.ConsensusMsg) *types.Equivocation {
+	switch m := msg.(type) {
+	case *types.PrepareMsg:
+		for _, pm := range rmp.preMsgs {
+			if pm.PrepareAddr == m.PrepareAddr && pm.BlockHash != m.BlockHash {
+				return types.NewEquivocation(pm, m)
+			}
+		}
+	case *types.CommitMsg:
+		for _, cm := range rmp.commitMsgs {
+			if cm.Commiter == m.Commiter && cm.BlockHash != m.BlockHash {
+				return types.NewEquivocation(cm, m)
+			}
+		}
+	}
+	return nil
+}
+
 func (rmp *roundMsgPool) clean() {
 	rmp.prePreMsg = nil
 	rmp.preMsgs = make([]*types.PrepareMsg, 0, bftMsgBufSize)
 	rmp.commitMsgs = make([]*types.CommitMsg, 0, bftMsgBufSize)
+	rmp.roundChangeMsgs = make([]*types.RoundChangeMsg, 0, bftMsgBufSize)
 }
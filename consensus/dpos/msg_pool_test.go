@@ -22,6 +22,7 @@ import (
 
 	"github.com/vntchain/go-vnt/common"
 	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/crypto"
 )
 
 func TestMsgPool_GetOrNewRoundMsgPool(t *testing.T) {
@@ -354,3 +355,49 @@ func TestCleanOldMsg(t *testing.T) {
 		}
 	}
 }
+
+func TestMsgPool_Equivocation(t *testing.T) {
+	mp := newMsgPool(3, "test")
+	key, _ := crypto.GenerateKey()
+	commiter := crypto.PubkeyToAddress(key.PublicKey)
+
+	newCommit := func(hash common.Hash) *types.CommitMsg {
+		msg := &types.CommitMsg{
+			Round:       0,
+			Commiter:    commiter,
+			BlockNumber: big.NewInt(1),
+			BlockHash:   hash,
+		}
+		msg.CommitSig, _ = crypto.Sign(msg.Hash().Bytes(), key)
+		return msg
+	}
+
+	if err := mp.addMsg(newCommit(common.HexToHash("0x01"))); err != nil {
+		t.Fatalf("add msg error: %s", err)
+	}
+	if len(mp.getEvidences()) != 0 {
+		t.Fatalf("should have no evidence")
+	}
+
+	// commit a different block at the same height and round
+	if err := mp.addMsg(newCommit(common.HexToHash("0x02"))); err != nil {
+		t.Fatalf("add msg error: %s", err)
+	}
+	evidences := mp.getEvidences()
+	if len(evidences) != 1 {
+		t.Fatalf("should have 1 evidence, got %d", len(evidences))
+	}
+	if evidences[0].Signer != commiter {
+		t.Errorf("evidence signer mismatch, got %s, want %s", evidences[0].Signer.String(), commiter.String())
+	}
+
+	// evidence is kept after cleaning messages
+	mp.cleanAllMessage()
+	if len(mp.getEvidences()) != 1 {
+		t.Errorf("evidence should not be cleaned with messages")
+	}
+	mp.removeEvidence(evidences[0].Hash())
+	if len(mp.getEvidences()) != 0 {
+		t.Errorf("evidence should be removed")
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package dpos
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/state"
+	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/core/vm/election"
+	"github.com/vntchain/go-vnt/log"
+)
+
+// newBlockRewards makes the record of the rewards granted in a block. granted
+// is the amount each candidate actually received, which is split into the
+// producing reward and the vote reward of the producer.
+func newBlockRewards(producer common.Address, state *state.StateDB, producing *big.Int, granted map[common.Address]*big.Int) *types.BlockRewards {
+	candidates := make([]common.Address, 0, len(granted))
+	for addr := range granted {
+		candidates = append(candidates, addr)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return bytes.Compare(candidates[i][:], candidates[j][:]) < 0
+	})
+
+	record := &types.BlockRewards{
+		Rewards:    make([]*types.Reward, 0, len(candidates)+1),
+		RestBounty: new(big.Int).Set(election.QueryRestReward(state)),
+	}
+	add := func(addr common.Address, beneficiary common.Address, typ types.RewardType, amount *big.Int) {
+		if amount.Sign() > 0 {
+			record.Rewards = append(record.Rewards, &types.Reward{
+				Candidate:   addr,
+				Beneficiary: beneficiary,
+				Type:        typ,
+				Amount:      amount,
+			})
+		}
+	}
+	for _, addr := range candidates {
+		var beneficiary common.Address
+		if can := election.GetCandidate(state, addr); can != nil {
+			beneficiary = can.Beneficiary
+		}
+
+		amount := granted[addr]
+		if addr == producer && producing != nil {
+			reward := new(big.Int).Set(producing)
+			if amount.Cmp(reward) < 0 {
+				reward.Set(amount)
+			}
+			add(addr, beneficiary, types.ProducingReward, reward)
+			amount = new(big.Int).Sub(amount, reward)
+		}
+		add(addr, beneficiary, types.VoteReward, amount)
+	}
+	return record
+}
+
+// cacheRewards keeps the record of rewards of the finalized header, until the
+// block is sealed and written.
+func (d *Dpos) cacheRewards(header *types.Header, rewards *types.BlockRewards) {
+	hash, err := sigHash(header)
+	if err != nil {
+		log.Warn("Cache block rewards failed", "number", header.Number, "err", err)
+		return
+	}
+	d.rewards.Add(hash, rewards)
+}
+
+// Rewards implements consensus.Engine, returning the record of rewards granted
+// when the block is finalized, nil if the block is not finalized recently.
+func (d *Dpos) Rewards(header *types.Header) *types.BlockRewards {
+	hash, err := sigHash(header)
+	if err != nil {
+		return nil
+	}
+	if rewards, ok := d.rewards.Get(hash); ok {
+		return rewards.(*types.BlockRewards)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package dpos
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/state"
+	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/params"
+	"github.com/vntchain/go-vnt/vntdb"
+)
+
+func TestNewBlockRewards(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(vntdb.NewMemDatabase()))
+	producer := common.BytesToAddress([]byte{1})
+	other := common.BytesToAddress([]byte{2})
+
+	granted := map[common.Address]*big.Int{
+		other:    big.NewInt(30),
+		producer: big.NewInt(100),
+	}
+	record := newBlockRewards(producer, statedb, big.NewInt(80), granted)
+	if assert.Len(t, record.Rewards, 3) {
+		assert.Equal(t, types.ProducingReward, record.Rewards[0].Type)
+		assert.Equal(t, int64(80), record.Rewards[0].Amount.Int64())
+		assert.Equal(t, producer, record.Rewards[1].Candidate)
+		assert.Equal(t, types.VoteReward, record.Rewards[1].Type)
+		assert.Equal(t, int64(20), record.Rewards[1].Amount.Int64())
+		assert.Equal(t, other, record.Rewards[2].Candidate)
+		assert.Equal(t, int64(30), record.Rewards[2].Amount.Int64())
+	}
+	assert.Equal(t, int64(130), record.Total().Int64())
+
+	// Producer received less than the producing reward
+	record = newBlockRewards(producer, statedb, big.NewInt(80), map[common.Address]*big.Int{producer: big.NewInt(50)})
+	if assert.Len(t, record.Rewards, 1) {
+		assert.Equal(t, types.ProducingReward, record.Rewards[0].Type)
+		assert.Equal(t, int64(50), record.Rewards[0].Amount.Int64())
+	}
+
+	// No bounty left
+	record = newBlockRewards(producer, statedb, nil, nil)
+	assert.Len(t, record.Rewards, 0)
+	assert.Equal(t, 0, record.RestBounty.Sign())
+}
+
+func TestCacheRewards(t *testing.T) {
+	dp := New(&params.DposConfig{WitnessesNum: 4, Period: 2}, nil)
+	header := &types.Header{Number: big.NewInt(10), Time: big.NewInt(20), Difficulty: big.NewInt(1)}
+	rewards := &types.BlockRewards{RestBounty: big.NewInt(100)}
+	dp.cacheRewards(header, rewards)
+
+	// Sealing the block does not change the record
+	sealed := types.CopyHeader(header)
+	sealed.Signature = []byte{1, 2, 3}
+	sealed.CmtMsges = []*types.CommitMsg{{BlockNumber: big.NewInt(10)}}
+	assert.Equal(t, rewards, dp.Rewards(sealed))
+
+	sealed.Root = common.HexToHash("0x01")
+	assert.Nil(t, dp.Rewards(sealed))
+}
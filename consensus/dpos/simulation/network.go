@@ -0,0 +1,218 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/crypto"
+	"github.com/vntchain/go-vnt/log"
+	"github.com/vntchain/go-vnt/rlp"
+)
+
+// network is the in-memory network connecting all nodes.
+type network struct {
+	lock      sync.Mutex
+	seed      int64
+	latency   time.Duration            // Default latency
+	latencies map[[2]int]time.Duration // Latency of (from, to)
+	dropRate  float64
+	groups    map[int]int // Partition group of nodes, nil if not partitioned
+}
+
+func newNetwork(config Config) *network {
+	return &network{
+		seed:      config.Seed,
+		latency:   config.Latency,
+		latencies: make(map[[2]int]time.Duration),
+		dropRate:  config.DropRate,
+	}
+}
+
+// route reports whether a message from node from reaches node to, and the
+// latency of it.
+func (net *network) route(from, to int, hash common.Hash) (time.Duration, bool) {
+	net.lock.Lock()
+	defer net.lock.Unlock()
+
+	if !net.reachable(from, to) {
+		return 0, false
+	}
+	if net.dropRate > 0 && net.dropped(from, to, hash) {
+		return 0, false
+	}
+	if latency, ok := net.latencies[[2]int{from, to}]; ok {
+		return latency, true
+	}
+	return net.latency, true
+}
+
+// dropped decides whether to drop the message of hash sent from node from to
+// node to. It's decided by the seed and the message, instead of the order of
+// sending, which is not controlled by simulator.
+func (net *network) dropped(from, to int, hash common.Hash) bool {
+	buf := make([]byte, 24)
+	binary.BigEndian.PutUint64(buf, uint64(net.seed))
+	binary.BigEndian.PutUint64(buf[8:], uint64(from))
+	binary.BigEndian.PutUint64(buf[16:], uint64(to))
+	r := binary.BigEndian.Uint64(crypto.Keccak256(buf, hash[:]))
+	return float64(r)/float64(math.MaxUint64) < net.dropRate
+}
+
+// reachable reports whether node from and to are in the same partition.
+// WARN: caller should lock the network
+func (net *network) reachable(from, to int) bool {
+	if net.groups == nil {
+		return true
+	}
+	gf, ok1 := net.groups[from]
+	gt, ok2 := net.groups[to]
+	return ok1 && ok2 && gf == gt
+}
+
+// SetLatency sets the latency of messages sent from node from to node to.
+func (s *Simulator) SetLatency(from, to int, latency time.Duration) {
+	s.net.lock.Lock()
+	defer s.net.lock.Unlock()
+	s.net.latencies[[2]int{from, to}] = latency
+}
+
+// SetDropRate sets the probability of dropping each message.
+func (s *Simulator) SetDropRate(rate float64) {
+	s.net.lock.Lock()
+	defer s.net.lock.Unlock()
+	s.net.dropRate = rate
+}
+
+// Partition splits nodes into groups by node index, nodes can only reach the
+// nodes in the same group, and nodes not in any group are isolated.
+func (s *Simulator) Partition(groups ...[]int) {
+	s.net.lock.Lock()
+	defer s.net.lock.Unlock()
+
+	s.net.groups = make(map[int]int)
+	for g, group := range groups {
+		for _, i := range group {
+			s.net.groups[i] = g
+		}
+	}
+}
+
+// Heal removes the partitions, and nodes synchronise blocks with each other.
+func (s *Simulator) Heal() {
+	s.net.lock.Lock()
+	s.net.groups = nil
+	s.net.lock.Unlock()
+
+	for _, n := range s.Nodes {
+		if n.Online() {
+			n.syncAll()
+		}
+	}
+}
+
+// Reachable reports whether node to can receive messages from node from.
+func (s *Simulator) Reachable(from, to int) bool {
+	s.net.lock.Lock()
+	defer s.net.lock.Unlock()
+	return s.net.reachable(from, to)
+}
+
+// sendBftMsg sends the bft message of node from to all other nodes. Message is
+// encoded and decoded as the vnt protocol, so nodes never share a message.
+func (s *Simulator) sendBftMsg(from *Node, msg types.ConsensusMsg) {
+	data, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		log.Error("Simulation encode bft msg failed", "type", msg.Type().String(), "err", err)
+		return
+	}
+	s.broadcast(from, msg.Hash(), func(to *Node) {
+		m, err := decodeBftMsg(msg.Type(), data)
+		if err != nil {
+			log.Error("Simulation decode bft msg failed", "type", msg.Type().String(), "err", err)
+			return
+		}
+		to.engine.HandleBftMsg(to.chain, m)
+	})
+}
+
+// broadcastBlock sends the block written by node from to all other nodes,
+// which is done by vnt protocol manager after producing.
+func (s *Simulator) broadcastBlock(from *Node, block *types.Block) {
+	data, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		log.Error("Simulation encode block failed", "number", block.Number(), "err", err)
+		return
+	}
+	s.broadcast(from, block.Hash(), func(to *Node) {
+		b := new(types.Block)
+		if err := rlp.DecodeBytes(data, b); err != nil {
+			log.Error("Simulation decode block failed", "err", err)
+			return
+		}
+		to.importBlock(from, b)
+	})
+}
+
+// broadcast schedules the delivery of message of hash to all other nodes reachable.
+func (s *Simulator) broadcast(from *Node, hash common.Hash, deliver func(to *Node)) {
+	if !from.Online() {
+		return
+	}
+	now := s.Now()
+	for _, to := range s.Nodes {
+		if to == from {
+			continue
+		}
+		latency, ok := s.net.route(from.Index, to.Index, hash)
+		if !ok {
+			continue
+		}
+		to := to
+		s.schedule(now.Add(latency), func() {
+			if to.Online() && s.Reachable(from.Index, to.Index) {
+				deliver(to)
+			}
+		})
+	}
+}
+
+func decodeBftMsg(typ types.BftMsgType, data []byte) (types.ConsensusMsg, error) {
+	var msg types.ConsensusMsg
+	switch typ {
+	case types.BftPreprepareMessage:
+		msg = new(types.PreprepareMsg)
+	case types.BftPrepareMessage:
+		msg = new(types.PrepareMsg)
+	case types.BftCommitMessage:
+		msg = new(types.CommitMsg)
+	case types.BftRoundChangeMessage:
+		msg = new(types.RoundChangeMsg)
+	default:
+		return nil, fmt.Errorf("unknown bft message type: %s", typ.String())
+	}
+	if err := rlp.DecodeBytes(data, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
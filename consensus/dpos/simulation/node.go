@@ -0,0 +1,420 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vntchain/go-vnt/accounts"
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/consensus/dpos"
+	"github.com/vntchain/go-vnt/core"
+	"github.com/vntchain/go-vnt/core/rawdb"
+	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/core/vm"
+	"github.com/vntchain/go-vnt/crypto"
+	"github.com/vntchain/go-vnt/event"
+	"github.com/vntchain/go-vnt/log"
+	"github.com/vntchain/go-vnt/producer"
+	"github.com/vntchain/go-vnt/vntdb"
+)
+
+// Node is a witness in the simulated network.
+type Node struct {
+	Index   int
+	Key     *ecdsa.PrivateKey
+	Address common.Address
+
+	sim      *Simulator
+	db       vntdb.Database
+	chain    *core.BlockChain
+	engine   *dpos.Dpos
+	txPool   *core.TxPool
+	mux      *event.TypeMux
+	producer *producer.Producer
+
+	sub      *event.TypeMuxSubscription // Bft messages and blocks produced by the worker
+	flushCh  chan chan struct{}         // Requests of flushing the events of worker
+	loopDone chan struct{}
+
+	offline int32 // Node is offline or not, atomic read and write
+
+	lock   sync.Mutex
+	quit   chan struct{} // Closed when node is offline, wakes the sleeping worker
+	timers []*timer      // Timers created by the worker
+}
+
+func newNode(s *Simulator, index int, key *ecdsa.PrivateKey, genesis *core.Genesis) (*Node, error) {
+	db := vntdb.NewMemDatabase()
+	genesis.MustCommit(db)
+
+	engine := dpos.New(s.chain.Dpos, db)
+	engine.SetClock(s.clock.Now)
+	engine.SetSpawn(s.spawn)
+	chain, err := core.NewBlockChain(db, nil, s.chain, engine, vm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	poolConfig := core.DefaultTxPoolConfig
+	poolConfig.Journal = ""
+
+	n := &Node{
+		Index:    index,
+		Key:      key,
+		Address:  crypto.PubkeyToAddress(key.PublicKey),
+		sim:      s,
+		db:       db,
+		chain:    chain,
+		engine:   engine,
+		txPool:   core.NewTxPool(poolConfig, s.chain, chain),
+		mux:      new(event.TypeMux),
+		flushCh:  make(chan chan struct{}),
+		loopDone: make(chan struct{}),
+		quit:     make(chan struct{}),
+	}
+	engine.Authorize(n.Address, func(account accounts.Account, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, n.Key)
+	})
+	n.sub = n.mux.Subscribe(core.SendBftMsgEvent{}, core.NewProducedBlockEvent{})
+	go n.loop()
+
+	n.producer = producer.NewWithClock(n, s.chain, n.mux, engine, &nodeClock{n})
+	return n, nil
+}
+
+// AccountManager implements producer.Backend, the worker signs by the engine.
+func (n *Node) AccountManager() *accounts.Manager { return nil }
+
+// BlockChain implements producer.Backend, returning the block chain of node.
+func (n *Node) BlockChain() *core.BlockChain { return n.chain }
+
+// TxPool implements producer.Backend, returning the transaction pool of node.
+func (n *Node) TxPool() *core.TxPool { return n.txPool }
+
+// ChainDb implements producer.Backend, returning the database of node.
+func (n *Node) ChainDb() vntdb.Database { return n.db }
+
+// Chain returns the block chain of node.
+func (n *Node) Chain() *core.BlockChain {
+	return n.chain
+}
+
+// Engine returns the consensus engine of node.
+func (n *Node) Engine() *dpos.Dpos {
+	return n.engine
+}
+
+// Head returns the header of current block.
+func (n *Node) Head() *types.Header {
+	return n.chain.CurrentHeader()
+}
+
+// Rewards returns the rewards granted in the canonical block of number.
+func (n *Node) Rewards(number uint64) *types.BlockRewards {
+	hash := rawdb.ReadCanonicalHash(n.db, number)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return rawdb.ReadRewards(n.db, hash, number)
+}
+
+// Balance returns the balance of addr in the state of current block.
+func (n *Node) Balance(addr common.Address) *big.Int {
+	state, err := n.chain.State()
+	if err != nil {
+		return nil
+	}
+	return state.GetBalance(addr)
+}
+
+// Online reports whether the node is online.
+func (n *Node) Online() bool {
+	return atomic.LoadInt32(&n.offline) == 0
+}
+
+// Disconnect stops producing of node, and disconnects it from the network.
+func (n *Node) Disconnect() {
+	if !atomic.CompareAndSwapInt32(&n.offline, 0, 1) {
+		return
+	}
+	n.closeQuit()
+	n.producer.Stop()
+	n.release()
+}
+
+// Connect connects the node to the network, synchronises blocks from other
+// nodes, and starts producing.
+func (n *Node) Connect() {
+	if !atomic.CompareAndSwapInt32(&n.offline, 1, 0) {
+		return
+	}
+	n.lock.Lock()
+	n.quit = make(chan struct{})
+	n.lock.Unlock()
+
+	n.syncAll()
+	n.start()
+}
+
+// start starts producing blocks at the current time. The timers are held
+// until the worker schedules the next round, so the clock is not moved before.
+func (n *Node) start() {
+	n.hold()
+	n.sim.spawn(func() {
+		n.producer.Start(n.Address)
+	})
+}
+
+func (n *Node) stop() {
+	n.closeQuit()
+	n.producer.Stop()
+	n.release()
+	n.txPool.Stop()
+	n.chain.Stop()
+	n.mux.Stop()
+}
+
+func (n *Node) closeQuit() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	select {
+	case <-n.quit:
+	default:
+		close(n.quit)
+	}
+}
+
+// loop sends the bft messages and the blocks produced by the worker to the
+// network, which is done by vnt protocol manager.
+func (n *Node) loop() {
+	defer close(n.loopDone)
+	for {
+		select {
+		case ev, ok := <-n.sub.Chan():
+			if !ok {
+				return
+			}
+			switch ev := ev.Data.(type) {
+			case core.SendBftMsgEvent:
+				n.sim.sendBftMsg(n, ev.BftMsg.Msg)
+			case core.NewProducedBlockEvent:
+				n.sim.broadcastBlock(n, ev.Block)
+			}
+		case done := <-n.flushCh:
+			close(done)
+		}
+	}
+}
+
+// flush waits until the events received by loop are handled.
+func (n *Node) flush() {
+	done := make(chan struct{})
+	select {
+	case n.flushCh <- done:
+		<-done
+	case <-n.loopDone:
+	}
+}
+
+// hold marks the timers of worker as fired, they are counted as running until
+// the worker resets them.
+func (n *Node) hold() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	for _, t := range n.timers {
+		t.lock.Lock()
+		t.hold()
+		t.lock.Unlock()
+	}
+}
+
+// release releases the timers held, as the stopped worker never resets them.
+func (n *Node) release() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	for _, t := range n.timers {
+		t.lock.Lock()
+		t.release()
+		t.lock.Unlock()
+	}
+}
+
+// importBlock imports the block broadcasted by node from, and synchronises
+// from it if the parent is unknown.
+func (n *Node) importBlock(from *Node, block *types.Block) {
+	if n.chain.HasBlock(block.Hash(), block.NumberU64()) {
+		return
+	}
+	if !n.chain.HasBlock(block.ParentHash(), block.NumberU64()-1) {
+		n.syncWith(from)
+		return
+	}
+	if _, err := n.chain.InsertChain(types.Blocks{block}); err != nil {
+		log.Debug("Simulation node import block failed", "node", n.Index, "number", block.Number(), "err", err)
+	}
+}
+
+// syncAll synchronises blocks from all the nodes reachable.
+func (n *Node) syncAll() {
+	for _, peer := range n.sim.Nodes {
+		if peer != n && peer.Online() && n.sim.Reachable(peer.Index, n.Index) {
+			n.syncWith(peer)
+		}
+	}
+}
+
+// syncWith imports the blocks of peer, if peer has a longer chain, which is
+// done by downloader.
+func (n *Node) syncWith(peer *Node) {
+	head := peer.chain.CurrentBlock()
+	if head.NumberU64() <= n.chain.CurrentBlock().NumberU64() {
+		return
+	}
+
+	var blocks types.Blocks
+	for b := head; b != nil && !n.chain.HasBlock(b.Hash(), b.NumberU64()); {
+		blocks = append(blocks, b)
+		b = peer.chain.GetBlock(b.ParentHash(), b.NumberU64()-1)
+	}
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+	if _, err := n.chain.InsertChain(blocks); err != nil {
+		log.Debug("Simulation node sync failed", "node", n.Index, "peer", peer.Index, "err", err)
+	}
+}
+
+// nodeClock is the fake clock of the worker of node.
+type nodeClock struct {
+	node *Node
+}
+
+func (c *nodeClock) Now() time.Time {
+	return c.node.sim.Now()
+}
+
+// Sleep blocks until the fake clock moves forward by d, or the node is offline.
+// The sleeping worker is waiting for the clock, which is not running.
+func (c *nodeClock) Sleep(d time.Duration) {
+	n, s := c.node, c.node.sim
+	n.lock.Lock()
+	quit := n.quit
+	n.lock.Unlock()
+
+	var once sync.Once
+	wake := make(chan struct{})
+	wakeup := func() {
+		once.Do(func() {
+			s.activity.wait(-1)
+			close(wake)
+		})
+	}
+	s.activity.wait(1)
+	s.schedule(s.Now().Add(d), wakeup)
+	select {
+	case <-wake:
+	case <-quit:
+		wakeup()
+	}
+}
+
+func (c *nodeClock) NewTimer(d time.Duration) producer.Timer {
+	n := c.node
+	t := &timer{node: n, c: make(chan time.Time, 1)}
+	n.lock.Lock()
+	n.timers = append(n.timers, t)
+	n.lock.Unlock()
+	t.Reset(d)
+	return t
+}
+
+// timer is a timer of the fake clock. The worker handles the fired timer by
+// producing, and resets it for the next round, the fired timer is counted as
+// running until then.
+type timer struct {
+	node *Node
+	c    chan time.Time
+
+	lock  sync.Mutex
+	gen   uint64 // Generation of the timer, stale firing is ignored
+	armed bool
+	fired bool // Fired and not reset yet
+}
+
+func (t *timer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *timer) Stop() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	active := t.armed
+	t.armed = false
+	t.gen++
+	return active
+}
+
+func (t *timer) Reset(d time.Duration) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	active := t.armed
+	t.armed = true
+	t.gen++
+	gen, s := t.gen, t.node.sim
+	s.schedule(s.Now().Add(d), func() {
+		t.fire(gen)
+	})
+	t.release()
+	return active
+}
+
+func (t *timer) fire(gen uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.gen != gen || !t.armed || !t.node.Online() {
+		return
+	}
+	t.armed = false
+	t.hold()
+	select {
+	case t.c <- t.node.sim.Now():
+	default:
+	}
+}
+
+// hold counts the timer as running, caller should lock the timer.
+func (t *timer) hold() {
+	if !t.fired {
+		t.fired = true
+		t.node.sim.activity.add(1)
+	}
+}
+
+// release counts the timer as not running, caller should lock the timer.
+func (t *timer) release() {
+	if t.fired {
+		t.fired = false
+		t.node.sim.activity.add(-1)
+	}
+}
@@ -0,0 +1,484 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package simulation runs a DPoS network of witnesses in process. Each witness
+// has it's own block chain, Dpos engine and producer, BFT messages and blocks
+// are exchanged over an in-memory network, which supports latency, drops and
+// partitions, and all nodes are driven by a fake clock.
+//
+// The producers work by the timers of the fake clock instead of the wall clock.
+// Events of the same time are handled in the order of scheduling, and the clock
+// is moved forward after the routines of nodes have handled them, so a scenario
+// is reproduced every time it runs.
+package simulation
+
+import (
+	"container/heap"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core"
+	"github.com/vntchain/go-vnt/core/vm/election"
+	"github.com/vntchain/go-vnt/crypto"
+	"github.com/vntchain/go-vnt/params"
+)
+
+// genesisTime is the time of genesis block and the start time of fake clock.
+const genesisTime = 1546300800
+
+// Config is the settings of a simulated network.
+type Config struct {
+	Witnesses       int           // Number of witnesses, each of them runs a node
+	Period          uint64        // Number of seconds between blocks
+	Latency         time.Duration // Default latency of messages between nodes
+	DropRate        float64       // Probability of dropping a message
+	Seed            int64         // Seed of witness keys and message drops
+	Bounty          *big.Int      // Bounty deposited to election contract in genesis, no reward if nil
+	MissedSlotsJail uint64        // Number of consecutive missed slots that jails a witness
+}
+
+// DefaultConfig is a network of 4 witnesses producing a block every 2 seconds.
+var DefaultConfig = Config{
+	Witnesses: 4,
+	Period:    2,
+	Latency:   100 * time.Millisecond,
+	Seed:      1,
+}
+
+// Clock is the fake clock of simulation, which is only moved by the simulator.
+type Clock struct {
+	lock sync.RWMutex
+	now  time.Time
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.now
+}
+
+func (c *Clock) set(t time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if t.After(c.now) {
+		c.now = t
+	}
+}
+
+// task is a thing happens at a time of the fake clock.
+type task struct {
+	at  time.Time
+	seq uint64 // Events of the same time are handled in scheduling order
+	fn  func()
+}
+
+type taskQueue []*task
+
+func (q taskQueue) Len() int { return len(q) }
+func (q taskQueue) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at.Before(q[j].at)
+}
+func (q taskQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *taskQueue) Push(x interface{}) { *q = append(*q, x.(*task)) }
+func (q *taskQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	ev := old[n-1]
+	*q = old[:n-1]
+	return ev
+}
+
+// Simulator runs the simulated network.
+type Simulator struct {
+	Nodes []*Node
+
+	config Config
+	chain  *params.ChainConfig
+	clock  *Clock
+	net    *network
+
+	lock     sync.Mutex
+	queue    taskQueue
+	seq      uint64
+	activity *activity
+	running  bool
+}
+
+// New creates a simulated network, all witnesses are registered as candidates
+// in genesis.
+func New(config Config) (*Simulator, error) {
+	if config.Witnesses <= 0 || config.Period == 0 {
+		return nil, errors.New("simulation: invalid witnesses number or period")
+	}
+
+	s := &Simulator{
+		config: config,
+		chain: &params.ChainConfig{
+			ChainID:       big.NewInt(1),
+			HubbleBlock:   big.NewInt(0),
+			SlashBlock:    big.NewInt(0),
+			HandoverBlock: big.NewInt(0),
+			Dpos: &params.DposConfig{
+				Period:          config.Period,
+				WitnessesNum:    config.Witnesses,
+				MissedSlotsJail: config.MissedSlotsJail,
+			},
+		},
+		clock:    &Clock{now: time.Unix(genesisTime, 0)},
+		net:      newNetwork(config),
+		activity: newActivity(),
+	}
+
+	keys := make([]*ecdsa.PrivateKey, config.Witnesses)
+	for i := range keys {
+		key, err := witnessKey(config.Seed, i)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	genesis, err := s.genesis(keys)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		node, err := newNode(s, i, key, genesis)
+		if err != nil {
+			s.Stop()
+			return nil, err
+		}
+		s.Nodes = append(s.Nodes, node)
+	}
+	return s, nil
+}
+
+// witnessKey generates the key of i-th witness from seed.
+func witnessKey(seed int64, i int) (*ecdsa.PrivateKey, error) {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf, uint64(seed))
+	binary.BigEndian.PutUint64(buf[8:], uint64(i))
+	return crypto.ToECDSA(crypto.Keccak256(buf))
+}
+
+// genesis makes the genesis of the simulated network, witnesses are registered
+// and bound as candidates, and the bounty is deposited.
+func (s *Simulator) genesis(keys []*ecdsa.PrivateKey) (*core.Genesis, error) {
+	witnesses := make([]common.Address, len(keys))
+	candidates := make([]election.Candidate, len(keys))
+	for i, key := range keys {
+		witnesses[i] = crypto.PubkeyToAddress(key.PublicKey)
+		candidates[i] = election.Candidate{
+			Owner:       witnesses[i],
+			Binder:      witnesses[i],
+			Beneficiary: witnesses[i],
+			VoteCount:   big.NewInt(1),
+			Registered:  true,
+			Bind:        true,
+			Url:         []byte("/ip4/127.0.0.1/tcp/3001/ipfs/node" + string('a'+rune(i%26))),
+		}
+	}
+	storage, err := election.GenesisStorage(candidates, s.config.Bounty)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := new(big.Int)
+	if s.config.Bounty != nil {
+		balance.Set(s.config.Bounty)
+	}
+	return &core.Genesis{
+		Config:     s.chain,
+		Timestamp:  genesisTime,
+		Difficulty: big.NewInt(1),
+		Witnesses:  witnesses,
+		Alloc: core.GenesisAlloc{
+			common.HexToAddress(election.ContractAddr): {Balance: balance, Storage: storage},
+		},
+	}, nil
+}
+
+// Clock returns the fake clock of simulation.
+func (s *Simulator) Clock() *Clock {
+	return s.clock
+}
+
+// Now returns the current time of the fake clock.
+func (s *Simulator) Now() time.Time {
+	return s.clock.Now()
+}
+
+// Start starts producing of all online nodes.
+func (s *Simulator) Start() {
+	s.lock.Lock()
+	s.running = true
+	s.lock.Unlock()
+
+	for _, n := range s.Nodes {
+		if n.Online() {
+			n.start()
+		}
+	}
+}
+
+// Stop stops all nodes, the simulator can not be used any more.
+func (s *Simulator) Stop() {
+	s.lock.Lock()
+	s.running = false
+	s.queue = nil
+	s.lock.Unlock()
+
+	for _, n := range s.Nodes {
+		n.stop()
+	}
+}
+
+// Run moves the fake clock forward by d, and handles all the events happen in
+// this duration.
+func (s *Simulator) Run(d time.Duration) {
+	end := s.Now().Add(d)
+	for {
+		s.settle()
+		events := s.nextEvents(end)
+		if len(events) == 0 {
+			break
+		}
+		s.clock.set(events[0].at)
+		for _, ev := range events {
+			ev.fn()
+		}
+	}
+	s.clock.set(end)
+}
+
+// RunSlots moves the fake clock forward by n producing slots.
+func (s *Simulator) RunSlots(n int) {
+	s.Run(time.Duration(n) * s.period())
+}
+
+// RunUntil moves the fake clock forward slot by slot, until cond returns true
+// or timeout, and reports whether cond is satisfied.
+func (s *Simulator) RunUntil(cond func() bool, timeout time.Duration) bool {
+	end := s.Now().Add(timeout)
+	for !cond() {
+		if !s.Now().Before(end) {
+			return false
+		}
+		s.RunSlots(1)
+	}
+	return true
+}
+
+func (s *Simulator) period() time.Duration {
+	return time.Duration(s.config.Period) * time.Second
+}
+
+// schedule adds a event happens at time at.
+func (s *Simulator) schedule(at time.Time, fn func()) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.running {
+		return
+	}
+	if now := s.clock.Now(); at.Before(now) {
+		at = now
+	}
+	s.seq++
+	heap.Push(&s.queue, &task{at: at, seq: s.seq, fn: fn})
+	s.activity.touch()
+}
+
+// nextEvents pops the earliest events, which happen at the same time not
+// later than end.
+func (s *Simulator) nextEvents(end time.Time) []*task {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var events []*task
+	for len(s.queue) > 0 {
+		next := s.queue[0]
+		if next.at.After(end) || (len(events) > 0 && !next.at.Equal(events[0].at)) {
+			break
+		}
+		events = append(events, heap.Pop(&s.queue).(*task))
+	}
+	return events
+}
+
+// spawn runs fn in a routine of node, which is tracked by the simulator.
+func (s *Simulator) spawn(fn func()) {
+	s.activity.add(1)
+	go func() {
+		defer s.activity.add(-1)
+		fn()
+	}()
+}
+
+// settle waits until the nodes have handled everything happened, that is all
+// the routines of nodes are done or waiting for the clock, and the events
+// posted by the workers are handled.
+func (s *Simulator) settle() {
+	for {
+		changes := s.activity.idle()
+		for _, n := range s.Nodes {
+			n.flush()
+		}
+		if s.activity.quiet(changes) {
+			return
+		}
+	}
+}
+
+// activity tracks the routines running in nodes, the clock is moved only when
+// all of them are done or waiting for the clock.
+type activity struct {
+	lock    sync.Mutex
+	cond    *sync.Cond
+	running int    // Number of routines running
+	waiting int    // Number of routines waiting for the clock
+	changes uint64 // Number of changes, including the events scheduled
+}
+
+func newActivity() *activity {
+	a := new(activity)
+	a.cond = sync.NewCond(&a.lock)
+	return a
+}
+
+// add changes the number of routines running by delta.
+func (a *activity) add(delta int) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.running += delta
+	a.changed()
+}
+
+// wait changes the number of routines waiting for the clock by delta.
+func (a *activity) wait(delta int) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.waiting += delta
+	a.changed()
+}
+
+// touch records something happened in nodes.
+func (a *activity) touch() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.changed()
+}
+
+// changed counts a change, and wakes up the idle waiting.
+// WARN: caller should lock the activity
+func (a *activity) changed() {
+	a.changes++
+	if a.running <= a.waiting {
+		a.cond.Broadcast()
+	}
+}
+
+// idle blocks until no routine is running except the waiting ones, and
+// returns the number of changes.
+func (a *activity) idle() uint64 {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for a.running > a.waiting {
+		a.cond.Wait()
+	}
+	return a.changes
+}
+
+// quiet reports whether it's still idle and nothing changed since changes.
+func (a *activity) quiet(changes uint64) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.running <= a.waiting && a.changes == changes
+}
+
+// Finalized returns the highest block number, at which all online nodes have
+// the same block.
+func (s *Simulator) Finalized() uint64 {
+	var nodes []*Node
+	for _, n := range s.Nodes {
+		if n.Online() {
+			nodes = append(nodes, n)
+		}
+	}
+	if len(nodes) == 0 {
+		return 0
+	}
+
+	height := nodes[0].Head().Number.Uint64()
+	for _, n := range nodes[1:] {
+		if h := n.Head().Number.Uint64(); h < height {
+			height = h
+		}
+	}
+	for ; height > 0; height-- {
+		hash := nodes[0].Chain().GetHeaderByNumber(height).Hash()
+		same := true
+		for _, n := range nodes[1:] {
+			if n.Chain().GetHeaderByNumber(height).Hash() != hash {
+				same = false
+				break
+			}
+		}
+		if same {
+			break
+		}
+	}
+	return height
+}
+
+// Forks returns the block numbers, at which any two nodes have different
+// blocks. Blocks are committed by a quorum of witnesses, so there should be
+// no fork.
+func (s *Simulator) Forks() []uint64 {
+	var forks []uint64
+	for height := uint64(1); ; height++ {
+		var (
+			hash   common.Hash
+			found  bool
+			forked bool
+		)
+		for _, n := range s.Nodes {
+			header := n.Chain().GetHeaderByNumber(height)
+			if header == nil {
+				continue
+			}
+			if !found {
+				hash, found = header.Hash(), true
+			} else if header.Hash() != hash {
+				forked = true
+			}
+		}
+		if !found {
+			return forks
+		}
+		if forked {
+			forks = append(forks, height)
+		}
+	}
+}
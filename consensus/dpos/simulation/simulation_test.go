@@ -0,0 +1,130 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/types"
+)
+
+func newTestSimulator(t *testing.T, config Config) *Simulator {
+	s, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	return s
+}
+
+func TestSimulation_Finality(t *testing.T) {
+	config := DefaultConfig
+	config.Bounty = new(big.Int).Mul(big.NewInt(1e18), big.NewInt(1e6))
+	s := newTestSimulator(t, config)
+	defer s.Stop()
+
+	s.RunSlots(10)
+	assert.True(t, s.Finalized() >= 8, "finalized: %d", s.Finalized())
+	assert.Empty(t, s.Forks())
+
+	// Each block is committed by a quorum, and rewards the producer
+	node := s.Nodes[0]
+	quorum := config.Witnesses - (config.Witnesses-1)/3
+	for i := uint64(1); i <= s.Finalized(); i++ {
+		header := node.Chain().GetHeaderByNumber(i)
+		assert.True(t, len(header.CmtMsges) >= quorum, "block %d has %d commit msg", i, len(header.CmtMsges))
+
+		rewards := node.Rewards(i)
+		if assert.NotNil(t, rewards, "block %d", i) && assert.NotEmpty(t, rewards.Rewards) {
+			assert.Equal(t, header.Coinbase, rewards.Rewards[0].Candidate)
+			assert.Equal(t, types.ProducingReward, rewards.Rewards[0].Type)
+		}
+	}
+	assert.True(t, node.Balance(node.Address).Sign() > 0)
+}
+
+// An offline producer misses it's slots, the others keep producing.
+func TestSimulation_OfflineProducer(t *testing.T) {
+	s := newTestSimulator(t, DefaultConfig)
+	defer s.Stop()
+
+	s.RunSlots(4)
+	offline := s.Nodes[1]
+	offline.Disconnect()
+	start := s.Finalized()
+
+	s.RunSlots(8)
+	end := s.Finalized()
+	assert.True(t, end > start+4, "finalized from %d to %d", start, end)
+	for i := start + 1; i <= end; i++ {
+		assert.NotEqual(t, offline.Address, s.Nodes[0].Chain().GetHeaderByNumber(i).Coinbase, "block %d", i)
+	}
+	assert.Empty(t, s.Forks())
+
+	// Catch up after online
+	offline.Connect()
+	assert.True(t, s.RunUntil(func() bool {
+		return offline.Head().Number.Uint64() >= end
+	}, 10*time.Second))
+	assert.Empty(t, s.Forks())
+}
+
+// No quorum in any partition, the chain stalls until healed.
+func TestSimulation_Partition(t *testing.T) {
+	s := newTestSimulator(t, DefaultConfig)
+	defer s.Stop()
+
+	s.RunSlots(4)
+	s.Partition([]int{0, 1}, []int{2, 3})
+	s.RunSlots(2)
+	stalled := s.Finalized()
+	for _, n := range s.Nodes {
+		assert.True(t, n.Head().Number.Uint64() <= stalled+1, "node %d", n.Index)
+	}
+
+	s.RunSlots(6)
+	for _, n := range s.Nodes {
+		assert.True(t, n.Head().Number.Uint64() <= stalled+1, "node %d", n.Index)
+	}
+
+	s.Heal()
+	s.RunSlots(6)
+	assert.True(t, s.Finalized() > stalled+2, "stalled: %d, finalized: %d", stalled, s.Finalized())
+	assert.Empty(t, s.Forks())
+}
+
+// Scenarios are reproduced by the seed.
+func TestSimulation_Deterministic(t *testing.T) {
+	run := func() []common.Hash {
+		config := DefaultConfig
+		config.DropRate = 0.1
+		s := newTestSimulator(t, config)
+		defer s.Stop()
+
+		s.RunSlots(8)
+		var hashes []common.Hash
+		for i := uint64(0); i <= s.Finalized(); i++ {
+			hashes = append(hashes, s.Nodes[0].Chain().GetHeaderByNumber(i).Hash())
+		}
+		return hashes
+	}
+	assert.Equal(t, run(), run())
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package dpos
+
+import (
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/consensus"
+	"github.com/vntchain/go-vnt/core/state"
+	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/core/vm/election"
+	"github.com/vntchain/go-vnt/log"
+)
+
+// recordMissedSlots tracks the witnesses which missed their slots before this
+// block, and jails them once they missed too many slots in succession. The
+// missed slots are not tracked before the slash fork.
+func (d *Dpos) recordMissedSlots(chain consensus.ChainReader, header *types.Header, state *state.StateDB) error {
+	if d.config.MissedSlotsJail == 0 || !chain.Config().IsSlash(header.Number) {
+		return nil
+	}
+
+	missed, err := d.missedWitnesses(chain, header)
+	if err != nil {
+		return err
+	}
+	if len(missed) > 0 {
+		log.Debug("Witnesses missed slots", "number", header.Number, "witnesses", missed)
+	}
+	return election.RecordMissedSlots(state, header.Coinbase, missed, d.config.MissedSlotsJail, header.Time, chain.Config().IsOperator(header.Number))
+}
+
+// missedWitnesses get the witnesses whose slots are between the previous witness and
+// the producer of header.
+func (d *Dpos) missedWitnesses(chain consensus.ChainReader, header *types.Header) ([]common.Address, error) {
+	number := header.Number.Uint64()
+	if number <= 1 {
+		return nil, nil
+	}
+
+	manager, err := d.manager(header)
+	if err != nil {
+		return nil, err
+	}
+
+	noParents := func(hash common.Hash, num uint64) *types.Header { return nil }
+	preWitness, preTime, err := d.previousWitness(manager, chain, header.ParentHash, number-1, noParents)
+	if err == errNoPreviousWitness {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return manager.missedWitnesses(header.Coinbase, preWitness, header.Time, preTime), nil
+}
+
+// Equivocations returns the evidences of witness equivocation found by this node.
+func (d *Dpos) Equivocations() []*types.Equivocation {
+	return d.bft.equivocations()
+}
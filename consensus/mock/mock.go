@@ -123,11 +123,11 @@ func (m *Mock) VerifySeal(chain consensus.ChainReader, header *types.Header) err
 	return nil
 }
 
-func (m *Mock) VerifyWitnesses(header *types.Header, db *state.StateDB, parent *types.Header) error {
+func (m *Mock) VerifyWitnesses(chain consensus.ChainReader, header *types.Header, db *state.StateDB, parent *types.Header) error {
 	return nil
 }
 
-func (m *Mock) VerifyCommitMsg(block *types.Block) error {
+func (m *Mock) VerifyCommitMsg(chain consensus.ChainReader, block *types.Block) error {
 	return nil
 }
 
@@ -156,6 +156,10 @@ func (m *Mock) CalcDifficulty(chain consensus.ChainReader, time uint64, parent *
 func (m *Mock) HandleBftMsg(chain consensus.ChainReader, msg types.ConsensusMsg) {
 }
 
+func (m *Mock) Rewards(header *types.Header) *types.BlockRewards {
+	return nil
+}
+
 // APIs returns the RPC APIs this consensus engine provides.
 func (m *Mock) APIs(chain consensus.ChainReader) []rpc.API {
 	return nil
@@ -49,6 +49,14 @@ typedef char *string;
 // uint256其实是char数组
 typedef char *uint256;
 typedef char *address;
+// bytes为任意长度的字节数组，bytes1到bytes32为定长字节数组，长度不足时右侧补0
+typedef char *bytes;
+// Event参数中的变长数组T[]，data指向length个连续存放的元素。bytes、int256和数组类型的Event参数RichEvent分叉之后可用
+// 定长数组T[N]直接传入指向N个连续元素的指针，bool元素占1个字节
+typedef struct {
+  uint32 length;
+  void *data;
+} event_array;
 
 //二次编译时用到的类型标记
 #define TY_INT32 1
@@ -86,6 +94,17 @@ string SHA3(string data);
 // Ecrecover("0x8309e99c09154c8f03d373c270965cf6d2be0af1fc1395e518596c4d7945b989",\
 "0x1c","0xdd3d526fb1154524d4c61a9e673d4bfc6fcc9fdcce39108a8486437196dd828b","0x5d7b80ae2985f43a817e1043ac02f6d421e9704b9bb31f4c3211aac9493d0d91")
 address Ecrecover(string hash, string v, string r, string s);
+// 以下加密函数在Crypto分叉之后可用
+// SHA256哈希运算,返回以0x开头的十六进制字符串
+string SHA256(string data);
+// RIPEMD160哈希运算,返回以0x开头的十六进制字符串
+string RIPEMD160(string data);
+// BLAKE2b-256哈希运算,返回以0x开头的十六进制字符串
+string Blake2b(string data);
+// Ed25519验签,公钥pubkey和签名sig都需要转化成十六进制字符串,data为签名的原始数据
+bool Ed25519Verify(string pubkey, string data, string sig);
+// bn256配对检查,input为十六进制字符串,由若干组192字节的(G1, G2)点对组成,编码与bn256配对预编译合约相同
+bool BN256PairingCheck(string input);
 //获取剩余GAS
 uint64 GetGas();
 //获取当前交易的GasLimit
@@ -105,6 +124,12 @@ void Require(bool condition, string msg) {
 void SendFromContract(address addr, uint256 amount);
 //合约向addr转账，转账金额为amount，单位为wei,转账失败返回false,消耗2300gas
 bool TransferFromContract(address addr, uint256 amount);
+//由合约创建合约,code为压缩后的合约代码加上构造函数参数,并向新合约转账amount,单位为wei
+//salt为空时新合约地址由当前合约的nonce决定,否则由十六进制的salt(至多32字节)和code决定,创建失败或salt不是十六进制会revert。CreateContract分叉之后可用
+address CreateContract(string code, uint256 amount, string salt);
+//只读调用addr合约,input为abi编码的调用数据,gas为可用gas上限,返回abi编码的结果。StaticCall分叉之后可用
+//被调用的合约只能执行unmutable的方法,不能修改状态、转账或发送事件,否则会revert
+string StaticCall(address addr, string input, uint64 gas);
 
 //将int64的数值转化为字符串
 string FromI64(int64 value);
@@ -944,6 +944,9 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		}
 	}
 	rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receipts)
+	if rewards := bc.engine.Rewards(block.Header()); rewards != nil {
+		rawdb.WriteRewards(batch, block.Hash(), block.NumberU64(), rewards)
+	}
 
 	// Choose the longest after LIB.
 	// If same length choose the early produced block.
@@ -1175,12 +1178,12 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 		}
 
 		// Verify the witness list using the parent's state
-		if err := bc.engine.VerifyWitnesses(block.Header(), stateDb, parent.Header()); err != nil {
+		if err := bc.engine.VerifyWitnesses(bc, block.Header(), stateDb, parent.Header()); err != nil {
 			return i, events, coalescedLogs, err
 		}
 
 		// Verify commit msg
-		if err := bc.engine.VerifyCommitMsg(block); err != nil {
+		if err := bc.engine.VerifyCommitMsg(bc, block); err != nil {
 			return i, events, coalescedLogs, fmt.Errorf("commit msg error: %s", err)
 		}
 
@@ -1667,7 +1670,7 @@ func (bc *BlockChain) VerifyBlockForBft(block *types.Block) (types.Receipts, []*
 	}
 
 	// Verify the witness list using the parent's state
-	if err = bc.engine.VerifyWitnesses(block.Header(), stateDb, parent.Header()); err != nil {
+	if err = bc.engine.VerifyWitnesses(bc, block.Header(), stateDb, parent.Header()); err != nil {
 		return nil, nil, 0, err
 	}
 
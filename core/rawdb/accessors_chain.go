@@ -315,6 +315,38 @@ func DeleteReceipts(db DatabaseDeleter, hash common.Hash, number uint64) {
 	}
 }
 
+// ReadRewards retrieves the rewards granted in a block.
+func ReadRewards(db DatabaseReader, hash common.Hash, number uint64) *types.BlockRewards {
+	data, _ := db.Get(blockRewardsKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	rewards := new(types.BlockRewards)
+	if err := rlp.DecodeBytes(data, rewards); err != nil {
+		log.Error("Invalid block rewards RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return rewards
+}
+
+// WriteRewards stores the rewards granted in a block.
+func WriteRewards(db DatabaseWriter, hash common.Hash, number uint64, rewards *types.BlockRewards) {
+	data, err := rlp.EncodeToBytes(rewards)
+	if err != nil {
+		log.Crit("Failed to encode block rewards", "err", err)
+	}
+	if err := db.Put(blockRewardsKey(number, hash), data); err != nil {
+		log.Crit("Failed to store block rewards", "err", err)
+	}
+}
+
+// DeleteRewards removes the rewards record associated with a block hash.
+func DeleteRewards(db DatabaseDeleter, hash common.Hash, number uint64) {
+	if err := db.Delete(blockRewardsKey(number, hash)); err != nil {
+		log.Crit("Failed to delete block rewards", "err", err)
+	}
+}
+
 // ReadBlock retrieves an entire block corresponding to the hash, assembling it
 // back from the stored header and body. If either the header or body could not
 // be retrieved nil is returned.
@@ -342,6 +374,7 @@ func WriteBlock(db DatabaseWriter, block *types.Block) {
 // DeleteBlock removes all block data associated with a hash.
 func DeleteBlock(db DatabaseDeleter, hash common.Hash, number uint64) {
 	DeleteReceipts(db, hash, number)
+	DeleteRewards(db, hash, number)
 	DeleteHeader(db, hash, number)
 	DeleteBody(db, hash, number)
 	DeleteTd(db, hash, number)
@@ -310,3 +310,34 @@ func TestBlockReceiptStorage(t *testing.T) {
 		t.Fatalf("deleted receipts returned: %v", rs)
 	}
 }
+
+// Tests that block rewards can be stored and retrieved.
+func TestBlockRewardsStorage(t *testing.T) {
+	db := vntdb.NewMemDatabase()
+
+	rewards := &types.BlockRewards{
+		Rewards: []*types.Reward{
+			{Candidate: common.BytesToAddress([]byte{0x01}), Beneficiary: common.BytesToAddress([]byte{0x11}), Type: types.ProducingReward, Amount: big.NewInt(24)},
+			{Candidate: common.BytesToAddress([]byte{0x02}), Beneficiary: common.BytesToAddress([]byte{0x22}), Type: types.VoteReward, Amount: big.NewInt(16)},
+		},
+		RestBounty: big.NewInt(1000),
+	}
+	hash := common.BytesToHash([]byte{0x03, 0x14})
+	if r := ReadRewards(db, hash, 0); r != nil {
+		t.Fatalf("non existent rewards returned: %v", r)
+	}
+	WriteRewards(db, hash, 0, rewards)
+	if r := ReadRewards(db, hash, 0); r == nil {
+		t.Fatalf("no rewards returned")
+	} else {
+		rlpHave, _ := rlp.EncodeToBytes(r)
+		rlpWant, _ := rlp.EncodeToBytes(rewards)
+		if !bytes.Equal(rlpHave, rlpWant) {
+			t.Fatalf("rewards mismatch: have %v, want %v", r, rewards)
+		}
+	}
+	DeleteRewards(db, hash, 0)
+	if r := ReadRewards(db, hash, 0); r != nil {
+		t.Fatalf("deleted rewards returned: %v", r)
+	}
+}
@@ -33,6 +33,7 @@ const (
 	BftPreprepareMessage BftMsgType = iota
 	BftPrepareMessage
 	BftCommitMessage
+	BftRoundChangeMessage
 )
 
 func (msg BftMsgType) String() string {
@@ -43,6 +44,8 @@ func (msg BftMsgType) String() string {
 		return "BftPrepareMessage"
 	case BftCommitMessage:
 		return "BftCommitMessage"
+	case BftRoundChangeMessage:
+		return "BftRoundChangeMessage"
 	default:
 		return "Unknown bft message type"
 	}
@@ -192,3 +195,42 @@ func CopyCmtMsg(msg *CommitMsg) *CommitMsg {
 	}
 	return &cpy
 }
+
+// RoundChangeMsg is sent by witness when it abandons the current round of the
+// height, and wants to switch to Round. Witnesses switch to Round when
+// receiving a quorum of it.
+type RoundChangeMsg struct {
+	Round       uint32
+	Sender      common.Address
+	BlockNumber *big.Int
+	Sig         []byte
+}
+
+func (msg *RoundChangeMsg) Type() BftMsgType {
+	return BftRoundChangeMessage
+}
+
+func (msg *RoundChangeMsg) GetBlockNum() *big.Int {
+	return msg.BlockNumber
+}
+
+func (msg *RoundChangeMsg) GetRound() uint32 {
+	return msg.Round
+}
+
+func (msg *RoundChangeMsg) Hash() (hash common.Hash) {
+	hasher := sha3.NewKeccak256()
+
+	if err := rlp.Encode(hasher, []interface{}{
+		BftRoundChangeMessage,
+		msg.Round,
+		msg.Sender,
+		msg.BlockNumber,
+	}); err != nil {
+		log.Error("Calc RoundChangeMsg hash", "error", err)
+		return common.Hash{}
+	}
+
+	hasher.Sum(hash[:0])
+	return
+}
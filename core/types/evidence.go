@@ -0,0 +1,163 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/crypto"
+	"github.com/vntchain/go-vnt/crypto/sha3"
+	"github.com/vntchain/go-vnt/log"
+	"github.com/vntchain/go-vnt/rlp"
+)
+
+var (
+	ErrEvidenceType      = errors.New("evidence message type should be prepare or commit")
+	ErrEvidenceNotDouble = errors.New("evidence messages are not conflicting")
+	ErrEvidenceSig       = errors.New("evidence signature is invalid")
+)
+
+// Equivocation is the proof that a witness signed two different blocks at the
+// same height and round, by prepare messages or commit messages.
+type Equivocation struct {
+	MsgType     BftMsgType
+	Round       uint32
+	Signer      common.Address
+	BlockNumber *big.Int
+	HashA       common.Hash
+	SigA        []byte
+	HashB       common.Hash
+	SigB        []byte
+}
+
+// NewEquivocation makes an equivocation from two conflicting messages. It
+// returns nil if the messages are not conflicting.
+func NewEquivocation(a, b ConsensusMsg) *Equivocation {
+	if a.Type() != b.Type() || a.GetRound() != b.GetRound() || a.GetBlockNum() == nil ||
+		b.GetBlockNum() == nil || a.GetBlockNum().Cmp(b.GetBlockNum()) != 0 {
+		return nil
+	}
+
+	e := &Equivocation{
+		MsgType:     a.Type(),
+		Round:       a.GetRound(),
+		BlockNumber: new(big.Int).Set(a.GetBlockNum()),
+	}
+	switch ma := a.(type) {
+	case *PrepareMsg:
+		mb := b.(*PrepareMsg)
+		if ma.PrepareAddr != mb.PrepareAddr || ma.BlockHash == mb.BlockHash {
+			return nil
+		}
+		e.Signer = ma.PrepareAddr
+		e.HashA, e.SigA = ma.BlockHash, common.CopyBytes(ma.PrepareSig)
+		e.HashB, e.SigB = mb.BlockHash, common.CopyBytes(mb.PrepareSig)
+	case *CommitMsg:
+		mb := b.(*CommitMsg)
+		if ma.Commiter != mb.Commiter || ma.BlockHash == mb.BlockHash {
+			return nil
+		}
+		e.Signer = ma.Commiter
+		e.HashA, e.SigA = ma.BlockHash, common.CopyBytes(ma.CommitSig)
+		e.HashB, e.SigB = mb.BlockHash, common.CopyBytes(mb.CommitSig)
+	default:
+		return nil
+	}
+	return e
+}
+
+// Verify checks that the two signed messages are conflicting and both signed
+// by the signer.
+func (e *Equivocation) Verify() error {
+	if e.MsgType != BftPrepareMessage && e.MsgType != BftCommitMessage {
+		return ErrEvidenceType
+	}
+	if e.BlockNumber == nil || e.HashA == e.HashB {
+		return ErrEvidenceNotDouble
+	}
+	for _, m := range e.Messages() {
+		if !verifyMsgSig(e.Signer, m) {
+			return ErrEvidenceSig
+		}
+	}
+	return nil
+}
+
+// Messages rebuilds the two conflicting messages of the equivocation.
+func (e *Equivocation) Messages() []ConsensusMsg {
+	if e.MsgType == BftPrepareMessage {
+		return []ConsensusMsg{
+			&PrepareMsg{Round: e.Round, PrepareAddr: e.Signer, BlockNumber: e.BlockNumber, BlockHash: e.HashA, PrepareSig: e.SigA},
+			&PrepareMsg{Round: e.Round, PrepareAddr: e.Signer, BlockNumber: e.BlockNumber, BlockHash: e.HashB, PrepareSig: e.SigB},
+		}
+	}
+	return []ConsensusMsg{
+		&CommitMsg{Round: e.Round, Commiter: e.Signer, BlockNumber: e.BlockNumber, BlockHash: e.HashA, CommitSig: e.SigA},
+		&CommitMsg{Round: e.Round, Commiter: e.Signer, BlockNumber: e.BlockNumber, BlockHash: e.HashB, CommitSig: e.SigB},
+	}
+}
+
+// Hash identifies the equivocation regardless of the order of two messages.
+func (e *Equivocation) Hash() (hash common.Hash) {
+	hashA, hashB := e.HashA, e.HashB
+	if hashA.Big().Cmp(hashB.Big()) > 0 {
+		hashA, hashB = hashB, hashA
+	}
+
+	hasher := sha3.NewKeccak256()
+	if err := rlp.Encode(hasher, []interface{}{
+		e.MsgType,
+		e.Round,
+		e.Signer,
+		e.BlockNumber,
+		hashA,
+		hashB,
+	}); err != nil {
+		log.Error("Calc Equivocation hash", "error", err)
+		return common.Hash{}
+	}
+
+	hasher.Sum(hash[:0])
+	return
+}
+
+func (e *Equivocation) String() string {
+	return fmt.Sprintf("equivocation{type: %s, signer: %s, number: %v, round: %d, hashes: [%s, %s]}",
+		e.MsgType.String(), e.Signer.String(), e.BlockNumber, e.Round, e.HashA.Hex(), e.HashB.Hex())
+}
+
+func verifyMsgSig(signer common.Address, msg ConsensusMsg) bool {
+	var sig []byte
+	switch m := msg.(type) {
+	case *PrepareMsg:
+		sig = m.PrepareSig
+	case *CommitMsg:
+		sig = m.CommitSig
+	default:
+		return false
+	}
+	pubkey, err := crypto.Ecrecover(msg.Hash().Bytes(), sig)
+	if err != nil {
+		return false
+	}
+	var addr common.Address
+	copy(addr[:], crypto.Keccak256(pubkey[1:])[12:])
+	return addr == signer
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/crypto"
+	"github.com/vntchain/go-vnt/rlp"
+)
+
+func signedCommitMsg(t *testing.T, hash common.Hash) *CommitMsg {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	msg := &CommitMsg{
+		Round:       1,
+		Commiter:    crypto.PubkeyToAddress(key.PublicKey),
+		BlockNumber: big.NewInt(10),
+		BlockHash:   hash,
+	}
+	sig, err := crypto.Sign(msg.Hash().Bytes(), key)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	msg.CommitSig = sig
+	return msg
+}
+
+func TestEquivocation(t *testing.T) {
+	a := signedCommitMsg(t, common.HexToHash("0x01"))
+	b := signedCommitMsg(t, common.HexToHash("0x02"))
+
+	// Same message is not an equivocation
+	if e := NewEquivocation(a, a); e != nil {
+		t.Fatal("same message should not be an equivocation")
+	}
+
+	e := NewEquivocation(a, b)
+	if e == nil {
+		t.Fatal("conflicting messages should be an equivocation")
+	}
+	if err := e.Verify(); err != nil {
+		t.Fatal("verify equivocation error:", err)
+	}
+	if e.Hash() != NewEquivocation(b, a).Hash() {
+		t.Error("equivocation hash should not rely on message order")
+	}
+
+	// Encoding round trip
+	enc, err := rlp.EncodeToBytes(e)
+	if err != nil {
+		t.Fatal("encode error:", err)
+	}
+	var dec Equivocation
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatal("decode error:", err)
+	}
+	if err := dec.Verify(); err != nil {
+		t.Fatal("verify decoded equivocation error:", err)
+	}
+
+	// Tampered evidence
+	dec.Signer = common.HexToAddress("0x01")
+	if err := dec.Verify(); err != ErrEvidenceSig {
+		t.Errorf("verify tampered equivocation, got %v, want %v", err, ErrEvidenceSig)
+	}
+}
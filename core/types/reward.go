@@ -0,0 +1,71 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/vntchain/go-vnt/common"
+)
+
+// RewardType is the reason of a reward.
+type RewardType uint8
+
+const (
+	// ProducingReward is granted to the producer for producing the block.
+	ProducingReward RewardType = iota
+	// VoteReward is granted to the active candidates for the votes, when the
+	// witnesses list is updated.
+	VoteReward
+)
+
+func (t RewardType) String() string {
+	switch t {
+	case ProducingReward:
+		return "producing"
+	case VoteReward:
+		return "vote"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(t))
+	}
+}
+
+// Reward is an amount of VNT granted to a candidate in a block, which is
+// transferred to the candidate's beneficiary.
+type Reward struct {
+	Candidate   common.Address
+	Beneficiary common.Address
+	Type        RewardType
+	Amount      *big.Int
+}
+
+// BlockRewards is the record of the rewards granted in a block, and the rest
+// bounty after granting.
+type BlockRewards struct {
+	Rewards    []*Reward
+	RestBounty *big.Int
+}
+
+// Total returns the sum of all the rewards.
+func (b *BlockRewards) Total() *big.Int {
+	total := new(big.Int)
+	for _, r := range b.Rewards {
+		total.Add(total, r.Amount)
+	}
+	return total
+}
@@ -48,16 +48,17 @@ func NewVMContext(msg Message, header *types.Header, chain ChainContext, author
 		beneficiary = *author
 	}
 	return vm.Context{
-		CanTransfer: CanTransfer,
-		Transfer:    Transfer,
-		GetHash:     GetHashFn(header, chain),
-		Origin:      msg.From(),
-		Coinbase:    beneficiary,
-		BlockNumber: new(big.Int).Set(header.Number),
-		Time:        new(big.Int).Set(header.Time),
-		Difficulty:  new(big.Int).Set(header.Difficulty),
-		GasLimit:    header.GasLimit,
-		GasPrice:    new(big.Int).Set(msg.GasPrice()),
+		CanTransfer:  CanTransfer,
+		Transfer:     Transfer,
+		GetHash:      GetHashFn(header, chain),
+		GetWitnesses: GetWitnessesFn(header, chain),
+		Origin:       msg.From(),
+		Coinbase:     beneficiary,
+		BlockNumber:  new(big.Int).Set(header.Number),
+		Time:         new(big.Int).Set(header.Time),
+		Difficulty:   new(big.Int).Set(header.Difficulty),
+		GasLimit:     header.GasLimit,
+		GasPrice:     new(big.Int).Set(msg.GasPrice()),
 	}
 }
 
@@ -87,6 +88,39 @@ func GetHashFn(ref *types.Header, chain ChainContext) func(n uint64) common.Hash
 	}
 }
 
+// canonicalReader retrieves the canonical headers by number, which is
+// implemented by the chains.
+type canonicalReader interface {
+	GetHeaderByNumber(number uint64) *types.Header
+}
+
+// GetWitnessesFn returns a GetWitnessesFunc which retrieves the witnesses list
+// of the ancestors of ref by number. The ancestor is looked up by the canonical
+// number if the parent of ref is canonical, otherwise by walking the parents.
+func GetWitnessesFn(ref *types.Header, chain ChainContext) func(n uint64) []common.Address {
+	return func(n uint64) []common.Address {
+		if n >= ref.Number.Uint64() {
+			return nil
+		}
+		if cr, ok := chain.(canonicalReader); ok {
+			if parent := cr.GetHeaderByNumber(ref.Number.Uint64() - 1); parent != nil && parent.Hash() == ref.ParentHash {
+				if header := cr.GetHeaderByNumber(n); header != nil {
+					return header.Witnesses
+				}
+				return nil
+			}
+		}
+		for header := chain.GetHeader(ref.ParentHash, ref.Number.Uint64()-1); header != nil; header = chain.GetHeader(header.ParentHash, header.Number.Uint64()-1) {
+			if number := header.Number.Uint64(); number == n {
+				return header.Witnesses
+			} else if number == 0 {
+				break
+			}
+		}
+		return nil
+	}
+}
+
 // CanTransfer checks wether there are enough funds in the address' account to make a transfer.
 // This does not take the necessary gas in to account to make the transfer valid.
 func CanTransfer(db inter.StateDB, addr common.Address, amount *big.Int) bool {
@@ -25,10 +25,10 @@ import (
 )
 
 // PrecompiledContract is the basic interface for native Go contracts. The implementation
-// requires a deterministic gas count based on the input size of the Run method of the
-// contract.
+// requires a deterministic gas count based on the input and the block of the Run method
+// of the contract.
 type PrecompiledContract interface {
-	RequiredGas(input []byte) uint64                                              // RequiredPrice calculates the contract gas use
+	RequiredGas(context inter.ChainContext, input []byte) uint64                  // RequiredPrice calculates the contract gas use
 	Run(context inter.ChainContext, input []byte, value *big.Int) ([]byte, error) // Run runs the precompiled contract
 }
 
@@ -40,7 +40,7 @@ var PrecompiledContractsHubble = map[common.Address]PrecompiledContract{
 
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
 func RunPrecompiledContract(context inter.ChainContext, p PrecompiledContract, input []byte, contract inter.Contract) (ret []byte, err error) {
-	gas := p.RequiredGas(input)
+	gas := p.RequiredGas(context, input)
 	if contract.UseGas(gas) {
 		return p.Run(context, input, contract.Value())
 	}
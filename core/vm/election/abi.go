@@ -33,9 +33,22 @@ const ElectionAbiJSON = `[
 {"name":"setProxy","inputs":[{"name":"proxy","type":"address"}],"outputs":[],"type":"function"},
 {"name":"$stake","inputs":[],"outputs":[],"type":"function"},
 {"name":"unStake","inputs":[],"outputs":[],"type":"function"},
+{"name":"unStakeAmount","inputs":[{"name":"amount","type":"uint256"}],"outputs":[],"type":"function"},
+{"name":"claimUnStake","inputs":[],"outputs":[],"type":"function"},
 {"name":"$depositReward","inputs":[],"outputs":[],"type":"function"},
 {"name":"$bindCandidate","inputs":[{"name":"candidate","type":"address"},{"name":"beneficiary","type":"address"}],"outputs":[],"type":"function"},
-{"name":"unbindCandidate","inputs":[{"name":"candidate","type":"address"},{"name":"beneficiary","type":"address"}],"outputs":[],"type":"function"}
+{"name":"unbindCandidate","inputs":[{"name":"candidate","type":"address"},{"name":"beneficiary","type":"address"}],"outputs":[],"type":"function"},
+{"name":"reportEquivocation","inputs":[{"name":"evidence","type":"bytes"}],"outputs":[],"type":"function"},
+{"name":"unjailWitness","inputs":[],"outputs":[],"type":"function"},
+{"name":"setOperator","inputs":[{"name":"operator","type":"address"}],"outputs":[],"type":"function"},
+{"name":"getCandidate","constant":true,"inputs":[{"name":"candidate","type":"address"}],"outputs":[{"name":"owner","type":"address"},{"name":"binder","type":"address"},{"name":"beneficiary","type":"address"},{"name":"voteCount","type":"uint256"},{"name":"registered","type":"bool"},{"name":"bind","type":"bool"},{"name":"url","type":"bytes"},{"name":"website","type":"bytes"},{"name":"name","type":"bytes"}],"type":"function"},
+{"name":"getVoter","constant":true,"inputs":[{"name":"voter","type":"address"}],"outputs":[{"name":"owner","type":"address"},{"name":"isProxy","type":"bool"},{"name":"proxyVoteCount","type":"uint256"},{"name":"proxy","type":"address"},{"name":"lastStakeCount","type":"uint256"},{"name":"lastVoteCount","type":"uint256"},{"name":"timeStamp","type":"uint256"},{"name":"voteCandidates","type":"address[]"}],"type":"function"},
+{"name":"getStake","constant":true,"inputs":[{"name":"staker","type":"address"}],"outputs":[{"name":"owner","type":"address"},{"name":"stakeCount","type":"uint256"},{"name":"vnt","type":"uint256"},{"name":"timeStamp","type":"uint256"}],"type":"function"},
+{"name":"getUnbonding","constant":true,"inputs":[{"name":"staker","type":"address"}],"outputs":[{"name":"vnts","type":"uint256[]"},{"name":"maturities","type":"uint256[]"}],"type":"function"},
+{"name":"getAllCandidates","constant":true,"inputs":[],"outputs":[{"name":"owners","type":"address[]"},{"name":"voteCounts","type":"uint256[]"},{"name":"actives","type":"bool[]"}],"type":"function"},
+{"name":"getJail","constant":true,"inputs":[{"name":"candidate","type":"address"}],"outputs":[{"name":"owner","type":"address"},{"name":"jailed","type":"bool"},{"name":"releaseTime","type":"uint256"},{"name":"missedSlots","type":"uint256"},{"name":"slashedHeight","type":"uint256"},{"name":"forfeited","type":"uint256"}],"type":"function"},
+{"name":"getOperator","constant":true,"inputs":[{"name":"candidate","type":"address"}],"outputs":[{"name":"operator","type":"address"}],"type":"function"},
+{"name":"getRestReward","constant":true,"inputs":[],"outputs":[{"name":"rest","type":"uint256"}],"type":"function"}
 ]`
 
 // To show how to use election abi
@@ -60,9 +60,7 @@ var (
 	emptyAddress = common.Address{}
 	eraTimeStamp = big.NewInt(year2019)
 
-	// stake minimum time period
-	unstakePeriod = big.NewInt(OneDay)
-	bindAmount    = big.NewInt(0).Mul(big.NewInt(1e+18), big.NewInt(1e7)) // 1000万VNT
+	bindAmount = big.NewInt(0).Mul(big.NewInt(1e+18), big.NewInt(1e7)) // 1000万VNT
 )
 
 type Election struct{}
@@ -201,8 +199,18 @@ func newElectionContext(ctx inter.ChainContext) electionContext {
 	}
 }
 
-func (e *Election) RequiredGas(input []byte) uint64 {
-	return 0
+// RequiredGas returns the gas of the call. Only reportEquivocation is charged
+// since the slash fork, for decoding the evidence, recovering the signers and
+// looking up the witnesses, the other methods are free.
+func (e *Election) RequiredGas(ctx inter.ChainContext, input []byte) uint64 {
+	if len(input) < 4 || !newElectionContext(ctx).isSlash() {
+		return 0
+	}
+	electionABI, err := GetElectionABI()
+	if err != nil || !bytes.Equal(input[:4], electionABI.Methods["reportEquivocation"].Id()) {
+		return 0
+	}
+	return reportEquivocationGas(input[4:])
 }
 
 type NodeInfo struct {
@@ -219,14 +227,24 @@ type BindInfo struct {
 }
 
 func (e *Election) Run(ctx inter.ChainContext, input []byte, value *big.Int) ([]byte, error) {
-	nonce := ctx.GetStateDb().GetNonce(contractAddr)
-	ctx.GetStateDb().SetNonce(contractAddr, nonce+1)
-
 	electionABI, err := abi.JSON(strings.NewReader(ElectionAbiJSON))
 	if err != nil {
 		return nil, err
 	}
 
+	// Query methods only read the state, so they neither bump the nonce
+	// nor accept any value.
+	if method, ok := isQuery(ctx, electionABI, input); ok {
+		if value != nil && value.Sign() != 0 {
+			return nil, ErrQueryWithValue
+		}
+		log.Debug("Election query", "method", method.Name)
+		return newElectionContext(ctx).query(electionABI, method, input[4:])
+	}
+
+	nonce := ctx.GetStateDb().GetNonce(contractAddr)
+	ctx.GetStateDb().SetNonce(contractAddr, nonce+1)
+
 	if len(input) < 4 {
 		return nil, nil
 	}
@@ -274,7 +292,18 @@ func (e *Election) Run(ctx inter.ChainContext, input []byte, value *big.Int) ([]
 	case isMethod("$stake"):
 		err = c.stake(sender, value)
 	case isMethod("unStake"):
-		err = c.unStake(sender)
+		if c.isUnbonding() {
+			err = c.unStake(sender, nil)
+		} else {
+			err = c.legacyUnStake(sender)
+		}
+	case isMethod("unStakeAmount") && c.isUnbonding():
+		var amount *big.Int
+		if err = electionABI.UnpackInput(&amount, methodName, methodArgs); err == nil {
+			err = c.unStake(sender, amount)
+		}
+	case isMethod("claimUnStake") && c.isUnbonding():
+		err = c.claimUnStake(sender)
 	case isMethod("$bindCandidate"):
 		var info BindInfo
 		if err = electionABI.UnpackInput(&info, methodName, methodArgs); err == nil {
@@ -287,6 +316,18 @@ func (e *Election) Run(ctx inter.ChainContext, input []byte, value *big.Int) ([]
 		}
 	case isMethod("$depositReward"):
 		err = c.depositReward(sender, value)
+	case isMethod("reportEquivocation") && c.isSlash():
+		var evidence []byte
+		if err = electionABI.UnpackInput(&evidence, methodName, methodArgs); err == nil {
+			err = c.reportEquivocation(evidence)
+		}
+	case isMethod("unjailWitness") && c.isSlash():
+		err = c.unjailWitness(sender)
+	case isMethod("setOperator") && c.isOperator():
+		var operator common.Address
+		if err = electionABI.UnpackInput(&operator, methodName, methodArgs); err == nil {
+			err = c.setOperator(sender, operator)
+		}
 	default:
 		log.Error("call election contract err: method doesn't exist")
 		err = fmt.Errorf("call election contract err: method doesn't exist")
@@ -318,6 +359,11 @@ func (ec electionContext) registerWitness(address common.Address, info *NodeInfo
 		candidate.VoteCount = big.NewInt(0)
 	}
 
+	// The address of candidate can not be an operator of other candidates
+	if err := checkOperatorFree(ec.context.GetStateDb(), address, address); err != nil {
+		return err
+	}
+
 	// Sanity check
 	if err := ec.checkCandi(address, string(info.NodeName), string(info.Website), string(info.NodeUrl)); err != nil {
 		return err
@@ -417,7 +463,7 @@ func (ec electionContext) unregisterWitness(address common.Address) error {
 
 	// 返还绑定金
 	if shouldReturnToken {
-		return ec.transfer(contractAddr, binder, bindAmount)
+		return ec.returnBindAmount(address, binder)
 	}
 	return nil
 }
@@ -479,7 +525,7 @@ func (ec electionContext) unbindCandidate(locker common.Address, info *BindInfo)
 	}
 
 	// 返回绑定人锁仓金额
-	return ec.transfer(contractAddr, locker, bindAmount)
+	return ec.returnBindAmount(candi, locker)
 }
 
 func (ec electionContext) matchLockerAndCandi(locker, candi, beneficiary common.Address) (*Candidate, error) {
@@ -709,6 +755,19 @@ func (ec electionContext) cancelProxy(address common.Address) error {
 		voteCount.Add(voteCount, voter.ProxyVoteCount)
 	}
 
+	if err := ec.subProxyVote(proxy, voteCount); err != nil {
+		return err
+	}
+
+	// 清空老数据
+	voter.Proxy = emptyAddress
+	voter.LastVoteCount = big.NewInt(0)
+	voter.LastStakeCount = big.NewInt(0)
+	return ec.setVoter(voter)
+}
+
+// subProxyVote 沿代理链减少各代理人收到的票数，以及最终代理人所投候选人的票数
+func (ec electionContext) subProxyVote(proxy common.Address, voteCount *big.Int) error {
 	for {
 		proxyVoter := ec.getVoter(proxy)
 		// 减少其代理的票
@@ -728,17 +787,11 @@ func (ec electionContext) cancelProxy(address common.Address) error {
 					return err
 				}
 			}
-			break
+			return nil
 		}
 
 		proxy = proxyVoter.Proxy
 	}
-
-	// 清空老数据
-	voter.Proxy = emptyAddress
-	voter.LastVoteCount = big.NewInt(0)
-	voter.LastStakeCount = big.NewInt(0)
-	return ec.setVoter(voter)
 }
 
 func (ec electionContext) stake(address common.Address, value *big.Int) error {
@@ -774,47 +827,6 @@ func (ec electionContext) stake(address common.Address, value *big.Int) error {
 	return nil
 }
 
-func (ec electionContext) unStake(address common.Address) error {
-	// get stake from db
-	stake := ec.getStake(address)
-
-	// if stake is not found in db, just ignore
-	if !bytes.Equal(stake.Owner.Bytes(), address.Bytes()) {
-		log.Error("unStake stake is not found in db.", "address", address.Hex())
-		return fmt.Errorf("unStake stake is not found in db")
-	}
-
-	// no stake, no need to unstake, just ignore
-	if stake.Vnt.Cmp(big.NewInt(0)) == 0 {
-		log.Error("unStake 0 stakeCount.", "address", address.Hex())
-		return fmt.Errorf("unStake 0 stakeCount")
-	}
-
-	// get the time point that can unstake
-	canUnstakeTime := big.NewInt(0).Add(stake.TimeStamp, unstakePeriod)
-
-	// if time is less than minimum stake period, cannot untake, just ignore
-	if ec.context.GetTime().Cmp(canUnstakeTime) < 0 {
-		log.Error("cannot unstake in 24 hours", "address", address.Hex())
-		return fmt.Errorf("cannot unstake in 24 hours")
-	}
-
-	amount := stake.Vnt
-	// sub stakeCount of staker
-	stake.StakeCount = big.NewInt(0)
-	stake.Vnt = big.NewInt(0)
-
-	// save stake into db
-	err := ec.setStake(stake)
-	if err != nil {
-		log.Error("unStake setStake err.", "address", address.Hex(), "err", err)
-		return err
-	}
-
-	// add balance of staker
-	return ec.transfer(contractAddr, address, amount)
-}
-
 // transfer 系统合约内的转账
 func (ec electionContext) transfer(sender, receiver common.Address, amount *big.Int) error {
 	return transfer(ec.context.GetStateDb(), sender, receiver, amount)
@@ -906,8 +918,10 @@ func (ec electionContext) calculateVoteCount(stakeCount *big.Int) *big.Int {
 	return big.NewInt(int64(votes))
 }
 
-// GetFirstNCandidates get candidates with most votes as witness from specific stateDB
-func GetFirstNCandidates(stateDB inter.StateDB, witnessesNum int) ([]common.Address, []string) {
+// GetFirstNCandidates get candidates with most votes as witness from specific stateDB.
+// The witnesses are the operators of the candidates, which sign the blocks, if
+// operator is true, the candidates themselves otherwise.
+func GetFirstNCandidates(stateDB inter.StateDB, witnessesNum int, operator bool) ([]common.Address, []string) {
 	var witnesses []common.Address
 	var urls []string
 	candidates := getAllCandidate(stateDB)
@@ -923,8 +937,12 @@ func GetFirstNCandidates(stateDB inter.StateDB, witnessesNum int) ([]common.Addr
 	candidates.Sort()
 	witnessSet := make(map[common.Address]struct{})
 	for i := 0; i < len(candidates) && len(witnesses) < witnessesNum; i++ {
-		if candidates[i].VoteCount.Cmp(big.NewInt(0)) >= 0 && candidates[i].Active() {
-			witnesses = append(witnesses, candidates[i].Owner)
+		if candidates[i].VoteCount.Cmp(big.NewInt(0)) >= 0 && candidates[i].Active() && !isJailed(stateDB, candidates[i].Owner) {
+			witness := candidates[i].Owner
+			if operator {
+				witness = GetOperator(stateDB, witness)
+			}
+			witnesses = append(witnesses, witness)
 			witnessSet[candidates[i].Owner] = struct{}{}
 			urls = append(urls, string(candidates[i].Url))
 		}
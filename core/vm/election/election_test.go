@@ -28,6 +28,7 @@ import (
 	"github.com/vntchain/go-vnt/common"
 	"github.com/vntchain/go-vnt/core/state"
 	inter "github.com/vntchain/go-vnt/core/vm/interface"
+	"github.com/vntchain/go-vnt/params"
 	"github.com/vntchain/go-vnt/vntdb"
 )
 
@@ -84,9 +85,12 @@ var (
 )
 
 type testContext struct {
-	Origin  common.Address
-	Time    *big.Int
-	StateDB inter.StateDB
+	Origin    common.Address
+	Time      *big.Int
+	StateDB   inter.StateDB
+	BlockNum  *big.Int
+	Config    *params.ChainConfig
+	Witnesses map[uint64][]common.Address
 }
 
 func (tc *testContext) GetOrigin() common.Address {
@@ -105,6 +109,24 @@ func (tc *testContext) SetTime(t *big.Int) {
 	tc.Time = t
 }
 
+func (tc *testContext) GetBlockNum() *big.Int {
+	if tc.BlockNum == nil {
+		return new(big.Int)
+	}
+	return tc.BlockNum
+}
+
+func (tc *testContext) GetChainConfig() *params.ChainConfig {
+	if tc.Config == nil {
+		return params.TestChainConfig
+	}
+	return tc.Config
+}
+
+func (tc *testContext) GetWitnesses(num *big.Int) []common.Address {
+	return tc.Witnesses[num.Uint64()]
+}
+
 func newcontext() inter.ChainContext {
 	db := vntdb.NewMemDatabase()
 	stateDB, _ := state.New(common.Hash{}, state.NewDatabase(db))
@@ -843,7 +865,7 @@ func TestStartAndStopProxy(t *testing.T) {
 	if err := c.startProxy(proxy); err != nil {
 		t.Errorf("start proxy, addr: %s, error: %s", proxy.String(), err)
 	}
-	if err := c.unStake(addr1); err != nil {
+	if err := c.unStake(addr1, nil); err != nil {
 		t.Errorf("unstake, addr: %s, error: %s", addr1.String(), err)
 	}
 	c.context.GetStateDb().AddBalance(addr1, big.NewInt(0).Mul(big.NewInt(20), big.NewInt(1e18)))
@@ -1199,22 +1221,28 @@ func testStakeWithCase(t *testing.T, c *stakeCase) {
 		t.Fatalf("after stake addr should have %v wei got %v wei", shouldLeft.String(), bal.String())
 	}
 
-	// 取消抵押
-	err = ec.unStake(addr)
-	if err.Error() != "cannot unstake in 24 hours" {
+	// 取消抵押，代币进入解锁队列
+	err = ec.unStake(addr, nil)
+	if err != nil {
 		t.Errorf("TestStake unStake err:%v ", err)
 	}
 	stake = ec.getStake(addr)
-	checkStake(t, &stake, addr, c.vnt, c.stake)
+	checkStake(t, &stake, addr, common.Big0, common.Big0)
+
+	// 解锁期内不能提取
+	if err = ec.claimUnStake(addr); err != ErrNoMaturedUnbonding {
+		t.Errorf("TestStake claimUnStake err:%v ", err)
+	}
 
-	// 取消抵押
+	// 解锁期满后提取
 	twentyFourHoursLater(t, context)
-	err = ec.unStake(addr)
+	err = ec.claimUnStake(addr)
 	if err != nil {
-		t.Errorf("TestStake unStake err:%v ", err)
+		t.Errorf("TestStake claimUnStake err:%v ", err)
+	}
+	if bal := db.GetBalance(addr); bal.Cmp(c.bal) != 0 {
+		t.Fatalf("after claim addr should have %v wei got %v wei", c.bal.String(), bal.String())
 	}
-	stake = ec.getStake(addr)
-	checkStake(t, &stake, addr, common.Big0, common.Big0)
 }
 
 func checkStake(t *testing.T, stake *Stake, expAddr common.Address, expVnt, expStake *big.Int) {
@@ -34,7 +34,12 @@ const (
 	CANDIDATEPREFIX = byte(1)
 	STAKEPREFIX     = byte(2)
 	REWARDPREFIX    = byte(3)
-	PREFIXLENGTH    = 4 // key的结构为，4位表前缀，20位address，8位的value在struct中的位置
+	JAILPREFIX      = byte(4)
+	UNBONDINGPREFIX = byte(5)
+	OPERATORPREFIX  = byte(6)
+	// OPERATORINDEXPREFIX is the prefix of the index from operators to candidates
+	OPERATORINDEXPREFIX = byte(7)
+	PREFIXLENGTH        = 4 // key的结构为，4位表前缀，20位address，8位的value在struct中的位置
 )
 
 type getFuncType func(key common.Hash) common.Hash
@@ -295,6 +300,23 @@ func convertToStruct(prefix byte, addr common.Address, v interface{}, getFn getF
 			} else {
 				return err
 			}
+		} else if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct {
+			var valLen uint32
+
+			// 结构体数组，先解析出数组长度，然后逐个解析数组中的结构体
+			if err := rlp.DecodeBytes(valByte.Big().Bytes(), &valLen); err != nil {
+				return err
+			}
+			tmp := reflect.MakeSlice(fv.Type(), 0, int(valLen))
+			for j := uint32(0); j < valLen; j++ {
+				elem := reflect.New(fv.Type().Elem())
+				binary.BigEndian.PutUint32(key[PREFIXLENGTH+common.AddressLength:], uint32(j+1))
+				if err := rlp.DecodeBytes(getFn(key).Big().Bytes(), elem.Interface()); err != nil {
+					return err
+				}
+				tmp = reflect.Append(tmp, elem.Elem())
+			}
+			value.Field(i).Set(tmp)
 		} else if _, ok := fv.Interface().([]byte); ok {
 			// 部分byte数组过长，是拆分了之后存储的
 			var val []byte
@@ -357,7 +357,7 @@ func TestGetFirstXCandidates_1(t *testing.T) {
 		}
 	}
 
-	witsAddr, _ := GetFirstNCandidates(stateDB, witNum)
+	witsAddr, _ := GetFirstNCandidates(stateDB, witNum, true)
 	if len(witsAddr) != len(rets) {
 		t.Errorf("lenght not match, want:%d, got:%d", witNum, len(witsAddr))
 	}
@@ -418,7 +418,7 @@ func TestGetFirstXCandidates_2(t *testing.T) {
 		}
 	}
 
-	witsAddr, _ := GetFirstNCandidates(stateDB, witNum)
+	witsAddr, _ := GetFirstNCandidates(stateDB, witNum, true)
 	if len(witsAddr) != len(rets) {
 		t.Errorf("lenght not match, want:%d, got:%d", witNum, len(witsAddr))
 	}
@@ -480,7 +480,7 @@ func TestGetFirstXCandidates_3(t *testing.T) {
 		}
 	}
 
-	witsAddr, _ := GetFirstNCandidates(stateDB, witNum)
+	witsAddr, _ := GetFirstNCandidates(stateDB, witNum, true)
 	if len(witsAddr) != len(rets) {
 		t.Errorf("lenght not match, want:%d, got:%d", witNum, len(witsAddr))
 	}
@@ -543,7 +543,7 @@ func TestGetFirstXCandidates_4(t *testing.T) {
 		}
 	}
 
-	witsAddr, _ := GetFirstNCandidates(stateDB, witNum)
+	witsAddr, _ := GetFirstNCandidates(stateDB, witNum, true)
 	if len(witsAddr) != len(rets) {
 		t.Errorf("lenght not match, want:%d, got:%d", witNum, len(witsAddr))
 		t.FailNow()
@@ -0,0 +1,49 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package election
+
+import (
+	"math/big"
+
+	"github.com/vntchain/go-vnt/common"
+)
+
+// GenesisStorage returns the storage of election contract, in which candidates
+// are saved and the bounty is deposited. It's used to build the genesis of
+// private and test chains, and the balance of election contract should be
+// allocated with the bounty too.
+func GenesisStorage(candidates []Candidate, bounty *big.Int) (map[common.Hash]common.Hash, error) {
+	storage := make(map[common.Hash]common.Hash)
+	setFn := func(key common.Hash, value common.Hash) {
+		storage[key] = value
+	}
+
+	for _, can := range candidates {
+		if can.VoteCount == nil {
+			can.VoteCount = big.NewInt(0)
+		}
+		if err := convertToKV(CANDIDATEPREFIX, can, setFn); err != nil {
+			return nil, err
+		}
+	}
+	if bounty != nil && bounty.Sign() > 0 {
+		if err := convertToKV(REWARDPREFIX, Reward{Rest: new(big.Int).Set(bounty)}, setFn); err != nil {
+			return nil, err
+		}
+	}
+	return storage, nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package election
+
+import (
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestGenesisStorage(t *testing.T) {
+	cans := []Candidate{
+		{Owner: addr1, Binder: binder, Beneficiary: beneficiary, Registered: true, Bind: true, Url: candiInfo1.url},
+		{Owner: addr2, Binder: binder, Beneficiary: beneficiary, Registered: true, Bind: true, Url: candiInfo2.url},
+	}
+	storage, err := GenesisStorage(cans, vnt2wei(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := newcontext().GetStateDb()
+	for key, value := range storage {
+		db.SetState(contractAddr, key, value)
+	}
+
+	witnesses, urls := GetFirstNCandidates(db, len(cans), true)
+	assert.Equal(t, len(witnesses), len(cans))
+	assert.Equal(t, len(urls), len(cans))
+	for _, can := range cans {
+		got := GetCandidate(db, can.Owner)
+		if got == nil || !got.Active() || got.Beneficiary != beneficiary {
+			t.Errorf("candidate mismatch, want: %v, got: %v", can.String(), got)
+		}
+	}
+	assert.Equal(t, QueryRestReward(db).Cmp(vnt2wei(100)), 0)
+}
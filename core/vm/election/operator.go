@@ -0,0 +1,137 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package election
+
+import (
+	"errors"
+
+	"github.com/vntchain/go-vnt/common"
+	inter "github.com/vntchain/go-vnt/core/vm/interface"
+	"github.com/vntchain/go-vnt/log"
+)
+
+var ErrOperatorInUse = errors.New("operator is already used by another candidate")
+
+// Operator is the account authorized by a witness candidate to produce blocks
+// and sign bft messages, so the owner's key holding the funds can be kept away
+// from the producing server. The witnesses list is made of the operators, and
+// the operator of a candidate is the owner itself if not set. The operators are
+// used since the operator fork, before it the witnesses are the candidates.
+type Operator struct {
+	Owner    common.Address // 候选人地址
+	Operator common.Address // 出块及签名BFT消息的账号
+}
+
+// operatorIndex is the reverse index from an operator to its candidate. The
+// index is kept after the operator is replaced, so the blocks and evidences
+// signed by the previous operator are still attributed to the candidate, and
+// the operator can not be taken by other candidates.
+type operatorIndex struct {
+	Owner     common.Address // 出块及签名BFT消息的账号
+	Candidate common.Address // 授权该账号的候选人
+}
+
+// GetOperator returns the operator of the candidate, which is the candidate
+// itself if the operator is not set.
+func GetOperator(stateDB inter.StateDB, candidate common.Address) common.Address {
+	var op Operator
+	if err := convertToStruct(OPERATORPREFIX, candidate, &op, genGetFunc(stateDB)); err != nil || op.Owner != candidate || op.Operator == emptyAddress {
+		return candidate
+	}
+	return op.Operator
+}
+
+// CandidateOf returns the candidate authorized the operator, which is the
+// operator itself if it's not authorized by any candidate.
+func CandidateOf(stateDB inter.StateDB, operator common.Address) common.Address {
+	if candidate := indexedCandidate(stateDB, operator); candidate != emptyAddress {
+		return candidate
+	}
+	return operator
+}
+
+// GetWitnessUrl returns the node url registered by the candidate of the witness,
+// which is empty if the candidate is not registered.
+func GetWitnessUrl(stateDB inter.StateDB, witness common.Address) string {
+	candidate := GetCandidate(stateDB, CandidateOf(stateDB, witness))
+	if candidate == nil {
+		return ""
+	}
+	return string(candidate.Url)
+}
+
+// witnessOwner returns the candidate of the witness in witnesses list, which is
+// the witness itself before the operator fork.
+func witnessOwner(stateDB inter.StateDB, witness common.Address, operator bool) common.Address {
+	if !operator {
+		return witness
+	}
+	return CandidateOf(stateDB, witness)
+}
+
+// isOperator reports whether the candidates can set operators at the current
+// block.
+func (ec electionContext) isOperator() bool {
+	config := ec.context.GetChainConfig()
+	return config != nil && config.IsOperator(ec.context.GetBlockNum())
+}
+
+func indexedCandidate(stateDB inter.StateDB, operator common.Address) common.Address {
+	var index operatorIndex
+	if err := convertToStruct(OPERATORINDEXPREFIX, operator, &index, genGetFunc(stateDB)); err != nil || index.Owner != operator {
+		return emptyAddress
+	}
+	return index.Candidate
+}
+
+// checkOperatorFree returns error if the address is an operator of, or the
+// owner of, a candidate other than the candidate.
+func checkOperatorFree(stateDB inter.StateDB, address, candidate common.Address) error {
+	if owner := indexedCandidate(stateDB, address); owner != emptyAddress && owner != candidate {
+		return ErrOperatorInUse
+	}
+	if address != candidate && GetCandidate(stateDB, address) != nil {
+		return ErrOperatorInUse
+	}
+	return nil
+}
+
+// setOperator 候选人设置或更换出块账号，新的出块账号在下次更新见证人列表时生效，
+// 设置为空地址时恢复使用候选人账号出块
+func (ec electionContext) setOperator(address common.Address, operator common.Address) error {
+	candidate := ec.getCandidate(address)
+	if candidate.Owner != address {
+		return ErrCandiNotReg
+	}
+	if operator == emptyAddress {
+		operator = address
+	}
+
+	stateDB := ec.context.GetStateDb()
+	if err := checkOperatorFree(stateDB, operator, address); err != nil {
+		return err
+	}
+
+	if err := convertToKV(OPERATORPREFIX, Operator{Owner: address, Operator: operator}, ec.setToDB); err != nil {
+		log.Error("setOperator error", "address", address.Hex(), "err", err)
+		return err
+	}
+	if operator == address {
+		return nil
+	}
+	return convertToKV(OPERATORINDEXPREFIX, operatorIndex{Owner: operator, Candidate: address}, ec.setToDB)
+}
@@ -0,0 +1,182 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package election
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/crypto"
+	"github.com/vntchain/go-vnt/params"
+)
+
+func TestSetOperator(t *testing.T) {
+	ec := newTestElectionCtx()
+	db := ec.context.GetStateDb()
+	ca := newTestCandi()
+	op1 := common.HexToAddress("41b0db166cfdf1c4ba3ce657171482a9aa55cc93")
+	op2 := common.HexToAddress("41b0db166cfdf1c4ba3ce657171482a9aa55cc94")
+
+	// 未注册的候选人不能设置出块账号
+	assert.Equal(t, ec.setOperator(ca.Owner, op1), ErrCandiNotReg)
+	if err := ec.setCandidate(*ca); err != nil {
+		t.Fatalf("set candidate error: %s", err)
+	}
+	assert.Equal(t, GetOperator(db, ca.Owner), ca.Owner)
+	assert.Equal(t, CandidateOf(db, ca.Owner), ca.Owner)
+
+	// 设置出块账号后见证人列表为出块账号
+	if err := ec.setOperator(ca.Owner, op1); err != nil {
+		t.Fatalf("set operator error: %s", err)
+	}
+	assert.Equal(t, GetOperator(db, ca.Owner), op1)
+	assert.Equal(t, CandidateOf(db, op1), ca.Owner)
+	if wits, _ := GetFirstNCandidates(db, 1, true); len(wits) != 1 || wits[0] != op1 {
+		t.Errorf("witness should be the operator, have %v", wits)
+	}
+	// 见证人的节点地址为候选人注册的地址
+	assert.Equal(t, GetWitnessUrl(db, op1), string(ca.Url))
+	assert.Equal(t, GetWitnessUrl(db, op2), "")
+
+	// 更换出块账号后，旧账号签名的区块仍属于该候选人
+	if err := ec.setOperator(ca.Owner, op2); err != nil {
+		t.Fatalf("rotate operator error: %s", err)
+	}
+	assert.Equal(t, GetOperator(db, ca.Owner), op2)
+	assert.Equal(t, CandidateOf(db, op1), ca.Owner)
+	assert.Equal(t, CandidateOf(db, op2), ca.Owner)
+
+	// 设置为空地址时恢复使用候选人账号
+	if err := ec.setOperator(ca.Owner, common.Address{}); err != nil {
+		t.Fatalf("reset operator error: %s", err)
+	}
+	assert.Equal(t, GetOperator(db, ca.Owner), ca.Owner)
+}
+
+func TestOperatorInUse(t *testing.T) {
+	ec := newTestElectionCtx()
+	ca := newTestCandi()
+	other := newTestCandi()
+	other.Owner = common.HexToAddress("41b0db166cfdf1c4ba3ce657171482a9aa55cc95")
+	op := common.HexToAddress("41b0db166cfdf1c4ba3ce657171482a9aa55cc93")
+	for _, c := range []*Candidate{ca, other} {
+		if err := ec.setCandidate(*c); err != nil {
+			t.Fatalf("set candidate error: %s", err)
+		}
+	}
+
+	// 其他候选人的地址不能作为出块账号
+	assert.Equal(t, ec.setOperator(ca.Owner, other.Owner), ErrOperatorInUse)
+
+	// 出块账号不能被其他候选人使用，更换后也不能
+	if err := ec.setOperator(ca.Owner, op); err != nil {
+		t.Fatalf("set operator error: %s", err)
+	}
+	assert.Equal(t, ec.setOperator(other.Owner, op), ErrOperatorInUse)
+	if err := ec.setOperator(ca.Owner, common.Address{}); err != nil {
+		t.Fatalf("reset operator error: %s", err)
+	}
+	assert.Equal(t, ec.setOperator(other.Owner, op), ErrOperatorInUse)
+
+	// 出块账号不能注册为候选人
+	assert.Equal(t, ec.registerWitness(op, &NodeInfo{}), ErrOperatorInUse)
+}
+
+func TestOperatorSlashing(t *testing.T) {
+	ec := newTestElectionCtx()
+	db := ec.context.GetStateDb()
+	key, _ := crypto.GenerateKey()
+	op := crypto.PubkeyToAddress(key.PublicKey)
+
+	ca := newTestCandi()
+	if err := ec.setCandidate(*ca); err != nil {
+		t.Fatalf("set candidate error: %s", err)
+	}
+	if err := ec.setOperator(ca.Owner, op); err != nil {
+		t.Fatalf("set operator error: %s", err)
+	}
+
+	// 出块账号错过出块计入候选人
+	now := ec.context.GetTime()
+	if err := RecordMissedSlots(db, addr1, []common.Address{op}, 3, now, true); err != nil {
+		t.Fatalf("record missed slots error: %s", err)
+	}
+	assert.Equal(t, getJail(db, ca.Owner).MissedSlots, big.NewInt(1))
+	if err := RecordMissedSlots(db, op, nil, 3, now, true); err != nil {
+		t.Fatalf("record missed slots error: %s", err)
+	}
+	assert.Equal(t, getJail(db, ca.Owner).MissedSlots.Sign(), 0)
+
+	// 出块账号双签时监禁候选人
+	ctx := ec.context.(*testContext)
+	ctx.BlockNum = big.NewInt(20)
+	ctx.Witnesses = map[uint64][]common.Address{10: {op}}
+	db.AddBalance(contractAddr, bindAmount)
+	if err := ec.reportEquivocation(newTestEvidence(t, key, 10)); err != nil {
+		t.Fatalf("report equivocation error: %s", err)
+	}
+	assert.Equal(t, isJailed(db, ca.Owner), true)
+	assert.Equal(t, getJail(db, ca.Owner).Forfeited, doubleSignSlash)
+}
+
+func TestOperatorFork(t *testing.T) {
+	ctx := newcontext().(*testContext)
+	ctx.Config = &params.ChainConfig{OperatorBlock: big.NewInt(100)}
+	ctx.BlockNum = big.NewInt(99)
+	ec := newElectionContext(ctx)
+	db := ctx.GetStateDb()
+	op := common.HexToAddress("41b0db166cfdf1c4ba3ce657171482a9aa55cc93")
+
+	ca := newTestCandi()
+	ca.Owner = ctx.GetOrigin()
+	if err := ec.setCandidate(*ca); err != nil {
+		t.Fatal(err)
+	}
+
+	electionABI, _ := GetElectionABI()
+	input, err := PackInput(electionABI, "setOperator", op)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 分叉之前不能设置出块账号
+	if _, err := (&Election{}).Run(ctx, input, big.NewInt(0)); err == nil {
+		t.Error("setOperator should be rejected before the fork")
+	}
+	assert.Equal(t, GetOperator(db, ca.Owner), ca.Owner)
+
+	ctx.BlockNum = big.NewInt(100)
+	if _, err := (&Election{}).Run(ctx, input, big.NewInt(0)); err != nil {
+		t.Fatalf("set operator error: %s", err)
+	}
+	assert.Equal(t, GetOperator(db, ca.Owner), op)
+
+	// 分叉之前见证人列表为候选人，出块账号不计入候选人
+	if wits, _ := GetFirstNCandidates(db, 1, false); len(wits) != 1 || wits[0] != ca.Owner {
+		t.Errorf("witness should be the candidate before the fork, have %v", wits)
+	}
+	if wits, _ := GetFirstNCandidates(db, 1, true); len(wits) != 1 || wits[0] != op {
+		t.Errorf("witness should be the operator since the fork, have %v", wits)
+	}
+	if err := RecordMissedSlots(db, addr1, []common.Address{op}, 3, ctx.GetTime(), false); err != nil {
+		t.Fatalf("record missed slots error: %s", err)
+	}
+	assert.Equal(t, getJail(db, ca.Owner).MissedSlots.Sign(), 0)
+	assert.Equal(t, getJail(db, op).MissedSlots, big.NewInt(1))
+}
@@ -0,0 +1,181 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package election
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/vntchain/go-vnt/accounts/abi"
+	"github.com/vntchain/go-vnt/common"
+	inter "github.com/vntchain/go-vnt/core/vm/interface"
+)
+
+var ErrQueryWithValue = errors.New("election query methods are not payable")
+
+// isQuery reports whether input calls one of the constant methods of the
+// election contract. The constant methods are supported since the election
+// query fork, and the ones of a feature since its fork, before them they are
+// called as the unknown methods.
+func isQuery(ctx inter.ChainContext, electionABI abi.ABI, input []byte) (*abi.Method, bool) {
+	ec := newElectionContext(ctx)
+	if len(input) < 4 || !ec.isElectionQuery() {
+		return nil, false
+	}
+	method, err := electionABI.MethodById(input[:4])
+	if err != nil || !method.Const || !ec.isQueryEnabled(method.Name) {
+		return nil, false
+	}
+	return method, true
+}
+
+// IsQuery reports whether input calls one of the constant methods of the
+// election contract, which never modify the state.
+func IsQuery(ctx inter.ChainContext, input []byte) bool {
+	electionABI, err := GetElectionABI()
+	if err != nil {
+		return false
+	}
+	_, ok := isQuery(ctx, electionABI, input)
+	return ok
+}
+
+// isElectionQuery reports whether the constant methods are supported at the
+// current block.
+func (ec electionContext) isElectionQuery() bool {
+	config := ec.context.GetChainConfig()
+	return config != nil && config.IsElectionQuery(ec.context.GetBlockNum())
+}
+
+// isQueryEnabled reports whether the feature queried by the constant method is
+// enabled at the current block.
+func (ec electionContext) isQueryEnabled(name string) bool {
+	switch name {
+	case "getJail":
+		return ec.isSlash()
+	case "getUnbonding":
+		return ec.isUnbonding()
+	case "getOperator":
+		return ec.isOperator()
+	}
+	return true
+}
+
+// query runs a constant method of the election contract and returns the
+// abi encoded outputs. It never modifies the state.
+//
+// The contract call of WAVM only unpacks the first output as a scalar, so
+// only getOperator and getRestReward can be called by contracts. The methods
+// returning more than one output, getCandidate, getVoter, getStake,
+// getUnbonding, getAllCandidates and getJail, are for RPC calls only.
+func (ec electionContext) query(electionABI abi.ABI, method *abi.Method, args []byte) ([]byte, error) {
+	stateDB := ec.context.GetStateDb()
+
+	switch method.Name {
+	case "getCandidate":
+		var addr common.Address
+		if err := electionABI.UnpackInput(&addr, method.Name, args); err != nil {
+			return nil, err
+		}
+		ca := newCandidate()
+		if c := GetCandidate(stateDB, addr); c != nil {
+			ca = *c
+		}
+		return method.Outputs.Pack(ca.Owner, ca.Binder, ca.Beneficiary, ca.VoteCount, ca.Registered, ca.Bind,
+			nonNilBytes(ca.Url), nonNilBytes(ca.Website), nonNilBytes(ca.Name))
+
+	case "getVoter":
+		var addr common.Address
+		if err := electionABI.UnpackInput(&addr, method.Name, args); err != nil {
+			return nil, err
+		}
+		v := newVoter()
+		if voter := GetVoter(stateDB, addr); voter != nil {
+			v = *voter
+		}
+		candidates := v.VoteCandidates
+		if candidates == nil {
+			candidates = []common.Address{}
+		}
+		return method.Outputs.Pack(v.Owner, v.IsProxy, v.ProxyVoteCount, v.Proxy, v.LastStakeCount, v.LastVoteCount,
+			v.TimeStamp, candidates)
+
+	case "getStake":
+		var addr common.Address
+		if err := electionABI.UnpackInput(&addr, method.Name, args); err != nil {
+			return nil, err
+		}
+		st := Stake{StakeCount: big.NewInt(0), Vnt: big.NewInt(0), TimeStamp: big.NewInt(0)}
+		if stake := GetStake(stateDB, addr); stake != nil {
+			st = *stake
+		}
+		return method.Outputs.Pack(st.Owner, st.StakeCount, st.Vnt, st.TimeStamp)
+
+	case "getUnbonding":
+		var addr common.Address
+		if err := electionABI.UnpackInput(&addr, method.Name, args); err != nil {
+			return nil, err
+		}
+		unbonding := getUnbonding(stateDB, addr)
+		vnts := make([]*big.Int, len(unbonding.Entries))
+		maturities := make([]*big.Int, len(unbonding.Entries))
+		for i, entry := range unbonding.Entries {
+			vnts[i] = entry.Vnt
+			maturities[i] = entry.Maturity
+		}
+		return method.Outputs.Pack(vnts, maturities)
+
+	case "getOperator":
+		var addr common.Address
+		if err := electionABI.UnpackInput(&addr, method.Name, args); err != nil {
+			return nil, err
+		}
+		return method.Outputs.Pack(GetOperator(stateDB, addr))
+
+	case "getAllCandidates":
+		list := GetAllCandidates(stateDB, true)
+		owners := make([]common.Address, len(list))
+		votes := make([]*big.Int, len(list))
+		actives := make([]bool, len(list))
+		for i, ca := range list {
+			owners[i] = ca.Owner
+			votes[i] = ca.VoteCount
+			actives[i] = ca.Active()
+		}
+		return method.Outputs.Pack(owners, votes, actives)
+
+	case "getJail":
+		var addr common.Address
+		if err := electionABI.UnpackInput(&addr, method.Name, args); err != nil {
+			return nil, err
+		}
+		j := getJail(stateDB, addr)
+		return method.Outputs.Pack(j.Owner, j.Jailed, j.ReleaseTime, j.MissedSlots, j.SlashedHeight, j.Forfeited)
+
+	case "getRestReward":
+		return method.Outputs.Pack(QueryRestReward(stateDB))
+	}
+	return nil, fmt.Errorf("call election contract err: query method %s doesn't exist", method.Name)
+}
+
+func nonNilBytes(b []byte) []byte {
+	if b == nil {
+		return []byte{}
+	}
+	return b
+}
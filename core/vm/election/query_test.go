@@ -0,0 +1,223 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package election
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/params"
+)
+
+func TestQueryCandidate(t *testing.T) {
+	var e Election
+	ec := newTestElectionCtx()
+	electionABI, _ := GetElectionABI()
+
+	ca := newTestCandi()
+	ca.VoteCount = big.NewInt(100)
+	if err := ec.setCandidate(*ca); err != nil {
+		t.Fatalf("set candidate error: %s", err)
+	}
+
+	input, err := PackInput(electionABI, "getCandidate", ca.Owner)
+	if err != nil {
+		t.Fatalf("pack input error: %s", err)
+	}
+	nonce := ec.context.GetStateDb().GetNonce(contractAddr)
+	ret, err := e.Run(ec.context, input, big.NewInt(0))
+	if err != nil {
+		t.Fatalf("query candidate error: %s", err)
+	}
+	assert.Equal(t, ec.context.GetStateDb().GetNonce(contractAddr), nonce, "query should not change nonce")
+
+	var got Candidate
+	if err := electionABI.Unpack(&got, "getCandidate", ret); err != nil {
+		t.Fatalf("unpack candidate error: %s", err)
+	}
+	assert.Equal(t, got.String(), ca.String())
+
+	// Query methods are not payable
+	_, err = e.Run(ec.context, input, big.NewInt(1))
+	assert.Equal(t, err, ErrQueryWithValue)
+}
+
+func TestQueryUnknownCandidate(t *testing.T) {
+	var e Election
+	ec := newTestElectionCtx()
+	electionABI, _ := GetElectionABI()
+
+	input, _ := PackInput(electionABI, "getCandidate", addr1)
+	ret, err := e.Run(ec.context, input, big.NewInt(0))
+	if err != nil {
+		t.Fatalf("query candidate error: %s", err)
+	}
+	var got Candidate
+	if err := electionABI.Unpack(&got, "getCandidate", ret); err != nil {
+		t.Fatalf("unpack candidate error: %s", err)
+	}
+	assert.Equal(t, got.Owner, emptyAddress)
+	assert.Equal(t, got.VoteCount.Sign(), 0)
+}
+
+func TestQueryStakeAndVoter(t *testing.T) {
+	var e Election
+	ec := newTestElectionCtx()
+	electionABI, _ := GetElectionABI()
+
+	if err := ec.stake(addr1, vnt2wei(10)); err != nil {
+		t.Fatalf("stake error: %s", err)
+	}
+	if err := ec.startProxy(addr1); err != nil {
+		t.Fatalf("start proxy error: %s", err)
+	}
+
+	input, _ := PackInput(electionABI, "getStake", addr1)
+	ret, err := e.Run(ec.context, input, big.NewInt(0))
+	if err != nil {
+		t.Fatalf("query stake error: %s", err)
+	}
+	var stake Stake
+	if err := electionABI.Unpack(&stake, "getStake", ret); err != nil {
+		t.Fatalf("unpack stake error: %s", err)
+	}
+	assert.Equal(t, stake.Owner, addr1)
+	assert.Equal(t, stake.StakeCount, big.NewInt(10))
+	assert.Equal(t, stake.Vnt, vnt2wei(10))
+
+	input, _ = PackInput(electionABI, "getVoter", addr1)
+	ret, err = e.Run(ec.context, input, big.NewInt(0))
+	if err != nil {
+		t.Fatalf("query voter error: %s", err)
+	}
+	var voter Voter
+	if err := electionABI.Unpack(&voter, "getVoter", ret); err != nil {
+		t.Fatalf("unpack voter error: %s", err)
+	}
+	assert.Equal(t, voter.Owner, addr1)
+	assert.Equal(t, voter.IsProxy, true)
+	assert.Equal(t, len(voter.VoteCandidates), 0)
+}
+
+func TestQueryAllCandidatesAndReward(t *testing.T) {
+	var e Election
+	ec := newTestElectionCtx()
+	electionABI, _ := GetElectionABI()
+
+	ca1 := Candidate{Owner: addr1, VoteCount: big.NewInt(10), Registered: true, Bind: true}
+	ca2 := Candidate{Owner: addr2, VoteCount: big.NewInt(20), Registered: true, Bind: false}
+	for _, ca := range []Candidate{ca1, ca2} {
+		if err := ec.setCandidate(ca); err != nil {
+			t.Fatalf("set candidate error: %s", err)
+		}
+	}
+	if err := setReward(ec.context.GetStateDb(), Reward{Rest: vnt2wei(5)}); err != nil {
+		t.Fatalf("set reward error: %s", err)
+	}
+
+	input, _ := PackInput(electionABI, "getAllCandidates")
+	ret, err := e.Run(ec.context, input, big.NewInt(0))
+	if err != nil {
+		t.Fatalf("query all candidates error: %s", err)
+	}
+	var all struct {
+		Owners     []common.Address
+		VoteCounts []*big.Int
+		Actives    []bool
+	}
+	if err := electionABI.Unpack(&all, "getAllCandidates", ret); err != nil {
+		t.Fatalf("unpack all candidates error: %s", err)
+	}
+	// Active candidates rank first
+	assert.Equal(t, all.Owners, []common.Address{addr1, addr2})
+	assert.Equal(t, all.VoteCounts, []*big.Int{big.NewInt(10), big.NewInt(20)})
+	assert.Equal(t, all.Actives, []bool{true, false})
+
+	input, _ = PackInput(electionABI, "getRestReward")
+	ret, err = e.Run(ec.context, input, big.NewInt(0))
+	if err != nil {
+		t.Fatalf("query rest reward error: %s", err)
+	}
+	var rest *big.Int
+	if err := electionABI.Unpack(&rest, "getRestReward", ret); err != nil {
+		t.Fatalf("unpack rest reward error: %s", err)
+	}
+	assert.Equal(t, rest, vnt2wei(5))
+}
+
+func TestQueryFork(t *testing.T) {
+	var e Election
+	ctx := newcontext().(*testContext)
+	ctx.Config = &params.ChainConfig{ElectionQueryBlock: big.NewInt(100)}
+	ctx.BlockNum = big.NewInt(99)
+	electionABI, _ := GetElectionABI()
+	db := ctx.GetStateDb()
+
+	// 分叉之前查询方法不存在，与其他未知方法一样增加nonce
+	input, _ := PackInput(electionABI, "getRestReward")
+	assert.Equal(t, IsQuery(ctx, input), false)
+	nonce := db.GetNonce(contractAddr)
+	if _, err := e.Run(ctx, input, big.NewInt(0)); err == nil || err.Error() != "call election contract err: method doesn't exist" {
+		t.Errorf("query before the fork error mismatch: %v", err)
+	}
+	assert.Equal(t, db.GetNonce(contractAddr), nonce+1)
+
+	ctx.BlockNum = big.NewInt(100)
+	assert.Equal(t, IsQuery(ctx, input), true)
+	if _, err := e.Run(ctx, input, big.NewInt(0)); err != nil {
+		t.Errorf("query since the fork error: %s", err)
+	}
+	assert.Equal(t, db.GetNonce(contractAddr), nonce+1)
+}
+
+func TestQueryFeatureFork(t *testing.T) {
+	var e Election
+	electionABI, _ := GetElectionABI()
+	addr := common.HexToAddress("0x122369f04f32269598789998de33e3d56e2c507a")
+
+	for _, tt := range []struct {
+		method string
+		config *params.ChainConfig
+	}{
+		{"getJail", &params.ChainConfig{ElectionQueryBlock: big.NewInt(0), SlashBlock: big.NewInt(100)}},
+		{"getUnbonding", &params.ChainConfig{ElectionQueryBlock: big.NewInt(0), UnbondingBlock: big.NewInt(100)}},
+		{"getOperator", &params.ChainConfig{ElectionQueryBlock: big.NewInt(0), OperatorBlock: big.NewInt(100)}},
+	} {
+		ctx := newcontext().(*testContext)
+		ctx.Config = tt.config
+		ctx.BlockNum = big.NewInt(99)
+		db := ctx.GetStateDb()
+
+		// 功能分叉之前查询方法不存在，与其他未知方法一样增加nonce
+		input, _ := PackInput(electionABI, tt.method, addr)
+		assert.Equal(t, IsQuery(ctx, input), false)
+		nonce := db.GetNonce(contractAddr)
+		if _, err := e.Run(ctx, input, big.NewInt(0)); err == nil || err.Error() != "call election contract err: method doesn't exist" {
+			t.Errorf("%s before the fork error mismatch: %v", tt.method, err)
+		}
+		assert.Equal(t, db.GetNonce(contractAddr), nonce+1)
+
+		ctx.BlockNum = big.NewInt(100)
+		assert.Equal(t, IsQuery(ctx, input), true)
+		if _, err := e.Run(ctx, input, big.NewInt(0)); err != nil {
+			t.Errorf("%s since the fork error: %s", tt.method, err)
+		}
+		assert.Equal(t, db.GetNonce(contractAddr), nonce+1)
+	}
+}
@@ -50,12 +50,15 @@ func (ec electionContext) depositReward(address common.Address, value *big.Int)
 // 发放激励的接口不区分是产块激励还是投票激励，超级节点必须是Active，否则无收益。
 // 激励金额不足发放时为正常情况不返回error，返回nil。
 // 返回错误时，数据状态恢复到原始情况，即所有激励都不发放。
-func GrantReward(stateDB inter.StateDB, rewards map[common.Address]*big.Int) (err error) {
+// 返回实际发放给各候选节点的激励金额。
+func GrantReward(stateDB inter.StateDB, rewards map[common.Address]*big.Int) (granted map[common.Address]*big.Int, err error) {
+	granted = make(map[common.Address]*big.Int)
+
 	// 无激励即可返回
 	reward := getReward(stateDB)
 	rest := reward.Rest
 	if rest.Cmp(common.Big0) <= 0 {
-		return nil
+		return granted, nil
 	}
 
 	// 退出时，如果存在错误，恢复原始状态
@@ -72,15 +75,20 @@ func GrantReward(stateDB inter.StateDB, rewards map[common.Address]*big.Int) (er
 			amount = rest
 		}
 		can := GetCandidate(stateDB, addr)
-		// 再检查：跳过不存在或未激活的候选人
+		// 再检查：跳过不存在、未激活或被监禁的候选人
 		if can == nil || !can.Active() {
 			log.Error("Not find candidate or inactive when granting reward", "addr", addr.String())
 			continue
 		}
+		if isJailed(stateDB, addr) {
+			log.Warn("Skip granting reward to jailed candidate", "addr", addr.String())
+			continue
+		}
 		// 发送错误退出
 		if err = transfer(stateDB, contractAddr, can.Beneficiary, amount); err != nil {
-			return err
+			return nil, err
 		}
+		granted[addr] = new(big.Int).Set(amount)
 		rest = rest.Sub(rest, amount)
 		// 发放到无剩余激励
 		if rest.Cmp(common.Big0) <= 0 {
@@ -89,7 +97,10 @@ func GrantReward(stateDB inter.StateDB, rewards map[common.Address]*big.Int) (er
 	}
 
 	// 激励正常发放完毕，更新剩余激励
-	return setReward(stateDB, Reward{Rest: big.NewInt(0).Set(rest)})
+	if err = setReward(stateDB, Reward{Rest: big.NewInt(0).Set(rest)}); err != nil {
+		return nil, err
+	}
+	return granted, nil
 }
 
 // QueryRestReward returns the value of left reward for candidates.
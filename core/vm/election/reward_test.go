@@ -107,7 +107,7 @@ func testGrantBounty(t *testing.T, cas *grantCase) {
 	}
 
 	// 执行分激励
-	err = GrantReward(db, cas.rewards)
+	granted, err := GrantReward(db, cas.rewards)
 	assert.Equal(t, err, cas.errExpOfGrant, fmt.Sprintf("%v, grant bounty error mismatch", cas.name))
 
 	// 校验回滚
@@ -131,6 +131,11 @@ func testGrantBounty(t *testing.T, cas *grantCase) {
 	reducedBalance := big.NewInt(0).Sub(cas.balance, db.GetBalance(contractAddr))
 	reducedReward := big.NewInt(0).Sub(cas.rewardBalance, getReward(db).Rest)
 	assert.Equal(t, reducedBalance, reducedReward, ",", cas.name, "reduced balance should always equal to reduces reward")
+	totalGranted := big.NewInt(0)
+	for _, re := range granted {
+		totalGranted = totalGranted.Add(totalGranted, re)
+	}
+	assert.Equal(t, reducedReward.Cmp(totalGranted), 0, ",", cas.name, "reduced reward should equal total granted reward")
 	if cas.matchTotal {
 		assert.Equal(t, reducedBalance, totalReward, ",", cas.name, "reduced contract balance should equal total reward")
 		assert.Equal(t, reducedReward, totalReward, ",", cas.name, "reduced contract reward should equal total reward")
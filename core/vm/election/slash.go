@@ -0,0 +1,253 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package election
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/types"
+	inter "github.com/vntchain/go-vnt/core/vm/interface"
+	"github.com/vntchain/go-vnt/log"
+	"github.com/vntchain/go-vnt/params"
+	"github.com/vntchain/go-vnt/rlp"
+)
+
+var (
+	ErrCandiNotJailed     = errors.New("candidate is not jailed")
+	ErrJailNotExpired     = errors.New("candidate can not unjail before release time")
+	ErrEvidenceDuplicated = errors.New("candidate is already punished at this height")
+	ErrEvidenceExpired    = errors.New("evidence is not of the recent blocks")
+	ErrSignerNotWitness   = errors.New("evidence signer is not a witness at the height")
+)
+
+// maxEvidenceAge 双签证据的有效期，只接受最近这些区块的证据，约为一小时的区块数
+const maxEvidenceAge = 1800
+
+const (
+	// evidenceGas 举报双签的基础gas，包括恢复两个签名和查找该高度的见证人列表
+	evidenceGas uint64 = 2*params.EcrecoverGas + params.SloadGas
+	// evidenceWordGas 证据每32字节的解码和哈希gas
+	evidenceWordGas uint64 = params.Sha3WordGas
+)
+
+var (
+	// 双签惩罚：罚没1/10的绑定金，监禁一周
+	doubleSignSlash  = big.NewInt(0).Div(bindAmount, big.NewInt(10))
+	doubleSignJailed = big.NewInt(oneWeek)
+
+	// 连续错过出块：监禁一天，不罚没绑定金
+	missedSlotsJailed = big.NewInt(OneDay)
+)
+
+// Jail records the misbehaviour and punishment of a witness candidate.
+type Jail struct {
+	Owner         common.Address // 候选人地址
+	Jailed        bool           // 是否处于监禁状态
+	ReleaseTime   *big.Int       // 可以解除监禁的时间
+	MissedSlots   *big.Int       // 连续错过出块的次数
+	SlashedHeight *big.Int       // 最近一次因双签被惩罚的区块高度
+	Forfeited     *big.Int       // 已被罚没的绑定金
+}
+
+func newJail(addr common.Address) Jail {
+	return Jail{
+		Owner:         addr,
+		Jailed:        false,
+		ReleaseTime:   big.NewInt(0),
+		MissedSlots:   big.NewInt(0),
+		SlashedHeight: big.NewInt(0),
+		Forfeited:     big.NewInt(0),
+	}
+}
+
+func getJail(stateDB inter.StateDB, addr common.Address) Jail {
+	var jail Jail
+	if err := convertToStruct(JAILPREFIX, addr, &jail, genGetFunc(stateDB)); err != nil || jail.Owner != addr {
+		return newJail(addr)
+	}
+	return jail
+}
+
+func setJail(stateDB inter.StateDB, jail Jail) error {
+	err := convertToKV(JAILPREFIX, jail, genSetFunc(stateDB))
+	if err != nil {
+		log.Error("setJail error", "err", err, "jail", jail)
+	}
+	return err
+}
+
+// isJailed reports whether the candidate is jailed.
+func isJailed(stateDB inter.StateDB, addr common.Address) bool {
+	return getJail(stateDB, addr).Jailed
+}
+
+// GetJail returns the punishment information of a candidate.
+func GetJail(stateDB inter.StateDB, addr common.Address) *Jail {
+	jail := getJail(stateDB, addr)
+	return &jail
+}
+
+// isSlash reports whether the witnesses can be jailed and slashed at the
+// current block.
+func (ec electionContext) isSlash() bool {
+	config := ec.context.GetChainConfig()
+	return config != nil && config.IsSlash(ec.context.GetBlockNum())
+}
+
+// reportEquivocation 提交见证人双签的证据，证据有效时监禁该候选人并罚没部分绑定金，
+// 罚没的绑定金转入剩余激励。
+func (ec electionContext) reportEquivocation(evidence []byte) error {
+	var e types.Equivocation
+	if err := rlp.DecodeBytes(evidence, &e); err != nil {
+		return err
+	}
+	if err := e.Verify(); err != nil {
+		return err
+	}
+	if err := ec.verifyEvidenceSigner(&e); err != nil {
+		return err
+	}
+
+	// 双签的是候选人的出块账号
+	stateDB := ec.context.GetStateDb()
+	owner := witnessOwner(stateDB, e.Signer, ec.isOperator())
+	candidate := ec.getCandidate(owner)
+	if candidate.Owner != owner {
+		return ErrCandiNotReg
+	}
+
+	jail := getJail(stateDB, owner)
+	if e.BlockNumber.Cmp(jail.SlashedHeight) <= 0 {
+		return ErrEvidenceDuplicated
+	}
+
+	// 已绑定的候选人罚没绑定金，罚没总额不超过绑定金
+	forfeit := big.NewInt(0)
+	if candidate.Bind {
+		forfeit.Sub(bindAmount, jail.Forfeited)
+		if forfeit.Cmp(doubleSignSlash) > 0 {
+			forfeit.Set(doubleSignSlash)
+		}
+	}
+
+	jail.Jailed = true
+	jail.ReleaseTime = new(big.Int).Add(ec.context.GetTime(), doubleSignJailed)
+	jail.SlashedHeight = new(big.Int).Set(e.BlockNumber)
+	jail.Forfeited = new(big.Int).Add(jail.Forfeited, forfeit)
+	if err := setJail(stateDB, jail); err != nil {
+		return err
+	}
+	log.Warn("Witness is punished for equivocation", "evidence", e.String(), "forfeit", forfeit)
+
+	if forfeit.Sign() > 0 {
+		reward := getReward(stateDB)
+		reward.Rest = new(big.Int).Add(reward.Rest, forfeit)
+		return setReward(stateDB, reward)
+	}
+	return nil
+}
+
+// reportEquivocationGas returns the gas of reporting the evidence, which is in
+// proportion to the size of evidence.
+func reportEquivocationGas(args []byte) uint64 {
+	return evidenceGas + uint64(len(args)+31)/32*evidenceWordGas
+}
+
+// verifyEvidenceSigner 双签的账号须是该高度区块的见证人，只接受最近区块的证据
+func (ec electionContext) verifyEvidenceSigner(e *types.Equivocation) error {
+	current := ec.context.GetBlockNum()
+	if e.BlockNumber.Sign() <= 0 || e.BlockNumber.Cmp(current) >= 0 ||
+		new(big.Int).Sub(current, e.BlockNumber).Cmp(big.NewInt(maxEvidenceAge)) > 0 {
+		return ErrEvidenceExpired
+	}
+	for _, witness := range ec.context.GetWitnesses(e.BlockNumber) {
+		if witness == e.Signer {
+			return nil
+		}
+	}
+	return ErrSignerNotWitness
+}
+
+// unjailWitness 被监禁的候选人在监禁期满后解除监禁
+func (ec electionContext) unjailWitness(address common.Address) error {
+	stateDB := ec.context.GetStateDb()
+	jail := getJail(stateDB, address)
+	if !jail.Jailed {
+		return ErrCandiNotJailed
+	}
+	if ec.context.GetTime().Cmp(jail.ReleaseTime) < 0 {
+		return ErrJailNotExpired
+	}
+
+	jail.Jailed = false
+	jail.MissedSlots = big.NewInt(0)
+	return setJail(stateDB, jail)
+}
+
+// returnBindAmount 返还绑定金中未被罚没的部分
+func (ec electionContext) returnBindAmount(candi, binder common.Address) error {
+	stateDB := ec.context.GetStateDb()
+	amount := new(big.Int).Set(bindAmount)
+
+	jail := getJail(stateDB, candi)
+	if jail.Forfeited.Sign() > 0 {
+		amount.Sub(amount, jail.Forfeited)
+		jail.Forfeited = big.NewInt(0)
+		if err := setJail(stateDB, jail); err != nil {
+			return err
+		}
+	}
+	if amount.Sign() <= 0 {
+		return nil
+	}
+	return ec.transfer(contractAddr, binder, amount)
+}
+
+// RecordMissedSlots updates the missed slot counters of witnesses. The counter
+// of the block producer is reset, and the witnesses that missed their slots will
+// be jailed for one day, once their counter reach the threshold. The producer
+// and missed witnesses are the operators in witness list if operator is true,
+// the candidates otherwise.
+func RecordMissedSlots(stateDB inter.StateDB, producer common.Address, missed []common.Address, threshold uint64, now *big.Int, operator bool) error {
+	if jail := getJail(stateDB, witnessOwner(stateDB, producer, operator)); jail.MissedSlots.Sign() > 0 {
+		jail.MissedSlots = big.NewInt(0)
+		if err := setJail(stateDB, jail); err != nil {
+			return err
+		}
+	}
+
+	limit := new(big.Int).SetUint64(threshold)
+	for _, addr := range missed {
+		jail := getJail(stateDB, witnessOwner(stateDB, addr, operator))
+		if jail.Jailed {
+			continue
+		}
+		jail.MissedSlots = new(big.Int).Add(jail.MissedSlots, common.Big1)
+		if jail.MissedSlots.Cmp(limit) >= 0 {
+			log.Warn("Witness is jailed for missing slots", "witness", addr.String(), "missed", jail.MissedSlots)
+			jail.Jailed = true
+			jail.ReleaseTime = new(big.Int).Add(now, missedSlotsJailed)
+			jail.MissedSlots = big.NewInt(0)
+		}
+		if err := setJail(stateDB, jail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
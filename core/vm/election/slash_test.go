@@ -0,0 +1,209 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package election
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/crypto"
+	"github.com/vntchain/go-vnt/params"
+	"github.com/vntchain/go-vnt/rlp"
+)
+
+func newTestEvidence(t *testing.T, key *ecdsa.PrivateKey, number int64) []byte {
+	newCommit := func(hash common.Hash) *types.CommitMsg {
+		msg := &types.CommitMsg{
+			Round:       0,
+			Commiter:    crypto.PubkeyToAddress(key.PublicKey),
+			BlockNumber: big.NewInt(number),
+			BlockHash:   hash,
+		}
+		msg.CommitSig, _ = crypto.Sign(msg.Hash().Bytes(), key)
+		return msg
+	}
+	e := types.NewEquivocation(newCommit(common.HexToHash("0x01")), newCommit(common.HexToHash("0x02")))
+	enc, err := rlp.EncodeToBytes(e)
+	if err != nil {
+		t.Fatalf("encode evidence error: %s", err)
+	}
+	return enc
+}
+
+func TestReportEquivocation(t *testing.T) {
+	ec := newTestElectionCtx()
+	db := ec.context.GetStateDb()
+	key, _ := crypto.GenerateKey()
+
+	ca := newTestCandi()
+	ca.Owner = crypto.PubkeyToAddress(key.PublicKey)
+	evidence := newTestEvidence(t, key, 10)
+
+	// 双签的账号不是该高度的见证人
+	ctx := ec.context.(*testContext)
+	ctx.BlockNum = big.NewInt(20)
+	ctx.Witnesses = map[uint64][]common.Address{10: {addr1, addr2}}
+	assert.Equal(t, ec.reportEquivocation(evidence), ErrSignerNotWitness)
+	ctx.Witnesses[10] = []common.Address{addr1, ca.Owner}
+
+	// 未注册的候选人
+	assert.Equal(t, ec.reportEquivocation(evidence), ErrCandiNotReg)
+
+	// 已绑定的候选人被监禁并罚没绑定金
+	if err := ec.setCandidate(*ca); err != nil {
+		t.Fatalf("set candidate error: %s", err)
+	}
+	db.AddBalance(contractAddr, bindAmount)
+	if err := ec.reportEquivocation(evidence); err != nil {
+		t.Fatalf("report equivocation error: %s", err)
+	}
+	jail := getJail(db, ca.Owner)
+	assert.Equal(t, jail.Jailed, true)
+	assert.Equal(t, jail.Forfeited, doubleSignSlash)
+	assert.Equal(t, QueryRestReward(db), doubleSignSlash)
+
+	// 同一高度的证据不能重复惩罚
+	assert.Equal(t, ec.reportEquivocation(evidence), ErrEvidenceDuplicated)
+
+	// 被监禁的候选人不能成为见证人
+	if wits, _ := GetFirstNCandidates(db, 1, true); len(wits) != 0 {
+		t.Errorf("jailed candidate should not be witness")
+	}
+
+	// 取消绑定时只返还未罚没的绑定金
+	if err := ec.unbindCandidate(ca.Binder, newTestBindInfo(ca)); err != nil {
+		t.Fatalf("unbind candidate error: %s", err)
+	}
+	assert.Equal(t, db.GetBalance(ca.Binder), new(big.Int).Sub(bindAmount, doubleSignSlash))
+	assert.Equal(t, getJail(db, ca.Owner).Forfeited.Sign(), 0)
+}
+
+func TestEvidenceExpired(t *testing.T) {
+	ec := newTestElectionCtx()
+	key, _ := crypto.GenerateKey()
+	ctx := ec.context.(*testContext)
+	ctx.Witnesses = map[uint64][]common.Address{10: {crypto.PubkeyToAddress(key.PublicKey)}}
+	evidence := newTestEvidence(t, key, 10)
+
+	// 当前及之后区块的证据
+	ctx.BlockNum = big.NewInt(10)
+	assert.Equal(t, ec.reportEquivocation(evidence), ErrEvidenceExpired)
+
+	// 过早区块的证据
+	ctx.BlockNum = big.NewInt(10 + maxEvidenceAge + 1)
+	assert.Equal(t, ec.reportEquivocation(evidence), ErrEvidenceExpired)
+
+	ctx.BlockNum = big.NewInt(10 + maxEvidenceAge)
+	assert.Equal(t, ec.reportEquivocation(evidence), ErrCandiNotReg)
+}
+
+func TestSlashFork(t *testing.T) {
+	ec := newTestElectionCtx()
+	ctx := ec.context.(*testContext)
+	ctx.Config = &params.ChainConfig{SlashBlock: big.NewInt(100)}
+	ctx.BlockNum = big.NewInt(99)
+
+	// 分叉前不能举报双签和解除监禁
+	electionABI, _ := GetElectionABI()
+	input, _ := PackInput(electionABI, "unjailWitness")
+	e := Election{}
+	if _, err := e.Run(ctx, input, big.NewInt(0)); err == nil {
+		t.Error("unjailWitness should not exist before the slash fork")
+	}
+	ctx.BlockNum = big.NewInt(100)
+	if _, err := e.Run(ctx, input, big.NewInt(0)); err != ErrCandiNotJailed {
+		t.Errorf("unjailWitness error mismatch: have %v, want %v", err, ErrCandiNotJailed)
+	}
+}
+
+func TestReportEquivocationGas(t *testing.T) {
+	ctx := newcontext().(*testContext)
+	ctx.Config = &params.ChainConfig{SlashBlock: big.NewInt(100)}
+	ctx.BlockNum = big.NewInt(99)
+	electionABI, _ := GetElectionABI()
+	key, _ := crypto.GenerateKey()
+	input, _ := PackInput(electionABI, "reportEquivocation", newTestEvidence(t, key, 10))
+	e := Election{}
+
+	// 分叉前不收取gas
+	assert.Equal(t, e.RequiredGas(ctx, input), uint64(0))
+
+	ctx.BlockNum = big.NewInt(100)
+	gas := e.RequiredGas(ctx, input)
+	if gas < 2*params.EcrecoverGas {
+		t.Errorf("report equivocation gas is too low: %d", gas)
+	}
+	assert.Equal(t, e.RequiredGas(ctx, append(input, make([]byte, 64)...)), gas+2*evidenceWordGas)
+
+	// 其他方法不收取gas
+	input, _ = PackInput(electionABI, "unjailWitness")
+	assert.Equal(t, e.RequiredGas(ctx, input), uint64(0))
+}
+
+func TestUnjailWitness(t *testing.T) {
+	ec := newTestElectionCtx()
+	db := ec.context.GetStateDb()
+	ca := newTestCandi()
+	if err := ec.setCandidate(*ca); err != nil {
+		t.Fatalf("set candidate error: %s", err)
+	}
+
+	assert.Equal(t, ec.unjailWitness(ca.Owner), ErrCandiNotJailed)
+
+	now := ec.context.GetTime()
+	for i := 0; i < 3; i++ {
+		if err := RecordMissedSlots(db, addr1, []common.Address{ca.Owner}, 3, now, true); err != nil {
+			t.Fatalf("record missed slots error: %s", err)
+		}
+	}
+	jail := getJail(db, ca.Owner)
+	assert.Equal(t, jail.Jailed, true)
+	assert.Equal(t, jail.ReleaseTime, new(big.Int).Add(now, missedSlotsJailed))
+	if wits, _ := GetFirstNCandidates(db, 1, true); len(wits) != 0 {
+		t.Errorf("jailed candidate should not be witness")
+	}
+
+	assert.Equal(t, ec.unjailWitness(ca.Owner), ErrJailNotExpired)
+	twentyFourHoursLater(t, ec.context)
+	if err := ec.unjailWitness(ca.Owner); err != nil {
+		t.Fatalf("unjail witness error: %s", err)
+	}
+	if wits, _ := GetFirstNCandidates(db, 1, true); len(wits) != 1 || wits[0] != ca.Owner {
+		t.Errorf("unjailed candidate should be witness")
+	}
+}
+
+func TestRecordMissedSlots(t *testing.T) {
+	ec := newTestElectionCtx()
+	db := ec.context.GetStateDb()
+	now := ec.context.GetTime()
+
+	// 出块后连续错过的次数清零
+	if err := RecordMissedSlots(db, addr1, []common.Address{addr2}, 3, now, true); err != nil {
+		t.Fatalf("record missed slots error: %s", err)
+	}
+	if err := RecordMissedSlots(db, addr2, []common.Address{addr1}, 3, now, true); err != nil {
+		t.Fatalf("record missed slots error: %s", err)
+	}
+	assert.Equal(t, getJail(db, addr2).MissedSlots.Sign(), 0)
+	assert.Equal(t, getJail(db, addr1).MissedSlots, big.NewInt(1))
+	assert.Equal(t, isJailed(db, addr1), false)
+}
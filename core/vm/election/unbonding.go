@@ -0,0 +1,249 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package election
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/vntchain/go-vnt/common"
+	inter "github.com/vntchain/go-vnt/core/vm/interface"
+	"github.com/vntchain/go-vnt/log"
+)
+
+// MaxUnbondingEntries is the maximum number of pending withdrawals of a staker.
+const MaxUnbondingEntries = 16
+
+var (
+	ErrUnStakeAmountInvalid = errors.New("unStake amount should be positive and not more than the stake")
+	ErrUnbondingQueueFull   = errors.New("too many pending unStake, claim the matured ones first")
+	ErrNoMaturedUnbonding   = errors.New("no matured unStake to claim")
+)
+
+var (
+	// defaultUnbondingPeriod is used when the chain config doesn't set the period.
+	defaultUnbondingPeriod = big.NewInt(OneDay)
+
+	// stake minimum time period before the unbonding fork
+	unstakePeriod = big.NewInt(OneDay)
+)
+
+// UnbondingEntry is a withdrawal of stake waiting for maturity.
+type UnbondingEntry struct {
+	Vnt      *big.Int // 解锁中的代币数，单位Wei
+	Maturity *big.Int // 可以提取的时间
+}
+
+// Unbonding is the queue of pending withdrawals of a staker, in the order of
+// maturity.
+type Unbonding struct {
+	Owner   common.Address   // 抵押人地址
+	Entries []UnbondingEntry // 解锁中的抵押
+}
+
+func getUnbonding(stateDB inter.StateDB, addr common.Address) Unbonding {
+	var unbonding Unbonding
+	if err := convertToStruct(UNBONDINGPREFIX, addr, &unbonding, genGetFunc(stateDB)); err != nil || unbonding.Owner != addr {
+		return Unbonding{Owner: addr}
+	}
+	return unbonding
+}
+
+func setUnbonding(stateDB inter.StateDB, unbonding Unbonding) error {
+	err := convertToKV(UNBONDINGPREFIX, unbonding, genSetFunc(stateDB))
+	if err != nil {
+		log.Error("setUnbonding error", "err", err, "unbonding", unbonding)
+	}
+	return err
+}
+
+// GetUnbonding returns the pending withdrawals of a staker. Return nil if the
+// staker has no pending withdrawal.
+func GetUnbonding(stateDB inter.StateDB, addr common.Address) *Unbonding {
+	unbonding := getUnbonding(stateDB, addr)
+	if len(unbonding.Entries) == 0 {
+		return nil
+	}
+	return &unbonding
+}
+
+// isUnbonding reports whether the stake is unStaked into the unbonding queue
+// at the current block.
+func (ec electionContext) isUnbonding() bool {
+	config := ec.context.GetChainConfig()
+	return config != nil && config.IsUnbonding(ec.context.GetBlockNum())
+}
+
+// unbondingPeriod returns the period from unStake to the withdrawal.
+func (ec electionContext) unbondingPeriod() *big.Int {
+	if config := ec.context.GetChainConfig(); config != nil && config.Dpos != nil && config.Dpos.UnbondingPeriod > 0 {
+		return new(big.Int).SetUint64(config.Dpos.UnbondingPeriod)
+	}
+	return defaultUnbondingPeriod
+}
+
+// unStake 解除抵押，解除的代币进入解锁队列，票数立即减少，解锁期满后由claimUnStake提取
+func (ec electionContext) unStake(address common.Address, amount *big.Int) error {
+	// get stake from db
+	stake := ec.getStake(address)
+
+	// if stake is not found in db, just ignore
+	if !bytes.Equal(stake.Owner.Bytes(), address.Bytes()) {
+		log.Error("unStake stake is not found in db.", "address", address.Hex())
+		return fmt.Errorf("unStake stake is not found in db")
+	}
+
+	// no stake, no need to unstake, just ignore
+	if stake.Vnt.Sign() == 0 {
+		log.Error("unStake 0 stakeCount.", "address", address.Hex())
+		return fmt.Errorf("unStake 0 stakeCount")
+	}
+
+	// unStake all the stake if amount is not given
+	if amount == nil {
+		amount = new(big.Int).Set(stake.Vnt)
+	}
+	if amount.Sign() <= 0 || amount.Cmp(stake.Vnt) > 0 {
+		return ErrUnStakeAmountInvalid
+	}
+
+	stateDB := ec.context.GetStateDb()
+	unbonding := getUnbonding(stateDB, address)
+	if len(unbonding.Entries) >= MaxUnbondingEntries {
+		return ErrUnbondingQueueFull
+	}
+
+	stake.Vnt = new(big.Int).Sub(stake.Vnt, amount)
+	stake.StakeCount = new(big.Int).Div(stake.Vnt, big.NewInt(1e+18))
+	if err := ec.setStake(stake); err != nil {
+		log.Error("unStake setStake err.", "address", address.Hex(), "err", err)
+		return err
+	}
+
+	// 解锁中的代币不再计入票数
+	if err := ec.reduceVotes(address, stake.StakeCount); err != nil {
+		return err
+	}
+
+	unbonding.Entries = append(unbonding.Entries, UnbondingEntry{
+		Vnt:      new(big.Int).Set(amount),
+		Maturity: new(big.Int).Add(ec.context.GetTime(), ec.unbondingPeriod()),
+	})
+	return setUnbonding(stateDB, unbonding)
+}
+
+// legacyUnStake 解除全部抵押，抵押满24小时后代币立即返还，票数不变，用于unbonding分叉之前
+func (ec electionContext) legacyUnStake(address common.Address) error {
+	// get stake from db
+	stake := ec.getStake(address)
+
+	// if stake is not found in db, just ignore
+	if !bytes.Equal(stake.Owner.Bytes(), address.Bytes()) {
+		log.Error("unStake stake is not found in db.", "address", address.Hex())
+		return fmt.Errorf("unStake stake is not found in db")
+	}
+
+	// no stake, no need to unstake, just ignore
+	if stake.Vnt.Cmp(big.NewInt(0)) == 0 {
+		log.Error("unStake 0 stakeCount.", "address", address.Hex())
+		return fmt.Errorf("unStake 0 stakeCount")
+	}
+
+	// get the time point that can unstake
+	canUnstakeTime := big.NewInt(0).Add(stake.TimeStamp, unstakePeriod)
+
+	// if time is less than minimum stake period, cannot untake, just ignore
+	if ec.context.GetTime().Cmp(canUnstakeTime) < 0 {
+		log.Error("cannot unstake in 24 hours", "address", address.Hex())
+		return fmt.Errorf("cannot unstake in 24 hours")
+	}
+
+	amount := stake.Vnt
+	// sub stakeCount of staker
+	stake.StakeCount = big.NewInt(0)
+	stake.Vnt = big.NewInt(0)
+
+	// save stake into db
+	err := ec.setStake(stake)
+	if err != nil {
+		log.Error("unStake setStake err.", "address", address.Hex(), "err", err)
+		return err
+	}
+
+	// add balance of staker
+	return ec.transfer(contractAddr, address, amount)
+}
+
+// claimUnStake 提取解锁期满的代币
+func (ec electionContext) claimUnStake(address common.Address) error {
+	stateDB := ec.context.GetStateDb()
+	unbonding := getUnbonding(stateDB, address)
+
+	now := ec.context.GetTime()
+	amount := big.NewInt(0)
+	var pending []UnbondingEntry
+	for _, entry := range unbonding.Entries {
+		if now.Cmp(entry.Maturity) >= 0 {
+			amount.Add(amount, entry.Vnt)
+		} else {
+			pending = append(pending, entry)
+		}
+	}
+	if amount.Sign() == 0 {
+		return ErrNoMaturedUnbonding
+	}
+
+	unbonding.Entries = pending
+	if err := setUnbonding(stateDB, unbonding); err != nil {
+		return err
+	}
+
+	// add balance of staker
+	return ec.transfer(contractAddr, address, amount)
+}
+
+// reduceVotes reduces the votes of the voter in proportion, when the stake
+// is reduced to stakeCount below the stake of the last vote.
+func (ec electionContext) reduceVotes(address common.Address, stakeCount *big.Int) error {
+	voter := ec.getVoter(address)
+	if voter.Owner != address || voter.LastStakeCount == nil || voter.LastStakeCount.Cmp(stakeCount) <= 0 {
+		return nil
+	}
+
+	voteCount := new(big.Int).Mul(voter.LastVoteCount, stakeCount)
+	voteCount.Div(voteCount, voter.LastStakeCount)
+	delta := new(big.Int).Sub(voter.LastVoteCount, voteCount)
+
+	if delta.Sign() > 0 {
+		subOp := func(count *big.Int) {
+			count.Sub(count, delta)
+		}
+		if voter.Proxy != emptyAddress {
+			if err := ec.subProxyVote(voter.Proxy, delta); err != nil {
+				return err
+			}
+		} else if err := ec.opCandidates(&voter, subOp); err != nil {
+			return err
+		}
+	}
+
+	voter.LastStakeCount = new(big.Int).Set(stakeCount)
+	voter.LastVoteCount = voteCount
+	return ec.setVoter(voter)
+}
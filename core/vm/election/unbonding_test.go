@@ -0,0 +1,222 @@
+package election
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/params"
+)
+
+func newTestStaker(t *testing.T, ec electionContext, addr common.Address, vnt int) {
+	db := ec.context.GetStateDb()
+	db.AddBalance(contractAddr, vnt2wei(vnt))
+	if err := ec.stake(addr, vnt2wei(vnt)); err != nil {
+		t.Fatalf("stake error: %s", err)
+	}
+}
+
+func TestPartialUnStake(t *testing.T) {
+	ec := newTestElectionCtx()
+	db := ec.context.GetStateDb()
+	addr := common.HexToAddress("41b0db166cfdf1c4ba3ce657171482a9aa55cc93")
+	newTestStaker(t, ec, addr, 100)
+
+	// 解除部分抵押
+	assert.Equal(t, ec.unStake(addr, vnt2wei(101)), ErrUnStakeAmountInvalid)
+	assert.Equal(t, ec.unStake(addr, big.NewInt(0)), ErrUnStakeAmountInvalid)
+	assert.Equal(t, ec.unStake(addr, vnt2wei(30)), nil)
+	stake := ec.getStake(addr)
+	checkStake(t, &stake, addr, vnt2wei(70), big.NewInt(70))
+
+	// 半天后再解除一部分
+	now := ec.context.GetTime()
+	ec.context.(*testContext).SetTime(new(big.Int).Add(now, big.NewInt(OneDay/2)))
+	assert.Equal(t, ec.unStake(addr, vnt2wei(20)), nil)
+
+	unbonding := GetUnbonding(db, addr)
+	if unbonding == nil || len(unbonding.Entries) != 2 {
+		t.Fatalf("unbonding entries mismatch: %v", unbonding)
+	}
+	assert.Equal(t, unbonding.Entries[0].Vnt, vnt2wei(30))
+	assert.Equal(t, unbonding.Entries[0].Maturity, new(big.Int).Add(now, big.NewInt(OneDay)))
+	assert.Equal(t, unbonding.Entries[1].Vnt, vnt2wei(20))
+
+	// 只能提取到期的部分
+	ec.context.(*testContext).SetTime(new(big.Int).Add(now, big.NewInt(OneDay)))
+	assert.Equal(t, ec.claimUnStake(addr), nil)
+	assert.Equal(t, db.GetBalance(addr), vnt2wei(30))
+	assert.Equal(t, ec.claimUnStake(addr), ErrNoMaturedUnbonding)
+
+	ec.context.(*testContext).SetTime(new(big.Int).Add(now, big.NewInt(OneDay*2)))
+	assert.Equal(t, ec.claimUnStake(addr), nil)
+	assert.Equal(t, db.GetBalance(addr), vnt2wei(50))
+	if GetUnbonding(db, addr) != nil {
+		t.Errorf("unbonding should be empty after claim")
+	}
+}
+
+func TestUnbondingQueueFull(t *testing.T) {
+	ec := newTestElectionCtx()
+	addr := common.HexToAddress("41b0db166cfdf1c4ba3ce657171482a9aa55cc93")
+	newTestStaker(t, ec, addr, 100)
+
+	for i := 0; i < MaxUnbondingEntries; i++ {
+		if err := ec.unStake(addr, vnt2wei(1)); err != nil {
+			t.Fatalf("unStake %d error: %s", i, err)
+		}
+	}
+	assert.Equal(t, ec.unStake(addr, vnt2wei(1)), ErrUnbondingQueueFull)
+}
+
+func TestUnbondingPeriod(t *testing.T) {
+	config := &params.ChainConfig{UnbondingBlock: big.NewInt(0), Dpos: &params.DposConfig{UnbondingPeriod: 60}}
+	ctx := newcontext().(*testContext)
+	ctx.Config = config
+	ec := newElectionContext(ctx)
+	addr := common.HexToAddress("41b0db166cfdf1c4ba3ce657171482a9aa55cc93")
+	newTestStaker(t, ec, addr, 10)
+
+	assert.Equal(t, ec.unStake(addr, nil), nil)
+	ctx.SetTime(new(big.Int).Add(ctx.GetTime(), big.NewInt(59)))
+	assert.Equal(t, ec.claimUnStake(addr), ErrNoMaturedUnbonding)
+	ctx.SetTime(new(big.Int).Add(ctx.GetTime(), big.NewInt(1)))
+	assert.Equal(t, ec.claimUnStake(addr), nil)
+	assert.Equal(t, ctx.GetStateDb().GetBalance(addr), vnt2wei(10))
+}
+
+func TestUnStakeReducesVotes(t *testing.T) {
+	ec := newTestElectionCtx()
+	ca := newTestCandi()
+	if err := ec.setCandidate(*ca); err != nil {
+		t.Fatal(err)
+	}
+
+	// voter直接投票，proxied通过代理投票
+	voter := common.HexToAddress("41b0db166cfdf1c4ba3ce657171482a9aa55cc93")
+	proxy := common.HexToAddress("41b0db166cfdf1c4ba3ce657171482a9aa55cc94")
+	proxied := common.HexToAddress("41b0db166cfdf1c4ba3ce657171482a9aa55cc95")
+	for _, addr := range []common.Address{voter, proxy, proxied} {
+		newTestStaker(t, ec, addr, 100)
+	}
+	if err := ec.voteWitnesses(voter, []common.Address{ca.Owner}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ec.startProxy(proxy); err != nil {
+		t.Fatal(err)
+	}
+	if err := ec.voteWitnesses(proxy, []common.Address{ca.Owner}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ec.setProxy(proxied, proxy); err != nil {
+		t.Fatal(err)
+	}
+	votes := ec.calculateVoteCount(big.NewInt(100))
+	assert.Equal(t, ec.getCandidate(ca.Owner).VoteCount, new(big.Int).Mul(votes, big.NewInt(3)))
+
+	// 解除一半抵押，票数立即减半
+	half := new(big.Int).Div(votes, big.NewInt(2))
+	assert.Equal(t, ec.unStake(voter, vnt2wei(50)), nil)
+	assert.Equal(t, ec.getVoter(voter).LastVoteCount, half)
+	assert.Equal(t, ec.getCandidate(ca.Owner).VoteCount, new(big.Int).Add(half, new(big.Int).Mul(votes, big.NewInt(2))))
+
+	assert.Equal(t, ec.unStake(proxied, vnt2wei(50)), nil)
+	assert.Equal(t, ec.getVoter(proxy).ProxyVoteCount, half)
+	assert.Equal(t, ec.getCandidate(ca.Owner).VoteCount, new(big.Int).Add(votes, new(big.Int).Mul(half, big.NewInt(2))))
+
+	// 取消投票后减去的是剩余的票数
+	if err := ec.cancelVote(voter); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ec.getCandidate(ca.Owner).VoteCount, new(big.Int).Add(votes, half))
+}
+
+func TestQueryUnbonding(t *testing.T) {
+	ec := newTestElectionCtx()
+	addr := common.HexToAddress("41b0db166cfdf1c4ba3ce657171482a9aa55cc93")
+	newTestStaker(t, ec, addr, 100)
+	if err := ec.unStake(addr, vnt2wei(40)); err != nil {
+		t.Fatal(err)
+	}
+
+	electionABI, _ := GetElectionABI()
+	input, _ := PackInput(electionABI, "getUnbonding", addr)
+	ret, err := (&Election{}).Run(ec.context, input, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unbonding struct {
+		Vnts       []*big.Int
+		Maturities []*big.Int
+	}
+	if err := electionABI.Unpack(&unbonding, "getUnbonding", ret); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, unbonding.Vnts, []*big.Int{vnt2wei(40)})
+	assert.Equal(t, unbonding.Maturities, []*big.Int{new(big.Int).Add(ec.context.GetTime(), big.NewInt(OneDay))})
+}
+
+func TestLegacyUnStake(t *testing.T) {
+	ctx := newcontext().(*testContext)
+	ctx.Config = &params.ChainConfig{UnbondingBlock: big.NewInt(100)}
+	ctx.BlockNum = big.NewInt(99)
+	ec := newElectionContext(ctx)
+	db := ctx.GetStateDb()
+	addr := ctx.GetOrigin()
+	newTestStaker(t, ec, addr, 100)
+
+	ca := newTestCandi()
+	if err := ec.setCandidate(*ca); err != nil {
+		t.Fatal(err)
+	}
+	if err := ec.voteWitnesses(addr, []common.Address{ca.Owner}); err != nil {
+		t.Fatal(err)
+	}
+	votes := ec.getCandidate(ca.Owner).VoteCount
+
+	electionABI, _ := GetElectionABI()
+	run := func(method string, args ...interface{}) error {
+		input, err := PackInput(electionABI, method, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = (&Election{}).Run(ctx, input, big.NewInt(0))
+		return err
+	}
+
+	// 分叉之前不能部分解除抵押和提取
+	if err := run("unStakeAmount", vnt2wei(10)); err == nil {
+		t.Error("unStakeAmount should be rejected before the fork")
+	}
+	if err := run("claimUnStake"); err == nil {
+		t.Error("claimUnStake should be rejected before the fork")
+	}
+
+	// 抵押满24小时后全部返还，票数不变
+	if err := run("unStake"); err == nil || err.Error() != "cannot unstake in 24 hours" {
+		t.Errorf("unStake in 24 hours error mismatch: %v", err)
+	}
+	twentyFourHoursLater(t, ctx)
+	if err := run("unStake"); err != nil {
+		t.Fatalf("unStake error: %s", err)
+	}
+	stake := ec.getStake(addr)
+	checkStake(t, &stake, addr, common.Big0, common.Big0)
+	assert.Equal(t, db.GetBalance(addr), vnt2wei(100))
+	assert.Equal(t, ec.getCandidate(ca.Owner).VoteCount, votes)
+	if GetUnbonding(db, addr) != nil {
+		t.Errorf("unbonding should be empty before the fork")
+	}
+
+	// 分叉之后进入解锁队列
+	ctx.BlockNum = big.NewInt(100)
+	newTestStaker(t, ec, addr, 50)
+	if err := run("unStake"); err != nil {
+		t.Fatalf("unStake error: %s", err)
+	}
+	assert.Equal(t, db.GetBalance(addr), vnt2wei(100))
+	if unbonding := GetUnbonding(db, addr); unbonding == nil || len(unbonding.Entries) != 1 {
+		t.Errorf("unbonding entries mismatch: %v", unbonding)
+	}
+}
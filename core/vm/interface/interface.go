@@ -21,6 +21,7 @@ import (
 
 	"github.com/vntchain/go-vnt/common"
 	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/params"
 )
 
 // StateDB is an VM database for full state querying.
@@ -78,4 +79,8 @@ type ChainContext interface {
 	GetStateDb() StateDB
 	GetOrigin() common.Address
 	GetTime() *big.Int
+	GetBlockNum() *big.Int
+	GetChainConfig() *params.ChainConfig
+	// GetWitnesses returns the witnesses list of an ancestor block
+	GetWitnesses(num *big.Int) []common.Address
 }
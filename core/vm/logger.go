@@ -100,3 +100,26 @@ type Tracer interface {
 	CaptureFault(env VM, pc uint64, op OPCode, gas, cost uint64, contract inter.Contract, depth int, err error) error
 	CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error
 }
+
+// CallTracer is an optional interface of Tracer. It is notified when the VM
+// enters or exits a nested call or contract creation, which is not reported
+// by CaptureStart and CaptureEnd.
+type CallTracer interface {
+	CaptureEnter(typ string, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) error
+	CaptureExit(output []byte, gasUsed uint64, err error) error
+}
+
+// StateTracer is an optional interface of Tracer. It is notified before the
+// VM accesses an account or a storage slot of a contract.
+type StateTracer interface {
+	CaptureAccount(env VM, addr common.Address) error
+	CaptureStorage(env VM, addr common.Address, key common.Hash) error
+}
+
+// FunctionTracer is an optional interface of Tracer. It is notified when the
+// VM enters or exits a wasm function or a host function, with the gas left of
+// the current contract.
+type FunctionTracer interface {
+	CaptureFunctionEnter(name string, gas uint64) error
+	CaptureFunctionExit(gas uint64) error
+}
@@ -40,6 +40,8 @@ type (
 	TransferFunc    func(inter.StateDB, common.Address, common.Address, *big.Int)
 	// GetHashFunc returns the nth block hash in the blockchain
 	GetHashFunc func(uint64) common.Hash
+	// GetWitnessesFunc returns the witnesses list of the nth block in the blockchain
+	GetWitnessesFunc func(uint64) []common.Address
 )
 
 type Context struct {
@@ -50,6 +52,8 @@ type Context struct {
 	Transfer TransferFunc
 	// GetHash returns the hash corresponding to n
 	GetHash GetHashFunc
+	// GetWitnesses returns the witnesses list corresponding to n
+	GetWitnesses GetWitnessesFunc
 
 	// Message information
 	Origin   common.Address // Provides information for ORIGIN
@@ -0,0 +1,97 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/consensus"
+	"github.com/vntchain/go-vnt/core/types"
+)
+
+// witnessesChain is a chain of headers whose canonical headers are indexed by
+// number, and counts the headers retrieved by hash.
+type witnessesChain struct {
+	headers   map[common.Hash]*types.Header
+	canonical []*types.Header
+	byHash    int
+}
+
+func (c *witnessesChain) Engine() consensus.Engine { return nil }
+
+func (c *witnessesChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	c.byHash++
+	return c.headers[hash]
+}
+
+func (c *witnessesChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number >= uint64(len(c.canonical)) {
+		return nil
+	}
+	return c.canonical[number]
+}
+
+// extend adds n headers after parent, the witnesses of each header is the
+// address of its number plus offset.
+func (c *witnessesChain) extend(parent *types.Header, n int, offset byte) []*types.Header {
+	var headers []*types.Header
+	for i := 0; i < n; i++ {
+		number := new(big.Int).Add(parent.Number, big.NewInt(1))
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     number,
+			Witnesses:  []common.Address{common.BytesToAddress([]byte{byte(number.Uint64()) + offset})},
+		}
+		c.headers[header.Hash()] = header
+		headers = append(headers, header)
+		parent = header
+	}
+	return headers
+}
+
+func TestGetWitnessesFn(t *testing.T) {
+	genesis := &types.Header{Number: big.NewInt(0), Witnesses: []common.Address{{0xff}}}
+	chain := &witnessesChain{headers: map[common.Hash]*types.Header{genesis.Hash(): genesis}}
+	canonical := chain.extend(genesis, 10, 0)
+	chain.canonical = append([]*types.Header{genesis}, canonical...)
+	side := chain.extend(canonical[3], 6, 100)
+
+	// The block on the canonical chain looks up the ancestors by number
+	next := &types.Header{ParentHash: canonical[9].Hash(), Number: big.NewInt(11)}
+	getWitnesses := GetWitnessesFn(next, chain)
+	if have := getWitnesses(2); len(have) != 1 || have[0] != common.BytesToAddress([]byte{2}) {
+		t.Errorf("canonical witnesses mismatch: have %v", have)
+	}
+	if chain.byHash != 0 {
+		t.Errorf("canonical ancestor should not be found by walking, %d headers retrieved", chain.byHash)
+	}
+	if have := getWitnesses(11); have != nil {
+		t.Errorf("witnesses of the block itself should not be found: have %v", have)
+	}
+
+	// The block on the side chain walks the parents
+	next = &types.Header{ParentHash: side[5].Hash(), Number: big.NewInt(11)}
+	getWitnesses = GetWitnessesFn(next, chain)
+	if have := getWitnesses(6); len(have) != 1 || have[0] != common.BytesToAddress([]byte{106}) {
+		t.Errorf("side chain witnesses mismatch: have %v", have)
+	}
+	if have := getWitnesses(2); len(have) != 1 || have[0] != common.BytesToAddress([]byte{2}) {
+		t.Errorf("common ancestor witnesses mismatch: have %v", have)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package wavm
+
+import (
+	"github.com/hashicorp/golang-lru"
+	"github.com/vntchain/go-vnt/accounts/abi"
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/metrics"
+	"github.com/vntchain/vnt-wasm/vnt"
+	"github.com/vntchain/vnt-wasm/wasm"
+)
+
+// moduleCacheSize is the number of deployed contracts whose decoded modules
+// are kept in memory.
+const moduleCacheSize = 128
+
+var (
+	moduleCache, _ = lru.New(moduleCacheSize)
+
+	moduleCacheHitMeter  = metrics.NewRegisteredMeter("wavm/cache/hit", nil)
+	moduleCacheMissMeter = metrics.NewRegisteredMeter("wavm/cache/miss", nil)
+)
+
+// cachedModule is a deployed contract whose code is decompressed, abi is parsed,
+// module is instantiated and compiled code is decoded. It is shared by all the
+// calls of the contract, so it must be read only.
+type cachedModule struct {
+	code     []byte
+	abi      abi.ABI
+	module   *wasm.Module // template module, the host functions must be rebound before execution
+	mutable  Mutable
+	compiled []vnt.Compiled
+}
+
+// getCachedModule retrieves the decoded contract by the code hash.
+func getCachedModule(codeHash common.Hash) *cachedModule {
+	if codeHash == (common.Hash{}) || codeHash == emptyCodeHash {
+		return nil
+	}
+	if cached, ok := moduleCache.Get(codeHash); ok {
+		moduleCacheHitMeter.Mark(1)
+		return cached.(*cachedModule)
+	}
+	moduleCacheMissMeter.Mark(1)
+	return nil
+}
+
+// putCachedModule adds the decoded contract into the cache.
+func putCachedModule(codeHash common.Hash, cached *cachedModule) {
+	if codeHash == (common.Hash{}) || codeHash == emptyCodeHash {
+		return
+	}
+	moduleCache.Add(codeHash, cached)
+}
+
+// PurgeModuleCache removes all the decoded contracts from the cache.
+func PurgeModuleCache() {
+	moduleCache.Purge()
+}
+
+// instantiate returns a module for the execution in the given context. The
+// module shares everything with the template except the host functions,
+// which are bound to the context.
+func (c *cachedModule) instantiate(ctx *ChainContext) (*wasm.Module, error) {
+	m := *c.module
+	m.FunctionIndexSpace = make([]wasm.Function, len(c.module.FunctionIndexSpace))
+	copy(m.FunctionIndexSpace, c.module.FunctionIndexSpace)
+	if m.Import == nil {
+		return &m, nil
+	}
+
+	envModule := EnvModule{}
+	envModule.InitModule(ctx)
+	env := envModule.GetModule()
+
+	// The imported functions are placed at the beginning of the function
+	// index space, in the order of the import entries.
+	index := 0
+	for _, entry := range m.Import.Entries {
+		if entry.Type.Kind() != wasm.ExternalFunction {
+			continue
+		}
+		export, ok := env.Export.Entries[entry.FieldName]
+		if !ok {
+			return nil, wasm.ExportNotFoundError{ModuleName: entry.ModuleName, FieldName: entry.FieldName}
+		}
+		m.FunctionIndexSpace[index] = *env.GetFunction(int(export.Index))
+		index++
+	}
+	return &m, nil
+}
@@ -18,6 +18,7 @@ package wavm
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
 	"errors"
@@ -30,16 +31,20 @@ import (
 
 	"github.com/vntchain/go-vnt/accounts/abi"
 	"github.com/vntchain/go-vnt/common"
-	mat "github.com/vntchain/go-vnt/common/math"
+	"github.com/vntchain/go-vnt/common/hexutil"
 	"github.com/vntchain/go-vnt/core/types"
 	errormsg "github.com/vntchain/go-vnt/core/vm"
 	"github.com/vntchain/go-vnt/core/wavm/storage"
 	"github.com/vntchain/go-vnt/core/wavm/utils"
 	"github.com/vntchain/go-vnt/crypto"
+	"github.com/vntchain/go-vnt/crypto/bn256"
 	"github.com/vntchain/go-vnt/log"
 	"github.com/vntchain/go-vnt/params"
 	"github.com/vntchain/vnt-wasm/exec"
 	"github.com/vntchain/vnt-wasm/wasm"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/ripemd160"
 )
 
 var (
@@ -50,6 +55,10 @@ var (
 	errNoContractCall           = "contractCall execution failed: can not find call '%s' in abi"
 	errContractCallArgsMismatch = "contractCall execution failed: expected call args number %d in abi, get args number %d"
 	errContractCallResult       = "failed to get result in contract call"
+	errPairingInputLength       = "pairing check failed: input length %d is not a multiple of 192"
+	errPairingInputPoint        = "pairing check failed: %s"
+	errCreateContract           = "failed to create contract"
+	errInvalidSalt              = "invalid salt %q"
 )
 
 var endianess = binary.LittleEndian
@@ -68,25 +77,37 @@ func (ef *EnvFunctions) InitFuncTable(context *ChainContext) {
 	if ef.ctx == nil {
 		return
 	}
+	// the crypto functions can't be imported before the fork
+	if wavm := ef.ctx.Wavm; wavm != nil && wavm.ChainConfig() != nil && wavm.ChainConfig().IsCrypto(ef.ctx.BlockNumber) {
+		for name, function := range ef.getCryptoFuncTable() {
+			ef.funcTable[name] = function
+		}
+	}
+	// neither can the static call function
+	if wavm := ef.ctx.Wavm; wavm != nil && wavm.ChainConfig() != nil && wavm.ChainConfig().IsStaticCall(ef.ctx.BlockNumber) {
+		for name, function := range ef.getStaticCallFuncTable() {
+			ef.funcTable[name] = function
+		}
+	}
+	// nor the create contract function
+	if wavm := ef.ctx.Wavm; wavm != nil && wavm.ChainConfig() != nil && wavm.ChainConfig().IsCreateContract(ef.ctx.BlockNumber) {
+		for name, function := range ef.getCreateContractFuncTable() {
+			ef.funcTable[name] = function
+		}
+	}
+	// the events are restricted to the legacy parameter types before the fork
+	paramTypeOf := legacyEventParamType
+	if ef.isRichEvent() {
+		paramTypeOf = eventParamType
+	}
 	for _, event := range ef.ctx.Abi.Events {
 		paramTypes := make([]wasm.ValueType, len(event.Inputs))
 		for index, input := range event.Inputs {
-			switch input.Type.T {
-			case abi.IntTy, abi.UintTy:
-				if input.Type.Size == 64 {
-					paramTypes[index] = wasm.ValueTypeI64
-				} else if input.Type.Size == 32 || input.Type.Size == 256 {
-					paramTypes[index] = wasm.ValueTypeI32
-				} else {
-					err := fmt.Errorf(errUnsupportType, input.Type.String())
-					panic(err)
-				}
-			case abi.AddressTy, abi.StringTy, abi.BoolTy:
-				paramTypes[index] = wasm.ValueTypeI32
-			default:
-				err := fmt.Errorf(errUnsupportType, input.Type.String())
+			paramType, err := paramTypeOf(input.Type)
+			if err != nil {
 				panic(err)
 			}
+			paramTypes[index] = paramType
 		}
 		//ef.funcTable[event.Name] = reflect.ValueOf(ef.getEvent(len(event.Inputs), event.Name))
 		ef.funcTable[event.Name] = wasm.Function{
@@ -168,6 +189,7 @@ func (ef *EnvFunctions) GetBalanceFromAddress(proc *exec.WavmProcess, locIndex u
 	ef.ctx.GasCounter.GasGetBalanceFromAddress()
 	ctx := ef.ctx
 	addr := common.BytesToAddress(proc.ReadAt(locIndex))
+	ctx.Wavm.captureAccount(addr)
 	balance := ctx.StateDB.GetBalance(addr)
 	return ef.returnU256(proc, balance)
 }
@@ -286,6 +308,79 @@ func (ef *EnvFunctions) Ecrecover(proc *exec.WavmProcess, hashptr uint64, sigv u
 	return ef.returnAddress(proc, addr)
 }
 
+//SHA256 returns the hex string of the SHA-256 hash of the data
+func (ef *EnvFunctions) SHA256(proc *exec.WavmProcess, dataIdx uint64) uint64 {
+	data := proc.ReadAt(dataIdx)
+	ef.ctx.GasCounter.GasSHA256(uint64(len(data)))
+	hash := sha256.Sum256(data)
+	return uint64(proc.SetBytes([]byte(common.Hash(hash).Hex())))
+}
+
+//RIPEMD160 returns the hex string of the RIPEMD-160 hash of the data
+func (ef *EnvFunctions) RIPEMD160(proc *exec.WavmProcess, dataIdx uint64) uint64 {
+	data := proc.ReadAt(dataIdx)
+	ef.ctx.GasCounter.GasRIPEMD160(uint64(len(data)))
+	hasher := ripemd160.New()
+	hasher.Write(data)
+	return uint64(proc.SetBytes([]byte(hexutil.Encode(hasher.Sum(nil)))))
+}
+
+//Blake2b returns the hex string of the 256 bits BLAKE2b hash of the data
+func (ef *EnvFunctions) Blake2b(proc *exec.WavmProcess, dataIdx uint64) uint64 {
+	data := proc.ReadAt(dataIdx)
+	ef.ctx.GasCounter.GasBlake2b(uint64(len(data)))
+	hash := blake2b.Sum256(data)
+	return uint64(proc.SetBytes([]byte(common.Hash(hash).Hex())))
+}
+
+//Ed25519Verify returns 1 if sig is a valid signature of the message data by
+//pubkey, otherwise 0. The public key and signature are hex strings.
+func (ef *EnvFunctions) Ed25519Verify(proc *exec.WavmProcess, pubkeyIdx uint64, dataIdx uint64, sigIdx uint64) uint64 {
+	ef.ctx.GasCounter.GasEd25519Verify()
+	pubkey := common.FromHex(string(proc.ReadAt(pubkeyIdx)))
+	data := proc.ReadAt(dataIdx)
+	sig := common.FromHex(string(proc.ReadAt(sigIdx)))
+	if len(pubkey) != ed25519.PublicKeySize || len(sig) != ed25519.SignatureSize {
+		return 0
+	}
+	if ed25519.Verify(ed25519.PublicKey(pubkey), data, sig) {
+		return 1
+	}
+	return 0
+}
+
+//BN256PairingCheck returns 1 if the pairing of the points is one, otherwise 0.
+//The input is the hex string of the (G1, G2) points in 192 bytes pairs, encoded
+//the same way as the bn256 pairing precompiled contract.
+func (ef *EnvFunctions) BN256PairingCheck(proc *exec.WavmProcess, inputIdx uint64) uint64 {
+	input := common.FromHex(string(proc.ReadAt(inputIdx)))
+	if len(input)%192 > 0 {
+		panic(fmt.Sprintf(errPairingInputLength, len(input)))
+	}
+	ef.ctx.GasCounter.GasBN256PairingCheck(uint64(len(input) / 192))
+
+	var (
+		cs []*bn256.G1
+		ts []*bn256.G2
+	)
+	for i := 0; i < len(input); i += 192 {
+		c := new(bn256.G1)
+		if _, err := c.Unmarshal(input[i : i+64]); err != nil {
+			panic(fmt.Sprintf(errPairingInputPoint, err))
+		}
+		t := new(bn256.G2)
+		if _, err := t.Unmarshal(input[i+64 : i+192]); err != nil {
+			panic(fmt.Sprintf(errPairingInputPoint, err))
+		}
+		cs = append(cs, c)
+		ts = append(ts, t)
+	}
+	if bn256.PairingCheck(cs, ts) {
+		return 1
+	}
+	return 0
+}
+
 //GetContractAddress get contract address
 func (ef *EnvFunctions) GetContractAddress(proc *exec.WavmProcess) uint64 {
 	ctx := ef.ctx
@@ -336,6 +431,72 @@ func (ef *EnvFunctions) TransferFromContract(proc *exec.WavmProcess, addrIdx uin
 	return 0
 }
 
+//CreateContract creates a contract by the compressed code followed by the packed
+//constructor arguments, and transfers amount to it. If salt is empty, the address
+//is derived from the nonce of this contract, otherwise from the hex salt and the
+//code, so that it's known before the creation. It returns the new address.
+func (ef *EnvFunctions) CreateContract(proc *exec.WavmProcess, codeIdx uint64, amountIdx uint64, saltIdx uint64) uint64 {
+	ef.forbiddenMutable(proc)
+	code := proc.ReadAt(codeIdx)
+	amount := readU256FromMemory(proc, amountIdx)
+	salt := proc.ReadAt(saltIdx)
+	saltHash, err := parseSalt(string(salt))
+	if err != nil {
+		panic(fmt.Errorf("%s Reason : %s", errors.New(errCreateContract), err))
+	}
+	gas := ef.ctx.GasCounter.GasCreate(uint64(len(code)), len(salt) != 0)
+
+	var (
+		addr      common.Address
+		returnGas uint64
+	)
+	if len(salt) == 0 {
+		_, addr, returnGas, err = ef.ctx.Wavm.Create(ef.ctx.Contract, code, gas, amount)
+	} else {
+		_, addr, returnGas, err = ef.ctx.Wavm.Create2(ef.ctx.Contract, code, gas, amount, saltHash)
+	}
+	if err != nil {
+		panic(fmt.Errorf("%s Reason : %s", errors.New(errCreateContract), err))
+	}
+	ef.ctx.Contract.Gas += returnGas
+	return ef.returnAddress(proc, addr.Bytes())
+}
+
+// parseSalt decodes the hex salt of CreateContract, with or without the 0x
+// prefix. Unlike common.HexToHash, it rejects the non-hex characters and the
+// salt longer than a hash, instead of creating with an unexpected salt.
+func parseSalt(salt string) (common.Hash, error) {
+	hexSalt := salt
+	if len(hexSalt) >= 2 && hexSalt[0] == '0' && (hexSalt[1] == 'x' || hexSalt[1] == 'X') {
+		hexSalt = hexSalt[2:]
+	}
+	if len(hexSalt)%2 == 1 {
+		hexSalt = "0" + hexSalt
+	}
+	b, err := hex.DecodeString(hexSalt)
+	if err != nil || len(b) > common.HashLength {
+		return common.Hash{}, fmt.Errorf(errInvalidSalt, salt)
+	}
+	return common.BytesToHash(b), nil
+}
+
+//StaticCall calls the contract at address with the packed input and at most gasLimit
+//gas, while disallowing any modifications to the state during the call. The called
+//contract can only execute unmutable functions. It returns the packed output.
+func (ef *EnvFunctions) StaticCall(proc *exec.WavmProcess, addrIdx uint64, inputIdx uint64, gasLimit uint64) uint64 {
+	toAddr := common.BytesToAddress(proc.ReadAt(addrIdx))
+	input := proc.ReadAt(inputIdx)
+	gas := ef.ctx.GasCounter.GasCall(toAddr, new(big.Int), new(big.Int).SetUint64(gasLimit), ef.ctx.BlockNumber, ef.ctx.Wavm.GetChainConfig(), ef.ctx.StateDB)
+	ef.ctx.Wavm.SetCallGasTemp(gas)
+
+	ret, returnGas, err := ef.ctx.Wavm.StaticCall(ef.ctx.Contract, toAddr, input, gas)
+	if err != nil {
+		panic(fmt.Errorf("%s Reason : %s", errors.New(errContractCallResult), err))
+	}
+	ef.ctx.Contract.Gas += returnGas
+	return uint64(proc.SetBytes(ret))
+}
+
 func (ef *EnvFunctions) fromI64(proc *exec.WavmProcess, value uint64) uint64 {
 	ef.ctx.GasCounter.GasFromI64()
 	amount := int(value)
@@ -391,6 +552,13 @@ func (ef *EnvFunctions) Equal(proc *exec.WavmProcess, str1Idx uint64, str2Idx ui
 	}
 }
 
+// isRichEvent returns whether the events are encoded by the abi, which is
+// since the rich event fork.
+func (ef *EnvFunctions) isRichEvent() bool {
+	wavm := ef.ctx.Wavm
+	return wavm != nil && wavm.ChainConfig() != nil && wavm.ChainConfig().IsRichEvent(ef.ctx.BlockNumber)
+}
+
 func (ef *EnvFunctions) getEvent(funcName string) interface{} {
 	fnDef := func(proc *exec.WavmProcess, vars ...uint64) {
 		ef.forbiddenMutable(proc)
@@ -409,73 +577,14 @@ func (ef *EnvFunctions) getEvent(funcName string) interface{} {
 			panic(fmt.Sprintf(errEventArgsMismatch, abiParamLen, paramLen))
 		}
 
-		topics := make([]common.Hash, 0)
-		data := make([]byte, 0)
-
-		topics = append(topics, event.Id())
-
-		strStartIndex := make([]int, 0)
-		strData := make([][]byte, 0)
-
-		for i := 0; i < paramLen; i++ {
-			input := event.Inputs[i]
-			indexed := input.Indexed
-			paramType := input.Type.T
-			param := vars[i]
-			var value []byte
-			switch paramType {
-			case abi.AddressTy, abi.StringTy:
-				value = proc.ReadAt(param)
-			case abi.UintTy, abi.IntTy:
-				if input.Type.Kind == reflect.Ptr {
-					mem := proc.ReadAt(param)
-					bigint := utils.GetU256(mem)
-					value = abi.U256(bigint)
-				} else if paramType == abi.UintTy {
-					value = abi.U256(new(big.Int).SetUint64(param))
-				} else {
-					if input.Type.Size == 32 {
-						value = abi.U256(big.NewInt(int64(int32(param))))
-					} else {
-						value = abi.U256(big.NewInt(int64(param)))
-					}
-				}
-			case abi.BoolTy:
-				if param == 1 {
-					value = mat.PaddedBigBytes(common.Big1, 32)
-				}
-				value = mat.PaddedBigBytes(common.Big0, 32)
-			}
-
-			if indexed {
-				if paramType == abi.StringTy {
-					value = crypto.Keccak256(value)
-				}
-				topic := common.BytesToHash(value)
-				topics = append(topics, topic)
-			} else {
-				if paramType == abi.StringTy {
-					strStartIndex = append(strStartIndex, len(data))
-					data = append(data, make([]byte, 32)...)
-					strData = append(strData, value)
-				} else {
-					data = append(data, common.LeftPadBytes(value, 32)...)
-				}
-			}
-		}
-
-		// append the string data at the end of the data, and
-		// update the start position of string data
-		if len(strStartIndex) > 0 {
-			for i := range strStartIndex {
-				value := strData[i]
-				startPos := abi.U256(new(big.Int).SetUint64(uint64(len(data))))
-				copy(data[strStartIndex[i]:], startPos)
-
-				size := abi.U256(new(big.Int).SetUint64(uint64(len(value))))
-				data = append(data, size...)
-				data = append(data, common.RightPadBytes(value, (len(value)+31)/32*32)...)
-			}
+		var (
+			topics []common.Hash
+			data   []byte
+		)
+		if ef.isRichEvent() {
+			topics, data = packEvent(proc, event, vars)
+		} else {
+			topics, data = packLegacyEvent(proc, event, vars)
 		}
 
 		log.Debug("Will add event log: ", "topics", topics, "data", data, "len", len(data))
@@ -998,6 +1107,7 @@ func (ef *EnvFunctions) WriteWithPointer(proc *exec.WavmProcess, offsetAddr, bas
 		valMem := getMemory(proc, val.StorageValue.ValueAddress, val.StorageValue.ValueType, false, 0)
 		statedb := ef.ctx.StateDB
 		contractAddr := ef.ctx.Contract.Address()
+		ef.captureStorage(keyHash)
 		if val.StorageValue.ValueType == abi.TY_STRING {
 			beforeN := statedb.GetState(contractAddr, keyHash).Big().Int64()
 			n, s := utils.Split(valMem)
@@ -1005,11 +1115,13 @@ func (ef *EnvFunctions) WriteWithPointer(proc *exec.WavmProcess, offsetAddr, bas
 			statedb.SetState(contractAddr, keyHash, common.BigToHash(new(big.Int).SetInt64(int64(n))))
 			for i := 1; i <= n; i++ {
 				loc0 := new(big.Int).Add(keyHash.Big(), new(big.Int).SetInt64(int64(i)))
+				ef.captureStorage(common.BigToHash(loc0))
 				ef.ctx.GasCounter.GasStore(statedb, contractAddr, common.BigToHash(loc0), common.BytesToHash(s[i-1]))
 				statedb.SetState(contractAddr, common.BigToHash(loc0), common.BytesToHash(s[i-1]))
 			}
 			for i := n + 1; i <= int(beforeN); i++ {
 				loc0 := new(big.Int).Add(keyHash.Big(), new(big.Int).SetInt64(int64(i)))
+				ef.captureStorage(common.BigToHash(loc0))
 				empty := common.Hash{}
 				ef.ctx.GasCounter.GasStore(statedb, contractAddr, common.BigToHash(loc0), empty)
 				statedb.SetState(contractAddr, common.BigToHash(loc0), empty)
@@ -1032,11 +1144,13 @@ func (ef *EnvFunctions) ReadWithPointer(proc *exec.WavmProcess, offsetAddr, base
 		stateVal := []byte{}
 		statedb := ef.ctx.StateDB
 		contractAddr := ef.ctx.Contract.Address()
+		ef.captureStorage(keyHash)
 		if val.StorageValue.ValueType == abi.TY_STRING {
 			n := statedb.GetState(contractAddr, keyHash).Big().Int64()
 			ef.ctx.GasCounter.GasLoad()
 			for i := 1; i <= int(n); i++ {
 				loc0 := new(big.Int).Add(keyHash.Big(), new(big.Int).SetInt64(int64(i)))
+				ef.captureStorage(common.BigToHash(loc0))
 				val0 := statedb.GetState(contractAddr, common.BigToHash(loc0)).Big().Bytes()
 				stateVal = append(stateVal, val0...)
 				ef.ctx.GasCounter.GasLoad()
@@ -1286,10 +1400,12 @@ func (ef *EnvFunctions) Load(proc *exec.WavmProcess, keyptr uint64, dataptr uint
 	keyHash := common.BytesToHash(keyData)
 	statedb := ef.ctx.StateDB
 	contractAddr := ef.ctx.Contract.Address()
+	ef.captureStorage(keyHash)
 	n := statedb.GetState(contractAddr, keyHash).Big().Int64()
 	stateVal := []byte{}
 	for i := 1; i <= int(n); i++ {
 		loc0 := new(big.Int).Add(keyHash.Big(), new(big.Int).SetInt64(int64(i)))
+		ef.captureStorage(common.BigToHash(loc0))
 		val0 := statedb.GetState(contractAddr, common.BigToHash(loc0)).Big().Bytes()
 		stateVal = append(stateVal, val0...)
 		ef.ctx.GasCounter.GasLoad()
@@ -1303,19 +1419,23 @@ func (ef *EnvFunctions) Store(proc *exec.WavmProcess, keyptr uint64, dataptr uin
 	keyData := ef.getQString(proc, keyptr)
 	keyHash := common.BytesToHash(keyData)
 	valueData := ef.getQString(proc, dataptr)
+	ef.forbiddenReadOnly()
 	statedb := ef.ctx.StateDB
 	contractAddr := ef.ctx.Contract.Address()
+	ef.captureStorage(keyHash)
 	beforeN := statedb.GetState(contractAddr, keyHash).Big().Int64()
 	n, s := utils.Split(valueData)
 	ef.ctx.GasCounter.GasStore(statedb, contractAddr, keyHash, common.BigToHash(new(big.Int).SetInt64(int64(n))))
 	statedb.SetState(contractAddr, keyHash, common.BigToHash(new(big.Int).SetInt64(int64(n))))
 	for i := 1; i <= n; i++ {
 		loc0 := new(big.Int).Add(keyHash.Big(), new(big.Int).SetInt64(int64(i)))
+		ef.captureStorage(common.BigToHash(loc0))
 		ef.ctx.GasCounter.GasStore(statedb, contractAddr, common.BigToHash(loc0), common.BytesToHash(s[i-1]))
 		statedb.SetState(contractAddr, common.BigToHash(loc0), common.BytesToHash(s[i-1]))
 	}
 	for i := n + 1; i <= int(beforeN); i++ {
 		loc0 := new(big.Int).Add(keyHash.Big(), new(big.Int).SetInt64(int64(i)))
+		ef.captureStorage(common.BigToHash(loc0))
 		empty := common.Hash{}
 		ef.ctx.GasCounter.GasStore(statedb, contractAddr, common.BigToHash(loc0), empty)
 		statedb.SetState(contractAddr, common.BigToHash(loc0), empty)
@@ -1334,4 +1454,17 @@ func (ef *EnvFunctions) forbiddenMutable(proc *exec.WavmProcess) {
 		err := errors.New("Mutable Forbidden: This function is not a mutable function")
 		panic(err)
 	}
+	ef.forbiddenReadOnly()
+}
+
+// captureStorage notifies the state tracer that a storage slot of the
+// contract is accessed.
+func (ef *EnvFunctions) captureStorage(key common.Hash) {
+	ef.ctx.Wavm.captureStorage(ef.ctx.Contract.Address(), key)
+}
+
+func (ef *EnvFunctions) forbiddenReadOnly() {
+	if ef.ctx.Wavm.ReadOnly() {
+		panic(errormsg.ErrWriteProtection)
+	}
 }
@@ -0,0 +1,293 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package wavm
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/vntchain/go-vnt/accounts/abi"
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/common/math"
+	"github.com/vntchain/go-vnt/core/wavm/utils"
+	"github.com/vntchain/go-vnt/crypto"
+	"github.com/vntchain/vnt-wasm/exec"
+	"github.com/vntchain/vnt-wasm/wasm"
+)
+
+// eventParamType returns the wasm type of an event parameter. The 64 bits
+// integers are passed by value, all the others are passed by value in i32 or
+// by the pointer to the memory:
+//
+//   - uint256, int256: pointer to the decimal string
+//   - string, bytes, address: pointer to the content
+//   - bytesN: pointer to at most N bytes, right padded with zeros
+//   - T[N]: pointer to N elements in C layout
+//   - T[]: pointer to the struct { uint32 length; T *data; }
+//
+// The elements of arrays are laid out like the parameters, except that bool
+// takes one byte. Arrays of arrays are not supported.
+func eventParamType(t abi.Type) (wasm.ValueType, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		switch t.Size {
+		case 64:
+			return wasm.ValueTypeI64, nil
+		case 32, 256:
+			return wasm.ValueTypeI32, nil
+		}
+	case abi.AddressTy, abi.StringTy, abi.BoolTy, abi.BytesTy, abi.FixedBytesTy:
+		return wasm.ValueTypeI32, nil
+	case abi.SliceTy, abi.ArrayTy:
+		if t.Elem.T != abi.SliceTy && t.Elem.T != abi.ArrayTy {
+			if _, err := eventParamType(*t.Elem); err == nil {
+				return wasm.ValueTypeI32, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf(errUnsupportType, t.String())
+}
+
+// legacyEventParamType returns the wasm type of an event parameter before the
+// rich event fork, which supports only the 32, 64 and 256 bits integers,
+// address, string and bool.
+func legacyEventParamType(t abi.Type) (wasm.ValueType, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		switch t.Size {
+		case 64:
+			return wasm.ValueTypeI64, nil
+		case 32, 256:
+			return wasm.ValueTypeI32, nil
+		}
+	case abi.AddressTy, abi.StringTy, abi.BoolTy:
+		return wasm.ValueTypeI32, nil
+	}
+	return 0, fmt.Errorf(errUnsupportType, t.String())
+}
+
+// eventElemSize returns the size of an array element in the memory.
+func eventElemSize(t abi.Type) int {
+	switch {
+	case t.T == abi.BoolTy:
+		return 1
+	case (t.T == abi.IntTy || t.T == abi.UintTy) && t.Size == 64:
+		return 8
+	default:
+		return 4
+	}
+}
+
+// packEvent returns the topics and the abi packed data of the event.
+func packEvent(proc *exec.WavmProcess, event abi.Event, vars []uint64) ([]common.Hash, []byte) {
+	topics := []common.Hash{event.Id()}
+	values := make([]interface{}, 0)
+	for i, input := range event.Inputs {
+		value := readEventValue(proc, input.Type, vars[i])
+		if input.Indexed {
+			topics = append(topics, eventTopic(input.Type, value))
+		} else {
+			values = append(values, value)
+		}
+	}
+	data, err := event.Inputs.NonIndexed().Pack(values...)
+	if err != nil {
+		panic(err)
+	}
+	return topics, data
+}
+
+// packLegacyEvent returns the topics and the data of the event in the encoding
+// before the rich event fork, which must be kept for the consensus. Notably the
+// bool is always encoded as 0.
+func packLegacyEvent(proc *exec.WavmProcess, event abi.Event, vars []uint64) ([]common.Hash, []byte) {
+	topics := []common.Hash{event.Id()}
+	data := make([]byte, 0)
+
+	strStartIndex := make([]int, 0)
+	strData := make([][]byte, 0)
+
+	for i, input := range event.Inputs {
+		param := vars[i]
+		var value []byte
+		switch input.Type.T {
+		case abi.AddressTy, abi.StringTy:
+			value = proc.ReadAt(param)
+		case abi.UintTy, abi.IntTy:
+			if input.Type.Kind == reflect.Ptr {
+				value = abi.U256(utils.GetU256(proc.ReadAt(param)))
+			} else if input.Type.T == abi.UintTy {
+				value = abi.U256(new(big.Int).SetUint64(param))
+			} else if input.Type.Size == 32 {
+				value = abi.U256(big.NewInt(int64(int32(param))))
+			} else {
+				value = abi.U256(big.NewInt(int64(param)))
+			}
+		case abi.BoolTy:
+			value = math.PaddedBigBytes(common.Big0, 32)
+		}
+
+		if input.Indexed {
+			if input.Type.T == abi.StringTy {
+				value = crypto.Keccak256(value)
+			}
+			topics = append(topics, common.BytesToHash(value))
+		} else if input.Type.T == abi.StringTy {
+			strStartIndex = append(strStartIndex, len(data))
+			data = append(data, make([]byte, 32)...)
+			strData = append(strData, value)
+		} else {
+			data = append(data, common.LeftPadBytes(value, 32)...)
+		}
+	}
+
+	// append the string data at the end of the data, and
+	// update the start position of string data
+	for i := range strStartIndex {
+		value := strData[i]
+		startPos := abi.U256(new(big.Int).SetUint64(uint64(len(data))))
+		copy(data[strStartIndex[i]:], startPos)
+
+		size := abi.U256(new(big.Int).SetUint64(uint64(len(value))))
+		data = append(data, size...)
+		data = append(data, common.RightPadBytes(value, (len(value)+31)/32*32)...)
+	}
+	return topics, data
+}
+
+// readEventValue reads the event parameter from the wasm value, and returns it
+// in the go type of abi packing.
+func readEventValue(proc *exec.WavmProcess, t abi.Type, param uint64) interface{} {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		switch {
+		case t.Size == 256:
+			bigint := utils.GetU256(proc.ReadAt(param))
+			if t.T == abi.IntTy {
+				bigint = math.S256(bigint)
+			}
+			return bigint
+		case t.T == abi.UintTy && t.Size == 64:
+			return param
+		case t.T == abi.UintTy:
+			return uint32(param)
+		case t.Size == 64:
+			return int64(param)
+		default:
+			return int32(param)
+		}
+	case abi.BoolTy:
+		return param != 0
+	case abi.AddressTy:
+		return common.BytesToAddress(proc.ReadAt(param))
+	case abi.StringTy:
+		return string(proc.ReadAt(param))
+	case abi.BytesTy:
+		return common.CopyBytes(proc.ReadAt(param))
+	case abi.FixedBytesTy:
+		b := proc.ReadAt(param)
+		if len(b) > t.Size {
+			panic(fmt.Sprintf("%s: %d bytes for %s", errExceededArray, len(b), t.String()))
+		}
+		value := reflect.New(t.Type).Elem()
+		reflect.Copy(value, reflect.ValueOf(b))
+		return value.Interface()
+	case abi.SliceTy, abi.ArrayTy:
+		return readEventArray(proc, t, param)
+	}
+	panic(fmt.Errorf(errUnsupportType, t.String()))
+}
+
+// readEventArray reads the array elements from the memory.
+func readEventArray(proc *exec.WavmProcess, t abi.Type, param uint64) interface{} {
+	mem := proc.GetData()
+	var value reflect.Value
+	if t.T == abi.SliceTy {
+		if param+8 > uint64(len(mem)) {
+			panic(errExceededArray)
+		}
+		length := int(endianess.Uint32(mem[param:]))
+		if uint64(length) > uint64(len(mem)) {
+			panic(errExceededArray)
+		}
+		value = reflect.MakeSlice(t.Type, length, length)
+		param = uint64(endianess.Uint32(mem[param+4:]))
+	} else {
+		value = reflect.New(t.Type).Elem()
+	}
+	size := eventElemSize(*t.Elem)
+	if param+uint64(value.Len()*size) > uint64(len(mem)) {
+		panic(errExceededArray)
+	}
+	for i := 0; i < value.Len(); i++ {
+		elem := mem[param+uint64(i*size):]
+		var word uint64
+		switch size {
+		case 1:
+			word = uint64(elem[0])
+		case 4:
+			word = uint64(endianess.Uint32(elem))
+		case 8:
+			word = endianess.Uint64(elem)
+		}
+		value.Index(i).Set(reflect.ValueOf(readEventValue(proc, *t.Elem, word)))
+	}
+	return value.Interface()
+}
+
+// eventTopic returns the topic of an indexed event parameter. The value types
+// are packed into the topic, while the dynamic types and arrays are hashed by
+// the in place encoding, in the same way as the ethereum abi.
+func eventTopic(t abi.Type, value interface{}) common.Hash {
+	switch t.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy:
+		return crypto.Keccak256Hash(encodeInPlace(t, reflect.ValueOf(value), false))
+	}
+	return common.BytesToHash(packEventValue(t, value))
+}
+
+// encodeInPlace encodes the value without the length prefix and offsets. The
+// string and bytes are padded to 32 bytes only in arrays.
+func encodeInPlace(t abi.Type, value reflect.Value, padded bool) []byte {
+	switch t.T {
+	case abi.StringTy, abi.BytesTy:
+		b := []byte(value.String())
+		if t.T == abi.BytesTy {
+			b = value.Bytes()
+		}
+		if padded {
+			return common.RightPadBytes(b, (len(b)+31)/32*32)
+		}
+		return b
+	case abi.SliceTy, abi.ArrayTy:
+		var enc []byte
+		for i := 0; i < value.Len(); i++ {
+			enc = append(enc, encodeInPlace(*t.Elem, value.Index(i), true)...)
+		}
+		return enc
+	}
+	return packEventValue(t, value.Interface())
+}
+
+func packEventValue(t abi.Type, value interface{}) []byte {
+	packed, err := abi.Arguments{{Type: t}}.Pack(value)
+	if err != nil {
+		panic(err)
+	}
+	return packed
+}
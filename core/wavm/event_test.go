@@ -0,0 +1,263 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package wavm
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vntchain/go-vnt/accounts/abi"
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/common/math"
+	"github.com/vntchain/go-vnt/crypto"
+	"github.com/vntchain/go-vnt/params"
+	"github.com/vntchain/vnt-wasm/exec"
+	"github.com/vntchain/vnt-wasm/wasm"
+)
+
+const richEventAbi = `[
+	{"name":"Rich","anonymous":false,"type":"event","inputs":[
+		{"name":"flag","type":"bool","indexed":true},
+		{"name":"delta","type":"int256","indexed":true},
+		{"name":"tag","type":"bytes4","indexed":true},
+		{"name":"memo","type":"bytes","indexed":false},
+		{"name":"small","type":"int32","indexed":false},
+		{"name":"ids","type":"uint64[]","indexed":false},
+		{"name":"owners","type":"address[2]","indexed":false}
+	]},
+	{"name":"Hashed","anonymous":false,"type":"event","inputs":[
+		{"name":"memo","type":"bytes","indexed":true},
+		{"name":"names","type":"string[]","indexed":true},
+		{"name":"ids","type":"uint32[3]","indexed":true}
+	]}
+]`
+
+func TestEventParamType(t *testing.T) {
+	for _, test := range []struct {
+		typ  string
+		want wasm.ValueType
+		ok   bool
+	}{
+		{"int64", wasm.ValueTypeI64, true},
+		{"uint32", wasm.ValueTypeI32, true},
+		{"int256", wasm.ValueTypeI32, true},
+		{"bytes", wasm.ValueTypeI32, true},
+		{"bytes32", wasm.ValueTypeI32, true},
+		{"address[]", wasm.ValueTypeI32, true},
+		{"uint64[4]", wasm.ValueTypeI32, true},
+		{"int8", 0, false},
+		{"uint8[]", 0, false},
+		{"uint64[][]", 0, false},
+	} {
+		typ, err := abi.NewType(test.typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		have, err := eventParamType(typ)
+		if (err == nil) != test.ok || have != test.want {
+			t.Errorf("%s: have %v, %v, want %v, %v", test.typ, have, err, test.want, test.ok)
+		}
+	}
+}
+
+func TestLegacyEventParamType(t *testing.T) {
+	for _, test := range []struct {
+		typ string
+		ok  bool
+	}{
+		{"int64", true},
+		{"uint256", true},
+		{"bool", true},
+		{"string", true},
+		{"bytes", false},
+		{"bytes32", false},
+		{"address[]", false},
+	} {
+		typ, err := abi.NewType(test.typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := legacyEventParamType(typ); (err == nil) != test.ok {
+			t.Errorf("%s: have %v, want supported %v", test.typ, err, test.ok)
+		}
+	}
+}
+
+// setData writes the raw data into the memory and returns the pointer.
+func setData(proc *exec.WavmProcess, data []byte) uint64 {
+	offset := proc.SetBytes(data)
+	copy(proc.GetData()[offset:], data)
+	return uint64(offset)
+}
+
+func TestRichEvent(t *testing.T) {
+	vm, ef := getVM(eventCodePath, eventAbiPath)
+	parsed, err := abi.JSON(strings.NewReader(richEventAbi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ef.ctx.Abi = parsed
+	ef.ctx.Wavm.chainConfig = &params.ChainConfig{RichEventBlock: big.NewInt(0)}
+	mutable := true
+	proc := exec.NewWavmProcess(vm.VM, vm.Memory, &mutable)
+
+	owner1 := common.HexToAddress("0x0523029b179009a28a7fae478cd0c2e5ba2adc38")
+	owner2 := common.HexToAddress("0xd2be7e0d40c1a73ec1709f00b11cb5e24c784077")
+	ptr1 := uint64(proc.SetBytes(owner1.Bytes()))
+	ptr2 := uint64(proc.SetBytes(owner2.Bytes()))
+	owners := make([]byte, 8)
+	endianess.PutUint32(owners, uint32(ptr1))
+	endianess.PutUint32(owners[4:], uint32(ptr2))
+
+	idsData := make([]byte, 16)
+	endianess.PutUint64(idsData, 7)
+	endianess.PutUint64(idsData[8:], 1<<40)
+	ids := make([]byte, 8)
+	endianess.PutUint32(ids, 2)
+	endianess.PutUint32(ids[4:], uint32(setData(proc, idsData)))
+
+	ef.getEvent("Rich").(func(*exec.WavmProcess, ...uint64))(proc,
+		1,
+		uint64(proc.SetBytes([]byte("-5"))),
+		uint64(proc.SetBytes([]byte("vnt"))),
+		uint64(proc.SetBytes([]byte{0x00, 0xff})),
+		uint64(uint32(0xfffffffe)),
+		setData(proc, ids),
+		setData(proc, owners),
+	)
+
+	logs := ef.ctx.StateDB.Logs()
+	if len(logs) != 1 {
+		t.Fatalf("log count mismatch: have %d, want 1", len(logs))
+	}
+	event := parsed.Events["Rich"]
+	wantTopics := []common.Hash{
+		event.Id(),
+		common.BigToHash(big.NewInt(1)),
+		common.BigToHash(math.U256(big.NewInt(-5))),
+		common.BytesToHash(common.RightPadBytes([]byte("vnt"), 32)),
+	}
+	if !reflect.DeepEqual(logs[0].Topics, wantTopics) {
+		t.Errorf("topics mismatch: have %x, want %x", logs[0].Topics, wantTopics)
+	}
+	var data struct {
+		Memo   []byte
+		Small  int32
+		Ids    []uint64
+		Owners [2]common.Address
+	}
+	if err := parsed.Unpack(&data, "Rich", logs[0].Data); err != nil {
+		t.Fatalf("failed to unpack log: %v", err)
+	}
+	if !reflect.DeepEqual(data.Memo, []byte{0x00, 0xff}) || data.Small != -2 ||
+		!reflect.DeepEqual(data.Ids, []uint64{7, 1 << 40}) || data.Owners != [2]common.Address{owner1, owner2} {
+		t.Errorf("data mismatch: have %+v", data)
+	}
+
+	// Dynamic types and arrays are hashed by the in place encoding
+	names := make([]byte, 8)
+	endianess.PutUint32(names, 1)
+	endianess.PutUint32(names[4:], uint32(setData(proc, make([]byte, 4))))
+	endianess.PutUint32(proc.GetData()[endianess.Uint32(names[4:]):], uint32(proc.SetBytes([]byte("bitcoin"))))
+	fixed := make([]byte, 12)
+	for i := 0; i < 3; i++ {
+		endianess.PutUint32(fixed[i*4:], uint32(i+1))
+	}
+	ef.getEvent("Hashed").(func(*exec.WavmProcess, ...uint64))(proc,
+		uint64(proc.SetBytes([]byte("memo"))),
+		setData(proc, names),
+		setData(proc, fixed),
+	)
+	logs = ef.ctx.StateDB.Logs()
+	if len(logs) != 2 {
+		t.Fatalf("log count mismatch: have %d, want 2", len(logs))
+	}
+	wantTopics = []common.Hash{
+		parsed.Events["Hashed"].Id(),
+		crypto.Keccak256Hash([]byte("memo")),
+		crypto.Keccak256Hash(common.RightPadBytes([]byte("bitcoin"), 32)),
+		crypto.Keccak256Hash(common.LeftPadBytes([]byte{1}, 32), common.LeftPadBytes([]byte{2}, 32), common.LeftPadBytes([]byte{3}, 32)),
+	}
+	if !reflect.DeepEqual(logs[1].Topics, wantTopics) {
+		t.Errorf("topics mismatch: have %x, want %x", logs[1].Topics, wantTopics)
+	}
+	if len(logs[1].Data) != 0 {
+		t.Errorf("data mismatch: have %x, want empty", logs[1].Data)
+	}
+
+	// Arrays out of the memory are rejected
+	defer handlePanic(t, errExceededArray)
+	ids = make([]byte, 8)
+	endianess.PutUint32(ids, 1<<30)
+	ef.getEvent("Rich").(func(*exec.WavmProcess, ...uint64))(proc,
+		0, uint64(proc.SetBytes([]byte("0"))), uint64(proc.SetBytes([]byte("x"))), uint64(proc.SetBytes([]byte("x"))), 0,
+		setData(proc, ids), setData(proc, owners),
+	)
+}
+
+func TestLegacyEvent(t *testing.T) {
+	vm, ef := getVM(eventCodePath, eventAbiPath)
+	parsed, err := abi.JSON(strings.NewReader(`[
+		{"name":"Legacy","anonymous":false,"type":"event","inputs":[
+			{"name":"flag","type":"bool","indexed":true},
+			{"name":"name","type":"string","indexed":true},
+			{"name":"ok","type":"bool","indexed":false},
+			{"name":"memo","type":"string","indexed":false},
+			{"name":"small","type":"int32","indexed":false}
+		]}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ef.ctx.Abi = parsed
+	mutable := true
+	proc := exec.NewWavmProcess(vm.VM, vm.Memory, &mutable)
+
+	// The bool is encoded as 0 before the rich event fork
+	ef.getEvent("Legacy").(func(*exec.WavmProcess, ...uint64))(proc,
+		1,
+		uint64(proc.SetBytes([]byte("bitcoin"))),
+		1,
+		uint64(proc.SetBytes([]byte("memo"))),
+		uint64(uint32(0xfffffffe)),
+	)
+	logs := ef.ctx.StateDB.Logs()
+	if len(logs) != 1 {
+		t.Fatalf("log count mismatch: have %d, want 1", len(logs))
+	}
+	wantTopics := []common.Hash{
+		parsed.Events["Legacy"].Id(),
+		{},
+		crypto.Keccak256Hash([]byte("bitcoin")),
+	}
+	if !reflect.DeepEqual(logs[0].Topics, wantTopics) {
+		t.Errorf("topics mismatch: have %x, want %x", logs[0].Topics, wantTopics)
+	}
+	var data struct {
+		Ok    bool
+		Memo  string
+		Small int32
+	}
+	if err := parsed.Unpack(&data, "Legacy", logs[0].Data); err != nil {
+		t.Fatalf("failed to unpack log: %v", err)
+	}
+	if data.Ok || data.Memo != "memo" || data.Small != -2 {
+		t.Errorf("data mismatch: have %+v", data)
+	}
+}
@@ -35,6 +35,11 @@ const (
 	OpNameGetValue              = "GetValue"
 	OpNameSHA3                  = "SHA3"
 	OpNameEcrecover             = "Ecrecover"
+	OpNameSHA256                = "SHA256"
+	OpNameRIPEMD160             = "RIPEMD160"
+	OpNameBlake2b               = "Blake2b"
+	OpNameEd25519Verify         = "Ed25519Verify"
+	OpNameBN256PairingCheck     = "BN256PairingCheck"
 	OpNameGetContractAddress    = "GetContractAddress"
 	OpNameAssert                = "Assert"
 
@@ -59,7 +64,9 @@ const (
 	OpNameSendFromContract     = "SendFromContract"
 	OpNameTransferFromContract = "TransferFromContract"
 
-	OpNameContractCall = "ContractCall"
+	OpNameContractCall   = "ContractCall"
+	OpNameCreateContract = "CreateContract"
+	OpNameStaticCall     = "StaticCall"
 
 	//将字符串转化为地址
 	OpNameAddressFrom     = "AddressFrom"
@@ -716,3 +723,91 @@ func (ef *EnvFunctions) getFuncTable() map[string]wasm.Function {
 //	}
 //	return resolveHostFunc
 //}
+
+// getCryptoFuncTable returns the cryptographic functions since the crypto fork.
+func (ef *EnvFunctions) getCryptoFuncTable() map[string]wasm.Function {
+	return map[string]wasm.Function{
+		OpNameSHA256: {
+			Host: reflect.ValueOf(ef.SHA256),
+			Sig: &wasm.FunctionSig{
+				ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32},
+				ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+			},
+			Body: &wasm.FunctionBody{
+				Code: []byte{},
+			},
+		},
+		OpNameRIPEMD160: {
+			Host: reflect.ValueOf(ef.RIPEMD160),
+			Sig: &wasm.FunctionSig{
+				ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32},
+				ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+			},
+			Body: &wasm.FunctionBody{
+				Code: []byte{},
+			},
+		},
+		OpNameBlake2b: {
+			Host: reflect.ValueOf(ef.Blake2b),
+			Sig: &wasm.FunctionSig{
+				ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32},
+				ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+			},
+			Body: &wasm.FunctionBody{
+				Code: []byte{},
+			},
+		},
+		OpNameEd25519Verify: {
+			Host: reflect.ValueOf(ef.Ed25519Verify),
+			Sig: &wasm.FunctionSig{
+				ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32},
+				ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+			},
+			Body: &wasm.FunctionBody{
+				Code: []byte{},
+			},
+		},
+		OpNameBN256PairingCheck: {
+			Host: reflect.ValueOf(ef.BN256PairingCheck),
+			Sig: &wasm.FunctionSig{
+				ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32},
+				ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+			},
+			Body: &wasm.FunctionBody{
+				Code: []byte{},
+			},
+		},
+	}
+}
+
+// getStaticCallFuncTable returns the static call function since the static call fork.
+func (ef *EnvFunctions) getStaticCallFuncTable() map[string]wasm.Function {
+	return map[string]wasm.Function{
+		OpNameStaticCall: {
+			Host: reflect.ValueOf(ef.StaticCall),
+			Sig: &wasm.FunctionSig{
+				ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI64},
+				ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+			},
+			Body: &wasm.FunctionBody{
+				Code: []byte{},
+			},
+		},
+	}
+}
+
+// getCreateContractFuncTable returns the create contract function since the create contract fork.
+func (ef *EnvFunctions) getCreateContractFuncTable() map[string]wasm.Function {
+	return map[string]wasm.Function{
+		OpNameCreateContract: {
+			Host: reflect.ValueOf(ef.CreateContract),
+			Sig: &wasm.FunctionSig{
+				ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32},
+				ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+			},
+			Body: &wasm.FunctionBody{
+				Code: []byte{},
+			},
+		},
+	}
+}
@@ -528,6 +528,51 @@ func (gas GasCounter) GasEcrecover() {
 	gas.Charge(constGasFunc(params.EcrecoverGas))
 }
 
+func (gas GasCounter) GasSHA256(size uint64) {
+	gas.Charge(gas.GasTable.Sha256)
+	gas.Charge(gas.GasTable.Sha256Word * toWordSize(size))
+}
+
+func (gas GasCounter) GasRIPEMD160(size uint64) {
+	gas.Charge(gas.GasTable.Ripemd160)
+	gas.Charge(gas.GasTable.Ripemd160Word * toWordSize(size))
+}
+
+func (gas GasCounter) GasBlake2b(size uint64) {
+	gas.Charge(gas.GasTable.Blake2b)
+	gas.Charge(gas.GasTable.Blake2bWord * toWordSize(size))
+}
+
+func (gas GasCounter) GasEd25519Verify() {
+	gas.Charge(constGasFunc(gas.GasTable.Ed25519Verify))
+}
+
+func (gas GasCounter) GasBN256PairingCheck(points uint64) {
+	gas.Charge(gas.GasTable.Bn256Pairing)
+	gas.Charge(gas.GasTable.Bn256PairingPerPoint * points)
+}
+
+// GasCreate charges the gas of creating a contract by a contract, and returns
+// the gas available for the creation, all but one 64th of the remaining gas.
+// The salted creation hashes the code to derive the address.
+func (gas GasCounter) GasCreate(size uint64, salted bool) uint64 {
+	gas.Charge(params.CreateGas)
+	if salted {
+		gas.Charge(params.Sha3WordGas * toWordSize(size))
+	}
+	available := gas.Contract.Gas - gas.Contract.Gas/64
+	gas.Charge(available)
+	return available
+}
+
+// toWordSize returns the number of 32 bytes words to hold size bytes.
+func toWordSize(size uint64) uint64 {
+	if size > math.MaxUint64-31 {
+		return math.MaxUint64/32 + 1
+	}
+	return (size + 31) / 32
+}
+
 func (gas GasCounter) GasPow(exponent *big.Int) {
 	expByteLen := uint64((exponent.BitLen() + 7) / 8)
 	var (
@@ -0,0 +1,715 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package wavm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vntchain/go-vnt/accounts/abi"
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/vm"
+	inter "github.com/vntchain/go-vnt/core/vm/interface"
+	"github.com/vntchain/go-vnt/core/wavm/contract"
+	"github.com/vntchain/go-vnt/core/wavm/gas"
+	"github.com/vntchain/go-vnt/core/wavm/storage"
+	"github.com/vntchain/go-vnt/core/wavm/utils"
+	"github.com/vntchain/go-vnt/params"
+	"github.com/vntchain/vnt-wasm/disasm"
+	"github.com/vntchain/vnt-wasm/exec"
+	"github.com/vntchain/vnt-wasm/wasm"
+)
+
+// registerGas is the gas limit of running the key registration of a contract.
+const registerGas = 100000000
+
+var (
+	errNoStorageLayout = errors.New("contract declares no storage layout in abi")
+	errLayoutMismatch  = errors.New("storage layout registered by contract mismatches abi")
+)
+
+// storageTypes are the names of the value types of storage variables.
+var storageTypes = map[string]int32{
+	"int32":   abi.TY_INT32,
+	"int64":   abi.TY_INT64,
+	"uint32":  abi.TY_UINT32,
+	"uint64":  abi.TY_UINT64,
+	"uint256": abi.TY_UINT256,
+	"string":  abi.TY_STRING,
+	"address": abi.TY_ADDRESS,
+	"bool":    abi.TY_BOOL,
+}
+
+func storageTypeName(typ int32) string {
+	for name, t := range storageTypes {
+		if t == typ {
+			return name
+		}
+	}
+	return fmt.Sprintf("unknown(%d)", typ)
+}
+
+type storageKind int
+
+const (
+	scalarKind storageKind = iota
+	structKind
+	mappingKind
+	arrayKind
+)
+
+// storageNode is a storage variable declared in abi, or a field or an element
+// of it.
+type storageNode struct {
+	name   string
+	kind   storageKind
+	typ    int32          // Type of scalar value, type of mapping key or array index
+	fields []*storageNode // Fields of struct
+	elem   *storageNode   // Value of mapping or element of array
+
+	addr   uint64 // Memory address
+	offset uint64 // Offset of field in struct
+	value  uint64 // Offset of value in mapping or array
+	length uint64 // Offset of length in array
+}
+
+// newStorageNode makes the node of table. Mappings and arrays are structs of
+// the fields "key", "value" and "index", "value", "length" as vntlib.h defines.
+func newStorageNode(table abi.Table) (*storageNode, error) {
+	node := &storageNode{name: table.Name}
+	if len(table.Tables) == 0 {
+		typ, ok := storageTypes[table.Type.String()]
+		if !ok {
+			return nil, fmt.Errorf("unsupported storage type %q of %s", table.Type.String(), table.Name)
+		}
+		node.kind, node.typ = scalarKind, typ
+		return node, nil
+	}
+
+	children := make(map[string]abi.Table)
+	for _, t := range table.Tables {
+		children[t.Name] = t
+	}
+	key, hasKey := children["key"]
+	index, hasIndex := children["index"]
+	value, hasValue := children["value"]
+	_, hasLength := children["length"]
+	switch {
+	case len(children) == 2 && hasKey && hasValue:
+		node.kind = mappingKind
+		keyNode, err := newStorageNode(key)
+		if err != nil {
+			return nil, err
+		}
+		if keyNode.kind != scalarKind {
+			return nil, fmt.Errorf("unsupported key type of mapping %s", table.Name)
+		}
+		node.typ = keyNode.typ
+	case len(children) == 3 && hasIndex && hasValue && hasLength:
+		node.kind = arrayKind
+		node.typ = abi.TY_UINT64
+		if len(index.Tables) != 0 || index.Type.String() != "uint64" {
+			return nil, fmt.Errorf("unsupported index type of array %s", table.Name)
+		}
+	default:
+		node.kind = structKind
+		for _, t := range table.Tables {
+			field, err := newStorageNode(t)
+			if err != nil {
+				return nil, err
+			}
+			node.fields = append(node.fields, field)
+		}
+		return node, nil
+	}
+	elem, err := newStorageNode(value)
+	if err != nil {
+		return nil, err
+	}
+	node.elem = elem
+	return node, nil
+}
+
+// StorageVariable is a value stored by contract, which can be read by a path
+// of the same form. The keys of mappings and indexes of arrays are shown as the
+// types of them, e.g. "balances[address]", "orders[uint64].price" and
+// "orders.length".
+type StorageVariable struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+func (node *storageNode) variables(path string, vars []StorageVariable) []StorageVariable {
+	switch node.kind {
+	case scalarKind:
+		vars = append(vars, StorageVariable{Path: path, Type: storageTypeName(node.typ)})
+	case structKind:
+		for _, field := range node.fields {
+			vars = field.variables(path+"."+field.name, vars)
+		}
+	case mappingKind:
+		vars = node.elem.variables(fmt.Sprintf("%s[%s]", path, storageTypeName(node.typ)), vars)
+	case arrayKind:
+		vars = append(vars, StorageVariable{Path: path + ".length", Type: "uint64"})
+		vars = node.elem.variables(fmt.Sprintf("%s[%s]", path, storageTypeName(node.typ)), vars)
+	}
+	return vars
+}
+
+// storageNodes makes the nodes of the storage variables declared in abi.
+func storageNodes(a abi.ABI) ([]*storageNode, error) {
+	var nodes []*storageNode
+	for _, key := range a.Keys {
+		if len(key.Tables) != 1 {
+			return nil, fmt.Errorf("invalid storage declaration of %s", key.Name)
+		}
+		node, err := newStorageNode(key.Tables[0])
+		if err != nil {
+			return nil, err
+		}
+		node.name = key.Name
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 0 {
+		return nil, errNoStorageLayout
+	}
+	return nodes, nil
+}
+
+// StorageVariables returns all the values can be stored by the storage
+// variables declared in abi.
+func StorageVariables(a abi.ABI) ([]StorageVariable, error) {
+	nodes, err := storageNodes(a)
+	if err != nil {
+		return nil, err
+	}
+	var vars []StorageVariable
+	for _, node := range nodes {
+		vars = node.variables(node.name, vars)
+	}
+	return vars, nil
+}
+
+// StorageLayout is where the storage variables of a contract are stored, it
+// computes the storage locations the same way as the runtime does.
+type StorageLayout struct {
+	vars map[string]*storageNode
+}
+
+// NewStorageLayout makes the storage layout of deployed code.
+//
+// The storage locations are derived from the memory addresses of variables,
+// which are registered by AddKeyInfo when the contract is called. So the
+// registration is run to find the addresses of variables, which are matched
+// with the variables declared in abi in the order of declaration. The fields
+// are placed as clang lays out the types of vntlib.h, and the keys registered
+// for every value are checked against the layout.
+func NewStorageLayout(code []byte) (*StorageLayout, error) {
+	wasmCode, _, err := utils.DecodeContractCode(code)
+	if err != nil {
+		return nil, err
+	}
+	a, err := GetAbi(wasmCode.Abi)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := storageNodes(a)
+	if err != nil {
+		return nil, err
+	}
+	registered, err := registerStorage(wasmCode.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first key of every value is the pointer to the variable
+	roots := make(map[uint64]bool)
+	for _, val := range registered {
+		if len(val.StorageKey) == 0 || val.StorageKey[0].KeyType != abi.TY_POINTER {
+			return nil, errLayoutMismatch
+		}
+		roots[val.StorageKey[0].KeyAddress] = true
+	}
+	if len(roots) != len(nodes) {
+		return nil, errLayoutMismatch
+	}
+	addrs := make([]uint64, 0, len(roots))
+	for addr := range roots {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i] < addrs[j] })
+
+	layout := &StorageLayout{vars: make(map[string]*storageNode)}
+	expected := make(map[uint64]storage.StorageMapping)
+	for i, node := range nodes {
+		node.layout()
+		node.place(addrs[i])
+		node.expect([]storage.StorageKey{{KeyAddress: node.addr, KeyType: abi.TY_POINTER}}, expected)
+		layout.vars[node.name] = node
+	}
+	if len(expected) != len(registered) {
+		return nil, errLayoutMismatch
+	}
+	for addr, want := range expected {
+		have, ok := registered[addr]
+		if !ok || have.StorageValue != want.StorageValue || len(have.StorageKey) != len(want.StorageKey) {
+			return nil, errLayoutMismatch
+		}
+		for i := range want.StorageKey {
+			if have.StorageKey[i] != want.StorageKey[i] {
+				return nil, errLayoutMismatch
+			}
+		}
+	}
+	return layout, nil
+}
+
+// scalarSizes are the sizes of the types of vntlib.h in wasm32, uint256,
+// string and address are pointers.
+var scalarSizes = map[int32]uint64{
+	abi.TY_INT32:   4,
+	abi.TY_INT64:   8,
+	abi.TY_UINT32:  4,
+	abi.TY_UINT64:  8,
+	abi.TY_UINT256: 4,
+	abi.TY_STRING:  4,
+	abi.TY_ADDRESS: 4,
+	abi.TY_BOOL:    1,
+}
+
+func alignUp(n, align uint64) uint64 {
+	return (n + align - 1) / align * align
+}
+
+// layout sets the offsets of node as clang lays out the structs, and returns
+// the size and alignment of node.
+func (node *storageNode) layout() (uint64, uint64) {
+	switch node.kind {
+	case structKind:
+		size, align := uint64(0), uint64(1)
+		for _, field := range node.fields {
+			s, a := field.layout()
+			field.offset = alignUp(size, a)
+			size = field.offset + s
+			if a > align {
+				align = a
+			}
+		}
+		return alignUp(size, align), align
+
+	case mappingKind:
+		// struct { key_type key; val_type value; uint64 mapping1537182776; }
+		s, a := node.elem.layout()
+		node.value = alignUp(scalarSizes[node.typ], a)
+		return alignUp(node.value+s, 8) + 8, 8
+
+	case arrayKind:
+		// struct { uint64 index; val_type value; uint64 length; uint64 array1537182776; }
+		s, a := node.elem.layout()
+		node.value = alignUp(8, a)
+		node.length = alignUp(node.value+s, 8)
+		return node.length + 16, 8
+	}
+	size := scalarSizes[node.typ]
+	return size, size
+}
+
+// place sets the memory addresses of node located at addr.
+func (node *storageNode) place(addr uint64) {
+	node.addr = addr
+	switch node.kind {
+	case structKind:
+		for _, field := range node.fields {
+			field.place(addr + field.offset)
+		}
+	case mappingKind, arrayKind:
+		node.elem.place(addr + node.value)
+	}
+}
+
+// expect adds the keys the compiler registers for the values of node, keys
+// are the keys to locate the node.
+func (node *storageNode) expect(keys []storage.StorageKey, expected map[uint64]storage.StorageMapping) {
+	switch node.kind {
+	case scalarKind:
+		val := storage.StorageMapping{StorageValue: storage.StorageValue{ValueAddress: node.addr, ValueType: node.typ}}
+		val.StorageKey = dedupKeys(keys)
+		expected[node.addr] = val
+	case structKind:
+		for _, field := range node.fields {
+			field.expect(appendKey(keys, storage.StorageKey{KeyAddress: field.addr, KeyType: abi.TY_POINTER}), expected)
+		}
+	case mappingKind:
+		node.elem.expect(appendKey(keys, storage.StorageKey{KeyAddress: node.addr, KeyType: node.typ}), expected)
+	case arrayKind:
+		length := node.addr + node.length
+		expected[length] = storage.StorageMapping{
+			StorageValue: storage.StorageValue{ValueAddress: length, ValueType: abi.TY_UINT64},
+			StorageKey:   dedupKeys(keys),
+		}
+		node.elem.expect(appendKey(keys, storage.StorageKey{KeyAddress: node.addr, KeyType: node.typ, IsArrayIndex: true}), expected)
+	}
+}
+
+func appendKey(keys []storage.StorageKey, key storage.StorageKey) []storage.StorageKey {
+	return append(keys[:len(keys):len(keys)], key)
+}
+
+// storageKeySym is the symbol AddKeyInfo identifies a key by.
+func storageKeySym(key storage.StorageKey) string {
+	return fmt.Sprintf("%d%d%t", key.KeyAddress, key.KeyType, key.IsArrayIndex)
+}
+
+// dedupKeys removes the duplicate keys, which are ignored by AddKeyInfo.
+func dedupKeys(keys []storage.StorageKey) []storage.StorageKey {
+	var res []storage.StorageKey
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if sym := storageKeySym(key); !seen[sym] {
+			seen[sym] = true
+			res = append(res, key)
+		}
+	}
+	return res
+}
+
+// registerStorage runs the functions calling AddKeyInfo in code, and returns
+// the storage mapping registered.
+func registerStorage(code []byte) (mapping map[uint64]storage.StorageMapping, err error) {
+	ref := vm.AccountRef(common.Address{})
+	ctx := ChainContext{
+		Contract:       contract.NewWASMContract(ref, ref, new(big.Int), registerGas),
+		StorageMapping: make(map[uint64]storage.StorageMapping),
+	}
+	ctx.GasCounter = gas.NewGasCounter(ctx.Contract, params.GasTable{})
+	wavm := NewWavm(ctx, Config{}, false)
+	if err := wavm.InstantiateModule(code, []uint8{}); err != nil {
+		return nil, err
+	}
+	module := wavm.Module
+
+	// The imported functions are placed at the beginning of the function
+	// index space.
+	addKeyInfo := -1
+	if module.Import != nil {
+		index := 0
+		for _, entry := range module.Import.Entries {
+			if entry.Type.Kind() != wasm.ExternalFunction {
+				continue
+			}
+			if entry.FieldName == OpNameAddKeyInfo {
+				addKeyInfo = index
+			}
+			index++
+		}
+	}
+	if addKeyInfo < 0 {
+		return nil, errLayoutMismatch
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			mapping, err = nil, fmt.Errorf("register storage failed: %v", r)
+		}
+	}()
+	interpreter, err := exec.NewInterpreter(module, nil, instantiateMemory, wavm.captureOp, wavm.captureEnvFunctionStart, wavm.captureEnvFunctionEnd, false)
+	if err != nil {
+		return nil, err
+	}
+	for i, fn := range module.FunctionIndexSpace {
+		if fn.IsHost() || len(fn.Sig.ParamTypes) != 0 || !callsFunction(fn, module, addKeyInfo) {
+			continue
+		}
+		if _, err := interpreter.ExecContractCode(int64(i)); err != nil {
+			return nil, err
+		}
+	}
+	return wavm.ChainContext.StorageMapping, nil
+}
+
+// callsFunction reports whether fn calls the function of index.
+func callsFunction(fn wasm.Function, module *wasm.Module, index int) bool {
+	d, err := disasm.Disassemble(fn, module)
+	if err != nil {
+		return false
+	}
+	for _, instr := range d.Code {
+		if instr.Op.Name == "call" && int(instr.Immediates[0].(uint32)) == index {
+			return true
+		}
+	}
+	return false
+}
+
+// storagePathElem is an element of the path of storage value.
+type storagePathElem struct {
+	name  string // Name of struct field, or "length" of array
+	key   string // Key of mapping or index of array
+	isKey bool
+}
+
+// parseStoragePath splits path like "orders[3].price" into the variable name
+// and the elements. Keys of string can be quoted.
+func parseStoragePath(path string) (string, []storagePathElem, error) {
+	end := strings.IndexAny(path, ".[")
+	if end < 0 {
+		end = len(path)
+	}
+	name := path[:end]
+	if name == "" {
+		return "", nil, fmt.Errorf("invalid storage path %q", path)
+	}
+
+	var elems []storagePathElem
+	for rest := path[end:]; rest != ""; {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return "", nil, fmt.Errorf("invalid storage path %q", path)
+			}
+			elems = append(elems, storagePathElem{name: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			rest = rest[1:]
+			var key string
+			if strings.HasPrefix(rest, `"`) {
+				quoted, err := quotedPrefix(rest)
+				if err != nil {
+					return "", nil, fmt.Errorf("invalid storage path %q: %v", path, err)
+				}
+				key, _ = strconv.Unquote(quoted)
+				rest = rest[len(quoted):]
+				if !strings.HasPrefix(rest, "]") {
+					return "", nil, fmt.Errorf("invalid storage path %q", path)
+				}
+			} else {
+				end := strings.IndexByte(rest, ']')
+				if end < 0 {
+					return "", nil, fmt.Errorf("invalid storage path %q", path)
+				}
+				key = rest[:end]
+				rest = rest[end:]
+			}
+			elems = append(elems, storagePathElem{key: key, isKey: true})
+			rest = rest[1:]
+		default:
+			return "", nil, fmt.Errorf("invalid storage path %q", path)
+		}
+	}
+	return name, elems, nil
+}
+
+// quotedPrefix returns the quoted string at the beginning of s.
+func quotedPrefix(s string) (string, error) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			if _, err := strconv.Unquote(s[:i+1]); err != nil {
+				return "", err
+			}
+			return s[:i+1], nil
+		}
+	}
+	return "", errors.New("unterminated string")
+}
+
+// storageKey encodes the key of type the same way as getMemory does.
+func storageKey(typ int32, key string) ([]byte, error) {
+	buf := make([]byte, 8)
+	switch typ {
+	case abi.TY_INT32, abi.TY_UINT32:
+		var v uint64
+		var err error
+		if typ == abi.TY_INT32 {
+			var i int64
+			i, err = strconv.ParseInt(key, 0, 32)
+			v = uint64(uint32(int32(i)))
+		} else {
+			v, err = strconv.ParseUint(key, 0, 32)
+		}
+		if err != nil {
+			return nil, err
+		}
+		endianess.PutUint32(buf, uint32(v))
+		return buf[:4], nil
+	case abi.TY_INT64, abi.TY_UINT64:
+		var v uint64
+		var err error
+		if typ == abi.TY_INT64 {
+			var i int64
+			i, err = strconv.ParseInt(key, 0, 64)
+			v = uint64(i)
+		} else {
+			v, err = strconv.ParseUint(key, 0, 64)
+		}
+		if err != nil {
+			return nil, err
+		}
+		endianess.PutUint64(buf, v)
+		return buf, nil
+	case abi.TY_BOOL:
+		b, err := strconv.ParseBool(key)
+		if err != nil {
+			return nil, err
+		}
+		if b {
+			endianess.PutUint32(buf, 1)
+		}
+		return buf[:4], nil
+	case abi.TY_UINT256:
+		v, ok := new(big.Int).SetString(key, 0)
+		if !ok || v.Sign() < 0 || v.BitLen() > 256 {
+			return nil, fmt.Errorf("invalid uint256 %q", key)
+		}
+		return []byte(v.String()), nil
+	case abi.TY_STRING:
+		return []byte(key), nil
+	case abi.TY_ADDRESS:
+		if !common.IsHexAddress(key) {
+			return nil, fmt.Errorf("invalid address %q", key)
+		}
+		return common.HexToAddress(key).Bytes(), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", storageTypeName(typ))
+}
+
+// pointerKey encodes the memory address the same way as getMemory does.
+func pointerKey(addr uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, addr)
+	return key
+}
+
+// StorageValue is a value read from the storage of contract.
+type StorageValue struct {
+	Type     string
+	Location common.Hash // Storage location of the value
+	Value    interface{} // Value of Go type: int32, int64, uint32, uint64, *big.Int, string, common.Address or bool
+}
+
+// Read reads the value of path from the storage of contract addr.
+func (l *StorageLayout) Read(db inter.StateDB, addr common.Address, path string) (*StorageValue, error) {
+	name, elems, err := parseStoragePath(path)
+	if err != nil {
+		return nil, err
+	}
+	node, ok := l.vars[name]
+	if !ok {
+		return nil, fmt.Errorf("storage variable %s not found", name)
+	}
+
+	// Locate the value as callStateDb does
+	var loc common.Hash
+	seen := make(map[string]bool)
+	locate := func(key storage.StorageKey, mem []byte) {
+		if sym := storageKeySym(key); !seen[sym] {
+			seen[sym] = true
+			if loc == (common.Hash{}) {
+				loc = utils.MapLocation(mem, nil)
+			} else {
+				loc = utils.MapLocation(loc.Bytes(), mem)
+			}
+		}
+	}
+	locate(storage.StorageKey{KeyAddress: node.addr, KeyType: abi.TY_POINTER}, pointerKey(node.addr))
+	for i, elem := range elems {
+		switch {
+		case node.kind == structKind && !elem.isKey:
+			var field *storageNode
+			for _, f := range node.fields {
+				if f.name == elem.name {
+					field = f
+				}
+			}
+			if field == nil {
+				return nil, fmt.Errorf("field %s not found in %s", elem.name, path)
+			}
+			locate(storage.StorageKey{KeyAddress: field.addr, KeyType: abi.TY_POINTER}, pointerKey(field.addr))
+			node = field
+
+		case node.kind == arrayKind && !elem.isKey && elem.name == "length":
+			if i != len(elems)-1 {
+				return nil, fmt.Errorf("invalid storage path %q", path)
+			}
+			return readStorage(db, addr, loc, abi.TY_UINT64), nil
+
+		case (node.kind == mappingKind || node.kind == arrayKind) && elem.isKey:
+			key, err := storageKey(node.typ, elem.key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid key of %s: %v", path, err)
+			}
+			if node.kind == arrayKind {
+				length := db.GetState(addr, loc).Bytes()
+				if endianess.Uint64(key) >= endianess.Uint64(length[len(length)-8:]) {
+					return nil, fmt.Errorf("index out of range: %s", path)
+				}
+			}
+			locate(storage.StorageKey{KeyAddress: node.addr, KeyType: node.typ, IsArrayIndex: node.kind == arrayKind}, key)
+			node = node.elem
+
+		default:
+			return nil, fmt.Errorf("invalid storage path %q", path)
+		}
+	}
+	if node.kind != scalarKind {
+		return nil, fmt.Errorf("storage path %q is not a value", path)
+	}
+	return readStorage(db, addr, loc, node.typ), nil
+}
+
+// readStorage reads the value of typ at loc, the same way as ReadWithPointer does.
+func readStorage(db inter.StateDB, addr common.Address, loc common.Hash, typ int32) *StorageValue {
+	val := db.GetState(addr, loc).Bytes()
+	res := &StorageValue{Type: storageTypeName(typ), Location: loc}
+	switch typ {
+	case abi.TY_STRING:
+		var str []byte
+		n := new(big.Int).SetBytes(val).Int64()
+		for i := int64(1); i <= n; i++ {
+			loc0 := new(big.Int).Add(loc.Big(), big.NewInt(i))
+			str = append(str, db.GetState(addr, common.BigToHash(loc0)).Big().Bytes()...)
+		}
+		res.Value = string(str)
+	case abi.TY_UINT256:
+		res.Value = new(big.Int).SetBytes(val)
+	case abi.TY_ADDRESS:
+		res.Value = common.BytesToAddress(val[12:])
+	case abi.TY_INT32:
+		res.Value = int32(endianess.Uint32(val[len(val)-4:]))
+	case abi.TY_UINT32:
+		res.Value = endianess.Uint32(val[len(val)-4:])
+	case abi.TY_BOOL:
+		res.Value = endianess.Uint32(val[len(val)-4:]) != 0
+	case abi.TY_INT64:
+		res.Value = int64(endianess.Uint64(val[len(val)-8:]))
+	case abi.TY_UINT64:
+		res.Value = endianess.Uint64(val[len(val)-8:])
+	}
+	return res
+}
@@ -0,0 +1,421 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package wavm
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/vm"
+	"github.com/vntchain/go-vnt/core/vm/interface"
+	"github.com/vntchain/vnt-wasm/exec"
+	"github.com/vntchain/vnt-wasm/wasm"
+)
+
+// profileFrame is a frame in the call stack of the profiler.
+type profileFrame struct {
+	name      string
+	call      bool   // Whether the frame is a contract call or creation
+	startGas  uint64 // Gas of the called contract
+	parentGas uint64 // Gas left of the caller when the call is entered
+}
+
+// FunctionProfile is the gas and time spent in a wasm function, a host function
+// or a contract call. The self values exclude the functions called by it.
+type FunctionProfile struct {
+	Name      string        `json:"name"`
+	Calls     uint64        `json:"calls"`
+	SelfGas   uint64        `json:"selfGas"`
+	TotalGas  uint64        `json:"totalGas"`
+	SelfTime  time.Duration `json:"selfTime"`
+	TotalTime time.Duration `json:"totalTime"`
+}
+
+// Profiler is a Tracer aggregating the gas and wall time spent in each wasm
+// function and host function. Contract calls are frames named by the call type
+// and the address, so the functions of the called contracts are nested in it.
+type Profiler struct {
+	stack    []profileFrame
+	calls    map[string]uint64
+	gas      map[string]uint64        // Gas by the folded call stack
+	times    map[string]time.Duration // Wall time by the folded call stack
+	lastGas  uint64
+	lastTime time.Time
+
+	gasUsed uint64
+	err     error
+}
+
+// NewProfiler creates a new profiler.
+func NewProfiler() *Profiler {
+	return &Profiler{
+		calls: make(map[string]uint64),
+		gas:   make(map[string]uint64),
+		times: make(map[string]time.Duration),
+	}
+}
+
+// sample charges the gas and time spent since the last sample to the current
+// call stack.
+func (p *Profiler) sample(gas uint64) {
+	if len(p.stack) == 0 {
+		return
+	}
+	names := make([]string, len(p.stack))
+	for i, frame := range p.stack {
+		names[i] = frame.name
+	}
+	key := strings.Join(names, ";")
+	if gas <= p.lastGas {
+		p.gas[key] += p.lastGas - gas
+	}
+	now := time.Now()
+	p.times[key] += now.Sub(p.lastTime)
+	p.lastGas, p.lastTime = gas, now
+}
+
+func (p *Profiler) push(frame profileFrame) {
+	p.stack = append(p.stack, frame)
+	p.calls[frame.name]++
+}
+
+func (p *Profiler) pop() (frame profileFrame) {
+	if len(p.stack) > 0 {
+		frame = p.stack[len(p.stack)-1]
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+	return frame
+}
+
+func (p *Profiler) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	typ := "CALL"
+	if create {
+		typ = "CREATE"
+	}
+	p.lastGas, p.lastTime = gas, time.Now()
+	p.push(profileFrame{name: typ + " " + to.Hex(), call: true, startGas: gas})
+	return nil
+}
+
+func (p *Profiler) CaptureState(env vm.VM, pc uint64, op vm.OPCode, gas, cost uint64, contract inter.Contract, depth int, err error) error {
+	return nil
+}
+
+func (p *Profiler) CaptureLog(env vm.VM, msg string) error {
+	return nil
+}
+
+func (p *Profiler) CaptureFault(env vm.VM, pc uint64, op vm.OPCode, gas, cost uint64, contract inter.Contract, depth int, err error) error {
+	return nil
+}
+
+func (p *Profiler) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	if len(p.stack) > 0 {
+		p.sample(p.stack[0].startGas - gasUsed)
+	}
+	p.stack = p.stack[:0]
+	p.gasUsed, p.err = gasUsed, err
+	return nil
+}
+
+func (p *Profiler) CaptureEnter(typ string, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) error {
+	p.sample(p.lastGas)
+	p.push(profileFrame{name: typ + " " + to.Hex(), call: true, startGas: gas, parentGas: p.lastGas})
+	p.lastGas = gas
+	return nil
+}
+
+func (p *Profiler) CaptureExit(output []byte, gasUsed uint64, err error) error {
+	// Pop the functions left by a failed call
+	for len(p.stack) > 0 && !p.stack[len(p.stack)-1].call {
+		p.pop()
+	}
+	if len(p.stack) == 0 {
+		return nil
+	}
+	frame := p.stack[len(p.stack)-1]
+	p.sample(frame.startGas - gasUsed)
+	p.pop()
+
+	// The gas used by the callee is charged to the caller when the call
+	// returns, which should not be charged again.
+	p.lastGas = frame.parentGas
+	if gasUsed <= p.lastGas {
+		p.lastGas -= gasUsed
+	}
+	return nil
+}
+
+func (p *Profiler) CaptureFunctionEnter(name string, gas uint64) error {
+	p.sample(gas)
+	p.push(profileFrame{name: name})
+	return nil
+}
+
+func (p *Profiler) CaptureFunctionExit(gas uint64) error {
+	p.sample(gas)
+	if len(p.stack) > 0 && !p.stack[len(p.stack)-1].call {
+		p.pop()
+	}
+	return nil
+}
+
+// GasUsed returns the gas used by the execution.
+func (p *Profiler) GasUsed() uint64 { return p.gasUsed }
+
+// Error returns the VM error captured by the profiler.
+func (p *Profiler) Error() error { return p.err }
+
+// Functions returns the profiles of the functions, in the descending order of
+// the self gas.
+func (p *Profiler) Functions() []FunctionProfile {
+	profiles := make(map[string]*FunctionProfile)
+	get := func(name string) *FunctionProfile {
+		if profiles[name] == nil {
+			profiles[name] = &FunctionProfile{Name: name, Calls: p.calls[name]}
+		}
+		return profiles[name]
+	}
+	for key, gas := range p.gas {
+		names := strings.Split(key, ";")
+		get(names[len(names)-1]).SelfGas += gas
+		for name := range uniqueNames(names) {
+			get(name).TotalGas += gas
+		}
+	}
+	for key, t := range p.times {
+		names := strings.Split(key, ";")
+		get(names[len(names)-1]).SelfTime += t
+		for name := range uniqueNames(names) {
+			get(name).TotalTime += t
+		}
+	}
+	result := make([]FunctionProfile, 0, len(profiles))
+	for _, profile := range profiles {
+		result = append(result, *profile)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].SelfGas != result[j].SelfGas {
+			return result[i].SelfGas > result[j].SelfGas
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// uniqueNames returns the set of names, so the recursive functions are counted
+// only once in the total values.
+func uniqueNames(names []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		set[name] = struct{}{}
+	}
+	return set
+}
+
+// GasFlamegraph returns the gas by call stacks in the folded format, which is
+// the input of flamegraph.pl and the compatible tools.
+func (p *Profiler) GasFlamegraph() string {
+	values := make(map[string]uint64, len(p.gas))
+	for key, gas := range p.gas {
+		values[key] = gas
+	}
+	return foldedStacks(values)
+}
+
+// TimeFlamegraph returns the wall time in nanoseconds by call stacks in the
+// folded format.
+func (p *Profiler) TimeFlamegraph() string {
+	values := make(map[string]uint64, len(p.times))
+	for key, t := range p.times {
+		values[key] = uint64(t)
+	}
+	return foldedStacks(values)
+}
+
+func foldedStacks(values map[string]uint64) string {
+	keys := make([]string, 0, len(values))
+	for key, value := range values {
+		if value > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	buf := new(bytes.Buffer)
+	for _, key := range keys {
+		fmt.Fprintf(buf, "%s %d\n", key, values[key])
+	}
+	return buf.String()
+}
+
+// captureFunction notifies the function tracer of the wasm functions entered
+// or exited since the last instruction.
+func (wavm *Wavm) captureFunction() {
+	tracer, ok := wavm.Tracer().(vm.FunctionTracer)
+	if !ok || wavm.VM == nil {
+		return
+	}
+	index, depth, ok := currentFunction(wavm.VM)
+	if !ok {
+		return
+	}
+	gas := wavm.ChainContext.Contract.Gas
+	for n := len(wavm.funcStack); n > depth || (n == depth && wavm.funcStack[n-1] != index); n = len(wavm.funcStack) {
+		wavm.funcStack = wavm.funcStack[:n-1]
+		tracer.CaptureFunctionExit(gas)
+	}
+	for len(wavm.funcStack) < depth {
+		wavm.funcStack = append(wavm.funcStack, index)
+		tracer.CaptureFunctionEnter(wavm.functionName(index), gas)
+	}
+}
+
+var (
+	interFieldsOnce sync.Once
+	curFuncField    []int // Path of the index of the function being executed in exec.VM
+	callDepthField  []int // Path of the depth of the nested wasm calls in exec.VM
+)
+
+// currentFunction returns the index of the wasm function being executed and
+// its depth in the call stack, which starts from 1. The interpreter doesn't
+// export them, so they are read from its execution context, only for the
+// function tracer in debug mode. It returns false if the interpreter has no
+// such fields, so the function tracing is disabled instead of failing.
+func currentFunction(inter *exec.Interpreter) (index int64, depth int, ok bool) {
+	interFieldsOnce.Do(func() {
+		typ := reflect.TypeOf(exec.VM{})
+		ctx, ok1 := typ.FieldByName("ctx")
+		callDepth, ok2 := typ.FieldByName("recursiveCallDepth")
+		if !ok1 || !ok2 || callDepth.Type.Kind() != reflect.Int || ctx.Type.Kind() != reflect.Struct {
+			return
+		}
+		curFunc, ok := ctx.Type.FieldByName("curFunc")
+		if !ok || curFunc.Type.Kind() != reflect.Int64 {
+			return
+		}
+		curFuncField = append(append([]int{}, ctx.Index...), curFunc.Index...)
+		callDepthField = callDepth.Index
+	})
+	if curFuncField == nil || inter == nil || inter.VM == nil {
+		return 0, 0, false
+	}
+	vm := reflect.ValueOf(inter.VM).Elem()
+	return vm.FieldByIndex(curFuncField).Int(), int(vm.FieldByIndex(callDepthField).Int()) + 1, true
+}
+
+// captureFunctionsEnd exits all the functions in the call stack when the
+// execution is finished or failed.
+func (wavm *Wavm) captureFunctionsEnd() {
+	tracer, ok := wavm.Tracer().(vm.FunctionTracer)
+	if !ok {
+		return
+	}
+	gas := wavm.ChainContext.Contract.Gas
+	if wavm.inHostFunc {
+		wavm.inHostFunc = false
+		tracer.CaptureFunctionExit(gas)
+	}
+	for range wavm.funcStack {
+		tracer.CaptureFunctionExit(gas)
+	}
+	wavm.funcStack = nil
+}
+
+func (wavm *Wavm) captureHostFunctionEnter(funcName string) {
+	tracer, ok := wavm.Tracer().(vm.FunctionTracer)
+	if !ok {
+		return
+	}
+	wavm.captureFunction()
+	// The gas of instructions is charged by AddGas, which belongs to the
+	// calling function.
+	name := wavm.hostFunctionName(funcName)
+	if name == OpNameAddGas {
+		return
+	}
+	wavm.inHostFunc = true
+	tracer.CaptureFunctionEnter(name, wavm.ChainContext.Contract.Gas)
+}
+
+func (wavm *Wavm) captureHostFunctionExit() {
+	tracer, ok := wavm.Tracer().(vm.FunctionTracer)
+	if !ok || !wavm.inHostFunc {
+		return
+	}
+	wavm.inHostFunc = false
+	tracer.CaptureFunctionExit(wavm.ChainContext.Contract.Gas)
+}
+
+// functionName returns the export name of the wasm function, or the index if
+// it's not exported.
+func (wavm *Wavm) functionName(index int64) string {
+	if wavm.Module.Export != nil {
+		for name, e := range wavm.Module.Export.Entries {
+			if e.Kind == wasm.ExternalFunction && int64(e.Index) == index {
+				return name
+			}
+		}
+	}
+	return fmt.Sprintf("func[%d]", index)
+}
+
+var hostFuncSuffix = regexp.MustCompile(`(-fm|\.func\d+)$`)
+
+// hostFunctionName returns the import name of the host function by the name of
+// the go function implementing it.
+func (wavm *Wavm) hostFunctionName(funcName string) string {
+	if wavm.hostFuncNames == nil {
+		wavm.hostFuncNames = make(map[string]string)
+		ambiguous := make(map[string]bool)
+		index := 0
+		if wavm.Module.Import != nil {
+			for _, entry := range wavm.Module.Import.Entries {
+				if entry.Type.Kind() != wasm.ExternalFunction {
+					continue
+				}
+				fn := wavm.Module.FunctionIndexSpace[index]
+				index++
+				if !fn.IsHost() {
+					continue
+				}
+				name := runtime.FuncForPC(fn.Host.Pointer()).Name()
+				// The events and calls are implemented by the same closure
+				if prev, ok := wavm.hostFuncNames[name]; ok && prev != entry.FieldName {
+					ambiguous[name] = true
+				}
+				wavm.hostFuncNames[name] = entry.FieldName
+			}
+		}
+		for name := range ambiguous {
+			delete(wavm.hostFuncNames, name)
+		}
+	}
+	if name, ok := wavm.hostFuncNames[funcName]; ok {
+		return name
+	}
+	trimmed := hostFuncSuffix.ReplaceAllString(funcName, "")
+	return trimmed[strings.LastIndex(trimmed, ".")+1:]
+}
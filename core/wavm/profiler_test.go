@@ -0,0 +1,35 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package wavm
+
+import (
+	"testing"
+
+	"github.com/vntchain/vnt-wasm/exec"
+)
+
+// TestCurrentFunction checks the execution context of the vendored interpreter
+// is still readable, otherwise the function tracing is silently disabled.
+func TestCurrentFunction(t *testing.T) {
+	index, depth, ok := currentFunction(&exec.Interpreter{VM: new(exec.VM)})
+	if !ok {
+		t.Fatal("current function of the interpreter is not readable")
+	}
+	if index != 0 || depth != 1 {
+		t.Errorf("current function mismatch: have %d at depth %d, want 0 at depth 1", index, depth)
+	}
+}
@@ -114,6 +114,9 @@ type Wavm struct {
 	currentFuncName string
 	MutableList     Mutable
 	tempGasLeft     uint64
+	funcStack       []int64 // Indexes of the wasm functions in the call stack, for FunctionTracer
+	inHostFunc      bool
+	hostFuncNames   map[string]string
 }
 
 // type InstanceContext struct {
@@ -136,6 +139,7 @@ func (wavm *Wavm) ResolveImports(name string) (*wasm.Module, error) {
 
 func (wavm *Wavm) captureOp(pc uint64, op byte) error {
 	if wavm.WavmConfig.Debug {
+		wavm.captureFunction()
 		wavm.Tracer().CaptureState(wavm.ChainContext.Wavm, pc, OpCode{Op: op}, wavm.ChainContext.Contract.Gas, 0, wavm.ChainContext.Contract, wavm.ChainContext.Wavm.depth, nil)
 	}
 	return nil
@@ -143,11 +147,15 @@ func (wavm *Wavm) captureOp(pc uint64, op byte) error {
 
 func (wavm *Wavm) captureEnvFunctionStart(pc uint64, funcName string) error {
 	wavm.tempGasLeft = wavm.ChainContext.Contract.Gas
+	if wavm.WavmConfig.Debug {
+		wavm.captureHostFunctionEnter(funcName)
+	}
 	return nil
 }
 
 func (wavm *Wavm) captureEnvFunctionEnd(pc uint64, funcName string) error {
 	if wavm.WavmConfig.Debug {
+		wavm.captureHostFunctionExit()
 		gas := wavm.tempGasLeft - wavm.ChainContext.Contract.Gas
 		wavm.Tracer().CaptureState(wavm.ChainContext.Wavm, pc, OpCode{FuncName: funcName}, wavm.ChainContext.Contract.Gas, gas, wavm.ChainContext.Contract, wavm.ChainContext.Wavm.depth, nil)
 	}
@@ -264,6 +272,9 @@ func (wavm *Wavm) Apply(input []byte, compiled []vnt.Compiled, mutable Mutable)
 	}
 
 	wavm.VM = vm
+	if wavm.WavmConfig.Debug {
+		defer wavm.captureFunctionsEnd()
+	}
 	// gas := wavm.ChainContext.Contract.Gas
 	// adjustedGas := uint64(gas * exec.WasmCostsOpcodesDiv / exec.WasmCostsOpcodesMul)
 	// if adjustedGas > math.MaxUint64 {
@@ -415,6 +426,10 @@ func (wavm *Wavm) ExecCodeWithFuncName(input []byte) ([]byte, error) {
 			*VM.Mutable = false
 		}
 	}
+	// static call can only call unmutable functions
+	if wavm.ChainContext.Wavm.readOnly && *VM.Mutable == true {
+		return nil, vm.ErrWriteProtection
+	}
 	if wavm.ChainContext.Wavm.mutable == -1 {
 		if *VM.Mutable == true {
 			wavm.ChainContext.Wavm.mutable = 1
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"io/ioutil"
+	"math/big"
+	"testing"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/vm"
+	"github.com/vntchain/go-vnt/core/wavm"
+	"github.com/vntchain/go-vnt/log"
+)
+
+func BenchmarkERC20Transfer(b *testing.B) {
+	log.Root().SetHandler(log.DiscardHandler())
+
+	b.Run("cache", func(b *testing.B) {
+		benchmarkERC20Transfer(b, true)
+	})
+	b.Run("nocache", func(b *testing.B) {
+		benchmarkERC20Transfer(b, false)
+	})
+}
+
+func benchmarkERC20Transfer(b *testing.B, cache bool) {
+	jsonfile, err := ioutil.ReadFile(ercJsonPath)
+	if err != nil {
+		b.Fatal(err)
+	}
+	envtest := new(ENVTest)
+	if err := envtest.UnmarshalJSON(jsonfile); err != nil {
+		b.Fatal(err)
+	}
+	vmconfig := vm.Config{}
+	abiobj := getABI(erc20Abi)
+	supply, _ := new(big.Int).SetString("1000000000000", 10)
+	code := append(readFile(erc20Code), packInput(abiobj, "", supply, "bitcoin", "BTC")...)
+	if _, err := envtest.Run(vmconfig, code, true, true, nil); err != nil {
+		b.Fatal(err)
+	}
+	wavm.PurgeModuleCache()
+
+	e := envtest.json.Exec
+	input := packInput(abiobj, "transfer", common.HexToAddress("0x02"), big.NewInt(1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !cache {
+			wavm.PurgeModuleCache()
+		}
+		wavmobj := envtest.newWAVM(envtest.statedb, vmconfig)
+		if _, _, err := wavmobj.Call(vm.AccountRef(e.Caller), e.Address, input, e.GasLimit, e.Value); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
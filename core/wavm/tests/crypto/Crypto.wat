@@ -0,0 +1,47 @@
+(module
+ (type $FUNCSIG$ii (func (param i32) (result i32)))
+ (type $FUNCSIG$iiii (func (param i32 i32 i32) (result i32)))
+ (type $FUNCSIG$v (func))
+ (import "env" "SHA256" (func $SHA256 (param i32) (result i32)))
+ (import "env" "RIPEMD160" (func $RIPEMD160 (param i32) (result i32)))
+ (import "env" "Blake2b" (func $Blake2b (param i32) (result i32)))
+ (import "env" "Ed25519Verify" (func $Ed25519Verify (param i32 i32 i32) (result i32)))
+ (import "env" "BN256PairingCheck" (func $BN256PairingCheck (param i32) (result i32)))
+ (memory $0 1)
+ (export "memory" (memory $0))
+ (export "Crypto" (func $Crypto))
+ (export "Sha256" (func $Sha256))
+ (export "Ripemd160" (func $Ripemd160))
+ (export "Blake2b" (func $Blake2b_))
+ (export "Ed25519Verify" (func $Ed25519Verify_))
+ (export "BN256PairingCheck" (func $BN256PairingCheck_))
+ (func $Crypto (; 5 ;)
+ )
+ (func $Sha256 (; 6 ;) (param $0 i32) (result i32)
+  (call $SHA256
+   (get_local $0)
+  )
+ )
+ (func $Ripemd160 (; 7 ;) (param $0 i32) (result i32)
+  (call $RIPEMD160
+   (get_local $0)
+  )
+ )
+ (func $Blake2b_ (; 8 ;) (param $0 i32) (result i32)
+  (call $Blake2b
+   (get_local $0)
+  )
+ )
+ (func $Ed25519Verify_ (; 9 ;) (param $0 i32) (param $1 i32) (param $2 i32) (result i32)
+  (call $Ed25519Verify
+   (get_local $0)
+   (get_local $1)
+   (get_local $2)
+  )
+ )
+ (func $BN256PairingCheck_ (; 10 ;) (param $0 i32) (result i32)
+  (call $BN256PairingCheck
+   (get_local $0)
+  )
+ )
+)
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/vntchain/go-vnt/core/vm"
+	"github.com/vntchain/go-vnt/core/wavm"
+	"github.com/vntchain/go-vnt/params"
+)
+
+var (
+	cryptoJsonPath = filepath.Join("", "crypto.json")
+	cryptoCode     = filepath.Join("crypto", "Crypto.compress")
+)
+
+func TestCrypto(t *testing.T) {
+	run(t, cryptoJsonPath)
+}
+
+func TestCryptoFork(t *testing.T) {
+	jsonfile, err := ioutil.ReadFile(cryptoJsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envtest := new(ENVTest)
+	if err := envtest.UnmarshalJSON(jsonfile); err != nil {
+		t.Fatal(err)
+	}
+	envtest.getStateDb()
+	e := envtest.json.Exec
+	number := new(big.Int).SetUint64(envtest.json.Env.Number)
+
+	for _, tt := range []struct {
+		block *big.Int
+		fail  bool
+	}{
+		{nil, true},
+		{new(big.Int).Add(number, big.NewInt(1)), true},
+		{number, false},
+	} {
+		config := *params.AllCliqueProtocolChanges
+		config.CryptoBlock = tt.block
+		wavmobj := envtest.newWAVM(envtest.statedb, vm.Config{}).(*wavm.WAVM)
+		wavmobj = wavm.NewWAVM(wavmobj.GetContext(), envtest.statedb, &config, vm.Config{})
+
+		_, _, _, err := wavmobj.Create(vm.AccountRef(e.Caller), readFile(cryptoCode), e.GasLimit, e.Value)
+		if tt.fail && err == nil {
+			t.Errorf("crypto block %v: contract is created before the fork", tt.block)
+		}
+		if !tt.fail && err != nil {
+			t.Errorf("crypto block %v: failed to create contract: %v", tt.block, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"io/ioutil"
+	"math/big"
+	"testing"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/vm"
+	"github.com/vntchain/go-vnt/core/vm/election"
+	"github.com/vntchain/go-vnt/core/wavm"
+	"github.com/vntchain/go-vnt/params"
+)
+
+// TestElectionQuery calls the query methods of election contract by the WAVM,
+// as the RPC calls do, since the methods returning more than one output can't
+// be called by contracts.
+func TestElectionQuery(t *testing.T) {
+	jsonfile, err := ioutil.ReadFile(mutableJsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envtest := new(ENVTest)
+	if err := envtest.UnmarshalJSON(jsonfile); err != nil {
+		t.Fatal(err)
+	}
+	envtest.getStateDb()
+	e := envtest.json.Exec
+	number := new(big.Int).SetUint64(envtest.json.Env.Number)
+
+	electionAddr := common.BytesToAddress([]byte{9})
+	owner := common.HexToAddress("0x122369f04f32269598789998de33e3d56e2c507a")
+	storage, err := election.GenesisStorage([]election.Candidate{{Owner: owner, VoteCount: big.NewInt(10), Registered: true, Bind: true}}, big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range storage {
+		envtest.statedb.SetState(electionAddr, key, value)
+	}
+
+	electionABI, err := election.GetElectionABI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidateInput := packInput(electionABI, "getCandidate", owner)
+	allInput := packInput(electionABI, "getAllCandidates")
+
+	for _, tt := range []struct {
+		block *big.Int
+		fail  bool
+	}{
+		{nil, true},
+		{new(big.Int).Add(number, big.NewInt(1)), true},
+		{number, false},
+	} {
+		config := *params.AllCliqueProtocolChanges
+		config.ElectionQueryBlock = tt.block
+		wavmobj := envtest.newWAVM(envtest.statedb, vm.Config{}).(*wavm.WAVM)
+		wavmobj = wavm.NewWAVM(wavmobj.GetContext(), envtest.statedb, &config, vm.Config{})
+
+		ret, _, err := wavmobj.Call(vm.AccountRef(e.Caller), electionAddr, candidateInput, e.GasLimit, big.NewInt(0))
+		if tt.fail {
+			if err == nil {
+				t.Errorf("election query block %v: query succeeded before the fork", tt.block)
+			}
+			if _, _, err := wavmobj.StaticCall(vm.AccountRef(e.Caller), electionAddr, candidateInput, e.GasLimit); err != vm.ErrWriteProtection {
+				t.Errorf("election query block %v: static call before the fork, got %v, want %v", tt.block, err, vm.ErrWriteProtection)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("election query block %v: failed to query candidate: %v", tt.block, err)
+		}
+		var candidate election.Candidate
+		unpackOutput(electionABI, &candidate, "getCandidate", ret)
+		if candidate.Owner != owner || candidate.VoteCount.Cmp(big.NewInt(10)) != 0 {
+			t.Errorf("candidate mismatch, got %v", candidate.String())
+		}
+
+		ret, _, err = wavmobj.StaticCall(vm.AccountRef(e.Caller), electionAddr, allInput, e.GasLimit)
+		if err != nil {
+			t.Fatalf("failed to query all candidates: %v", err)
+		}
+		var all struct {
+			Owners     []common.Address
+			VoteCounts []*big.Int
+			Actives    []bool
+		}
+		unpackOutput(electionABI, &all, "getAllCandidates", ret)
+		if len(all.Owners) != 1 || all.Owners[0] != owner || !all.Actives[0] {
+			t.Errorf("all candidates mismatch, got %v", all)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+(module
+ (type $FUNCSIG$iiii (func (param i32 i32 i32) (result i32)))
+ (type $FUNCSIG$v (func))
+ (import "env" "CreateContract" (func $CreateContract (param i32 i32 i32) (result i32)))
+ (memory $0 1)
+ (export "memory" (memory $0))
+ (export "Factory" (func $Factory))
+ (export "Create" (func $Create))
+ (func $Factory (; 1 ;)
+ )
+ (func $Create (; 2 ;) (param $0 i32) (param $1 i32) (param $2 i32) (result i32)
+  (call $CreateContract
+   (get_local $0)
+   (get_local $1)
+   (get_local $2)
+  )
+ )
+)
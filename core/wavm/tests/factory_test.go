@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/vm"
+	"github.com/vntchain/go-vnt/core/wavm"
+	"github.com/vntchain/go-vnt/crypto"
+	"github.com/vntchain/go-vnt/params"
+)
+
+var (
+	factoryCode = filepath.Join("factory", "Factory.compress")
+	factoryAbi  = filepath.Join("factory", "abi.json")
+)
+
+func TestCreateContract(t *testing.T) {
+	jsonfile, err := ioutil.ReadFile(ercJsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envtest := new(ENVTest)
+	if err := envtest.UnmarshalJSON(jsonfile); err != nil {
+		t.Fatal(err)
+	}
+	vmconfig := vm.Config{}
+	factoryabi := getABI(factoryAbi)
+	if _, err := envtest.Run(vmconfig, append(readFile(factoryCode), packInput(factoryabi, "")...), true, true, t); err != nil {
+		t.Fatal(err)
+	}
+	factory := envtest.json.Exec.Address
+
+	erc20abi := getABI(erc20Abi)
+	child := append(readFile(erc20Code), packInput(erc20abi, "", big.NewInt(1000), "bitcoin", "BTC")...)
+	create := func(salt string) (common.Address, error) {
+		ret, err := envtest.Run(vmconfig, packInput(factoryabi, "Create", string(child), big.NewInt(0), salt), false, true, t)
+		if err != nil {
+			return common.Address{}, err
+		}
+		var addr common.Address
+		unpackOutput(factoryabi, &addr, "Create", ret)
+		return addr, nil
+	}
+	checkChild := func(addr common.Address) {
+		e := envtest.json.Exec
+		wavmobj := envtest.newWAVM(envtest.statedb, vmconfig)
+		ret, _, err := wavmobj.Call(vm.AccountRef(e.Caller), addr, packInput(erc20abi, "GetTokenName"), e.GasLimit, e.Value)
+		if err != nil {
+			t.Fatalf("failed to call created contract %x: %v", addr, err)
+		}
+		var name string
+		unpackOutput(erc20abi, &name, "GetTokenName", ret)
+		if name != "bitcoin" {
+			t.Errorf("token name mismatch, got %s, want bitcoin", name)
+		}
+	}
+
+	// The address is derived from the nonce of factory
+	nonce := envtest.statedb.GetNonce(factory)
+	addr, err := create("")
+	if err != nil {
+		t.Fatalf("failed to create contract: %v", err)
+	}
+	if want := crypto.CreateAddress(factory, nonce); addr != want {
+		t.Fatalf("address mismatch, got %x, want %x", addr, want)
+	}
+	if have := envtest.statedb.GetNonce(factory); have != nonce+1 {
+		t.Errorf("factory nonce mismatch, got %d, want %d", have, nonce+1)
+	}
+	checkChild(addr)
+
+	// The address is derived from the salt and code, only one can be created
+	salt := crypto.Keccak256Hash([]byte("listing"))
+	addr, err = create(salt.Hex())
+	if err != nil {
+		t.Fatalf("failed to create contract with salt: %v", err)
+	}
+	if want := crypto.CreateAddress2(factory, salt, crypto.Keccak256(child)); addr != want {
+		t.Fatalf("salted address mismatch, got %x, want %x", addr, want)
+	}
+	checkChild(addr)
+	if _, err := create(salt.Hex()); err == nil {
+		t.Errorf("contract created twice with the same salt")
+	}
+
+	// The salt must be the hex of at most 32 bytes
+	for _, salt := range []string{"listing", "0xzz", "0x" + strings.Repeat("00", common.HashLength+1)} {
+		if _, err := create(salt); err == nil {
+			t.Errorf("contract created with invalid salt %q", salt)
+		}
+	}
+}
+
+func TestCreateContractFork(t *testing.T) {
+	jsonfile, err := ioutil.ReadFile(ercJsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envtest := new(ENVTest)
+	if err := envtest.UnmarshalJSON(jsonfile); err != nil {
+		t.Fatal(err)
+	}
+	envtest.getStateDb()
+	e := envtest.json.Exec
+	number := new(big.Int).SetUint64(envtest.json.Env.Number)
+	code := append(readFile(factoryCode), packInput(getABI(factoryAbi), "")...)
+
+	for _, tt := range []struct {
+		block *big.Int
+		fail  bool
+	}{
+		{nil, true},
+		{new(big.Int).Add(number, big.NewInt(1)), true},
+		{number, false},
+	} {
+		config := *params.AllCliqueProtocolChanges
+		config.CreateContractBlock = tt.block
+		wavmobj := envtest.newWAVM(envtest.statedb, vm.Config{}).(*wavm.WAVM)
+		wavmobj = wavm.NewWAVM(wavmobj.GetContext(), envtest.statedb, &config, vm.Config{})
+
+		_, _, _, err := wavmobj.Create(vm.AccountRef(e.Caller), code, e.GasLimit, e.Value)
+		if tt.fail && err == nil {
+			t.Errorf("create contract block %v: contract is created before the fork", tt.block)
+		}
+		if !tt.fail && err != nil {
+			t.Errorf("create contract block %v: failed to create contract: %v", tt.block, err)
+		}
+	}
+}
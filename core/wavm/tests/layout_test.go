@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core"
+	"github.com/vntchain/go-vnt/core/vm"
+	"github.com/vntchain/go-vnt/core/wavm"
+	"github.com/vntchain/go-vnt/core/wavm/utils"
+)
+
+var (
+	layoutWasmPath = filepath.Join("initializeVariables", "initializeVariables.wasm")
+	layoutAbiPath  = filepath.Join("initializeVariables", "layout.json")
+)
+
+func TestStorageVariables(t *testing.T) {
+	vars, err := wavm.StorageVariables(getABI(layoutAbiPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []wavm.StorageVariable{
+		{Path: "u256", Type: "uint256"},
+		{Path: "str", Type: "string"},
+		{Path: "addr", Type: "address"},
+		{Path: "u64", Type: "uint64"},
+		{Path: "s1.str", Type: "string"},
+		{Path: "s1.addr", Type: "address"},
+		{Path: "s1.u256", Type: "uint256"},
+		{Path: "s1.u64", Type: "uint64"},
+		{Path: "s2.str1", Type: "string"},
+		{Path: "s2.addr1", Type: "address"},
+		{Path: "s2.u2561", Type: "uint256"},
+		{Path: "s2.u641", Type: "uint64"},
+		{Path: "s2.s.str2", Type: "string"},
+		{Path: "s2.s.addr2", Type: "address"},
+		{Path: "s2.s.u2562", Type: "uint256"},
+		{Path: "s2.s.u642", Type: "uint64"},
+		{Path: "groups[string].members.length", Type: "uint64"},
+		{Path: "groups[string].members[uint64]", Type: "string"},
+		{Path: "lists[string].length", Type: "uint64"},
+		{Path: "lists[string][uint64]", Type: "string"},
+		{Path: "matrix.length", Type: "uint64"},
+		{Path: "matrix[uint64].length", Type: "uint64"},
+		{Path: "matrix[uint64][uint64]", Type: "string"},
+	}
+	if len(vars) != len(want) {
+		t.Fatalf("variables mismatch: have %v, want %v", vars, want)
+	}
+	for i := range want {
+		if vars[i] != want[i] {
+			t.Errorf("variable %d mismatch: have %v, want %v", i, vars[i], want[i])
+		}
+	}
+}
+
+func TestStorageLayoutRead(t *testing.T) {
+	envtest := new(ENVTest)
+	envtest.json.Pre = core.GenesisAlloc{}
+	envtest.json.Exec.GasLimit = 4000000
+	envtest.json.Exec.Caller = activeAddr
+	envtest.json.Exec.Value = new(big.Int)
+	envtest.json.Env.Difficulty = new(big.Int)
+	envtest.getStateDb()
+	statedb := envtest.statedb
+
+	code := utils.CompressWasmAndAbi(readFile(layoutAbiPath), readFile(layoutWasmPath), nil)
+	wavmobj := envtest.newWAVM(statedb, vm.Config{})
+	_, addr, _, err := wavmobj.Create(vm.AccountRef(activeAddr), code, envtest.json.Exec.GasLimit, new(big.Int))
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout, err := wavm.NewStorageLayout(statedb.GetCode(addr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Values initialized by the constructor, same as initializeVariables.json
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{"u256", big.NewInt(100000000000)},
+		{"addr", common.HexToAddress("0xaaaaaa")},
+		{"str", "teststring"},
+		{"u64", uint64(100000)},
+		{"s1.str", "teststringinstruct"},
+		{"s1.u256", big.NewInt(10000000000011)},
+		{"s1.addr", common.HexToAddress("0xaaaaaa11")},
+		{"s1.u64", uint64(1000001)},
+		{"s2.str1", "teststringinstruct"},
+		{"s2.u2561", big.NewInt(10000000000011)},
+		{"s2.addr1", common.HexToAddress("0xaaaaaa11")},
+		{"s2.u641", uint64(1000001)},
+		{"s2.s.str2", "teststringinstructstruct"},
+		{"s2.s.u2562", big.NewInt(1000000000001122)},
+		{"s2.s.addr2", common.HexToAddress("0xaaaaaa1122")},
+		{"s2.s.u642", uint64(10000012)},
+		{"matrix.length", uint64(0)},
+		{`lists["a]b"].length`, uint64(0)},
+	}
+	for _, tt := range tests {
+		val, err := layout.Read(statedb, addr, tt.path)
+		if err != nil {
+			t.Errorf("read %s failed: %v", tt.path, err)
+			continue
+		}
+		if want, ok := tt.want.(*big.Int); ok {
+			if have, ok := val.Value.(*big.Int); !ok || have.Cmp(want) != 0 {
+				t.Errorf("read %s mismatch: have %v, want %v", tt.path, val.Value, want)
+			}
+		} else if val.Value != tt.want {
+			t.Errorf("read %s mismatch: have %v, want %v", tt.path, val.Value, tt.want)
+		}
+	}
+
+	// Set the length of lists["alice"] at the location derived the same way
+	// as the runtime, and read its elements.
+	lengthLoc := utils.MapLocation(utils.MapLocation(common.LeftPadBytes(big.NewInt(1400).Bytes(), 8), nil).Bytes(), []byte("alice"))
+	statedb.SetState(addr, lengthLoc, common.BytesToHash([]byte{1, 0, 0, 0, 0, 0, 0, 0}))
+	elemLoc := utils.MapLocation(lengthLoc.Bytes(), make([]byte, 8))
+	statedb.SetState(addr, elemLoc, common.BigToHash(big.NewInt(1)))
+	statedb.SetState(addr, common.BigToHash(new(big.Int).Add(elemLoc.Big(), common.Big1)), common.BytesToHash([]byte("bob")))
+
+	if val, err := layout.Read(statedb, addr, "lists[alice].length"); err != nil || val.Value != uint64(1) {
+		t.Errorf("read length mismatch: have %v, %v, want 1", val, err)
+	}
+	if val, err := layout.Read(statedb, addr, `lists["alice"][0]`); err != nil || val.Value != "bob" || val.Location != elemLoc {
+		t.Errorf("read element mismatch: have %v, %v, want bob", val, err)
+	}
+	if _, err := layout.Read(statedb, addr, "lists[alice][1]"); err == nil {
+		t.Errorf("read out of range succeeded")
+	}
+	for _, path := range []string{"s1", "s1.none", "none", "u64.length", "matrix[x]", "lists[alice"} {
+		if _, err := layout.Read(statedb, addr, path); err == nil {
+			t.Errorf("read invalid path %s succeeded", path)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"io/ioutil"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/vm"
+	"github.com/vntchain/go-vnt/core/wavm"
+)
+
+// foldedGas returns the sum of gas in the folded stacks, and checks the format.
+func foldedGas(t *testing.T, folded string) uint64 {
+	var sum uint64
+	for _, line := range strings.Split(strings.TrimSpace(folded), "\n") {
+		i := strings.LastIndex(line, " ")
+		gas, err := strconv.ParseUint(line[i+1:], 10, 64)
+		if i < 0 || err != nil {
+			t.Fatalf("invalid folded stack %q", line)
+		}
+		sum += gas
+	}
+	return sum
+}
+
+func findProfile(profiles []wavm.FunctionProfile, name string) *wavm.FunctionProfile {
+	for i := range profiles {
+		if profiles[i].Name == name {
+			return &profiles[i]
+		}
+	}
+	return nil
+}
+
+func TestProfiler(t *testing.T) {
+	jsonfile, err := ioutil.ReadFile(ercJsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envtest := new(ENVTest)
+	if err := envtest.UnmarshalJSON(jsonfile); err != nil {
+		t.Fatal(err)
+	}
+	erc20abi := getABI(erc20Abi)
+	code := append(readFile(erc20Code), packInput(erc20abi, "", big.NewInt(1000), "bitcoin", "BTC")...)
+	if _, err := envtest.Run(vm.Config{}, code, true, true, t); err != nil {
+		t.Fatal(err)
+	}
+
+	profiler := wavm.NewProfiler()
+	input := packInput(erc20abi, "transfer", common.HexToAddress("0x01"), big.NewInt(10))
+	if _, err := envtest.Run(vm.Config{Debug: true, Tracer: profiler}, input, false, true, t); err != nil {
+		t.Fatal(err)
+	}
+	// All the gas used is charged to the functions
+	if profiler.GasUsed() == 0 || foldedGas(t, profiler.GasFlamegraph()) != profiler.GasUsed() {
+		t.Errorf("gas mismatch: have %d in flamegraph, want %d", foldedGas(t, profiler.GasFlamegraph()), profiler.GasUsed())
+	}
+	root := "CALL " + envtest.json.Exec.Address.Hex()
+	if !strings.HasPrefix(profiler.GasFlamegraph(), root+";transfer") {
+		t.Errorf("flamegraph has no transfer function:\n%s", profiler.GasFlamegraph())
+	}
+	profiles := profiler.Functions()
+	if p := findProfile(profiles, root); p == nil || p.Calls != 1 || p.TotalGas != profiler.GasUsed() {
+		t.Errorf("contract profile mismatch: have %+v", p)
+	}
+	if p := findProfile(profiles, "transfer"); p == nil || p.Calls != 1 || p.TotalGas == 0 || p.TotalGas < p.SelfGas {
+		t.Errorf("transfer profile mismatch: have %+v", p)
+	}
+	if p := findProfile(profiles, "GetSender"); p == nil || p.Calls == 0 {
+		t.Errorf("host function profile mismatch: have %+v", p)
+	}
+	if p := findProfile(profiles, "AddGas"); p != nil {
+		t.Errorf("gas metering is profiled: %+v", p)
+	}
+}
+
+func TestProfilerNestedCall(t *testing.T) {
+	jsonfile, err := ioutil.ReadFile(ercJsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envtest := new(ENVTest)
+	if err := envtest.UnmarshalJSON(jsonfile); err != nil {
+		t.Fatal(err)
+	}
+	factoryabi := getABI(factoryAbi)
+	if _, err := envtest.Run(vm.Config{}, append(readFile(factoryCode), packInput(factoryabi, "")...), true, true, t); err != nil {
+		t.Fatal(err)
+	}
+	erc20abi := getABI(erc20Abi)
+	child := append(readFile(erc20Code), packInput(erc20abi, "", big.NewInt(1000), "bitcoin", "BTC")...)
+
+	profiler := wavm.NewProfiler()
+	ret, err := envtest.Run(vm.Config{Debug: true, Tracer: profiler}, packInput(factoryabi, "Create", string(child), big.NewInt(0), ""), false, true, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var addr common.Address
+	unpackOutput(factoryabi, &addr, "Create", ret)
+
+	// The gas of the created contract is charged only once
+	if foldedGas(t, profiler.GasFlamegraph()) != profiler.GasUsed() {
+		t.Errorf("gas mismatch: have %d in flamegraph, want %d", foldedGas(t, profiler.GasFlamegraph()), profiler.GasUsed())
+	}
+	create := "CREATE " + addr.Hex()
+	if !strings.Contains(profiler.GasFlamegraph(), ";CreateContract;"+create+";") {
+		t.Errorf("flamegraph has no nested creation:\n%s", profiler.GasFlamegraph())
+	}
+	if p := findProfile(profiler.Functions(), create); p == nil || p.Calls != 1 || p.TotalGas == 0 {
+		t.Errorf("creation profile mismatch: have %+v", p)
+	}
+}
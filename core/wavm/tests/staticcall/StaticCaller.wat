@@ -0,0 +1,18 @@
+(module
+ (type $FUNCSIG$iiji (func (param i32 i32 i64) (result i32)))
+ (type $FUNCSIG$v (func))
+ (import "env" "StaticCall" (func $StaticCall (param i32 i32 i64) (result i32)))
+ (memory $0 1)
+ (export "memory" (memory $0))
+ (export "StaticCaller" (func $StaticCaller))
+ (export "Read" (func $Read))
+ (func $StaticCaller (; 1 ;)
+ )
+ (func $Read (; 2 ;) (param $0 i32) (param $1 i32) (param $2 i64) (result i32)
+  (call $StaticCall
+   (get_local $0)
+   (get_local $1)
+   (get_local $2)
+  )
+ )
+)
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vntchain/go-vnt/core/vm"
+	"github.com/vntchain/go-vnt/core/wavm"
+	"github.com/vntchain/go-vnt/params"
+)
+
+var (
+	staticCallCode = filepath.Join("mutableCall", "$TestMutableCall.compress")
+	staticCallAbi  = filepath.Join("mutableCall", "$TestMutableCall.abi")
+)
+
+func TestStaticCall(t *testing.T) {
+	jsonfile, err := ioutil.ReadFile(mutableJsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envtest := new(ENVTest)
+	if err := envtest.UnmarshalJSON(jsonfile); err != nil {
+		t.Fatal(err)
+	}
+	vmconfig := vm.Config{}
+	abiobj := getABI(staticCallAbi)
+	code := append(readFile(staticCallCode), packInput(abiobj, "")...)
+	if _, err := envtest.Run(vmconfig, code, true, true, t); err != nil {
+		t.Fatal(err)
+	}
+	e := envtest.json.Exec
+	wavmobj := envtest.newWAVM(envtest.statedb, vmconfig)
+
+	// unmutable function can be called
+	ret, _, err := wavmobj.StaticCall(vm.AccountRef(e.Caller), e.Address, packInput(abiobj, "ReadA"), e.GasLimit)
+	if err != nil {
+		t.Fatalf("static call unmutable function error: %s", err)
+	}
+	var a int32
+	unpackOutput(abiobj, &a, "ReadA", ret)
+
+	// mutable function is reverted
+	_, _, err = wavmobj.StaticCall(vm.AccountRef(e.Caller), e.Address, packInput(abiobj, "SetA"), e.GasLimit)
+	if err != vm.ErrWriteProtection {
+		t.Fatalf("static call mutable function, got %v, want %v", err, vm.ErrWriteProtection)
+	}
+	if wavmobj.(*wavm.WAVM).ReadOnly() {
+		t.Fatal("read only flag should be reset after static call")
+	}
+
+	ret, _, err = wavmobj.StaticCall(vm.AccountRef(e.Caller), e.Address, packInput(abiobj, "ReadA"), e.GasLimit)
+	if err != nil {
+		t.Fatalf("static call unmutable function error: %s", err)
+	}
+	var b int32
+	unpackOutput(abiobj, &b, "ReadA", ret)
+	if a != b {
+		t.Errorf("state changed by static call, got %d, want %d", b, a)
+	}
+}
+
+var (
+	staticCallerCode = filepath.Join("staticcall", "StaticCaller.compress")
+	staticCallerAbi  = filepath.Join("staticcall", "abi.json")
+)
+
+func TestStaticCallFromContract(t *testing.T) {
+	jsonfile, err := ioutil.ReadFile(mutableJsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envtest := new(ENVTest)
+	if err := envtest.UnmarshalJSON(jsonfile); err != nil {
+		t.Fatal(err)
+	}
+	vmconfig := vm.Config{}
+	targetabi := getABI(staticCallAbi)
+	if _, err := envtest.Run(vmconfig, append(readFile(staticCallCode), packInput(targetabi, "")...), true, true, t); err != nil {
+		t.Fatal(err)
+	}
+	target := envtest.json.Exec.Address
+	callerabi := getABI(staticCallerAbi)
+	if _, err := envtest.Run(vmconfig, append(readFile(staticCallerCode), packInput(callerabi, "")...), true, true, t); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(method string) ([]byte, error) {
+		ret, err := envtest.Run(vmconfig, packInput(callerabi, "Read", target, string(packInput(targetabi, method)), uint64(1000000)), false, true, t)
+		if err != nil {
+			return nil, err
+		}
+		var output string
+		unpackOutput(callerabi, &output, "Read", ret)
+		return []byte(output), nil
+	}
+
+	// unmutable function can be called
+	ret, err := read("ReadA")
+	if err != nil {
+		t.Fatalf("static call unmutable function error: %s", err)
+	}
+	var a int32
+	unpackOutput(targetabi, &a, "ReadA", ret)
+
+	// mutable function is reverted
+	if _, err := read("SetA"); err == nil || !strings.Contains(err.Error(), vm.ErrWriteProtection.Error()) {
+		t.Fatalf("static call mutable function, got %v, want %v", err, vm.ErrWriteProtection)
+	}
+	ret, err = read("ReadA")
+	if err != nil {
+		t.Fatalf("static call unmutable function error: %s", err)
+	}
+	var b int32
+	unpackOutput(targetabi, &b, "ReadA", ret)
+	if a != b {
+		t.Errorf("state changed by static call, got %d, want %d", b, a)
+	}
+}
+
+func TestStaticCallFork(t *testing.T) {
+	jsonfile, err := ioutil.ReadFile(mutableJsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envtest := new(ENVTest)
+	if err := envtest.UnmarshalJSON(jsonfile); err != nil {
+		t.Fatal(err)
+	}
+	envtest.getStateDb()
+	e := envtest.json.Exec
+	number := new(big.Int).SetUint64(envtest.json.Env.Number)
+
+	for _, tt := range []struct {
+		block *big.Int
+		fail  bool
+	}{
+		{nil, true},
+		{new(big.Int).Add(number, big.NewInt(1)), true},
+		{number, false},
+	} {
+		config := *params.AllCliqueProtocolChanges
+		config.StaticCallBlock = tt.block
+		wavmobj := envtest.newWAVM(envtest.statedb, vm.Config{}).(*wavm.WAVM)
+		wavmobj = wavm.NewWAVM(wavmobj.GetContext(), envtest.statedb, &config, vm.Config{})
+
+		_, _, _, err := wavmobj.Create(vm.AccountRef(e.Caller), readFile(staticCallerCode), e.GasLimit, e.Value)
+		if tt.fail && err == nil {
+			t.Errorf("static call block %v: contract is created before the fork", tt.block)
+		}
+		if !tt.fail && err != nil {
+			t.Errorf("static call block %v: failed to create contract: %v", tt.block, err)
+		}
+	}
+}
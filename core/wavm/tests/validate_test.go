@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/vntchain/go-vnt/accounts/abi"
+	"github.com/vntchain/go-vnt/core/wavm"
+	"github.com/vntchain/go-vnt/core/wavm/utils"
+	"github.com/vntchain/go-vnt/params"
+)
+
+var wasmHeader = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+func TestValidateModule(t *testing.T) {
+	code, _, err := utils.DecodeContractCode(readFile(erc20Code))
+	if err != nil {
+		t.Fatal(err)
+	}
+	erc20abi := getABI(erc20Abi)
+	module, err := wavm.ReadModule(code.Code, erc20abi, params.MainnetChainConfig, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wavm.ValidateModule(module, erc20abi, wavm.Config{DisableFloatingPoint: true}); err != nil {
+		t.Errorf("erc20 is invalid: %v", err)
+	}
+
+	// The methods of abi must be exported
+	missing := getABI(erc20Abi)
+	missing.Methods["notExported"] = abi.Method{Name: "notExported"}
+	if err := wavm.ValidateModule(module, missing, wavm.Config{}); err == nil || !strings.Contains(err.Error(), "notExported") {
+		t.Errorf("unexported method: have %v", err)
+	}
+}
+
+func TestValidateModuleInvalid(t *testing.T) {
+	emptyAbi, err := abi.JSON(strings.NewReader("[]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		sections []byte
+		err      string
+	}{
+		{
+			name: "import from other module",
+			sections: []byte{
+				0x01, 0x04, 0x01, 0x60, 0x00, 0x00, // type: func [] -> []
+				0x02, 0x0b, 0x01, 0x03, 'f', 'o', 'o', 0x03, 'b', 'a', 'r', 0x00, 0x00, // import: foo.bar
+			},
+			err: `import from module "foo"`,
+		},
+		{
+			name:     "no export",
+			sections: []byte{},
+			err:      "no export section",
+		},
+		{
+			name: "memory exceeds limit",
+			sections: []byte{
+				0x05, 0x04, 0x01, 0x00, 0xe8, 0x07, // memory: 1000 pages
+				0x07, 0x01, 0x00, // export: none
+			},
+			err: "initial memory pages 1000 exceeds the limit",
+		},
+	}
+	for _, test := range tests {
+		code := append(append([]byte{}, wasmHeader...), test.sections...)
+		module, err := wavm.ReadModule(code, emptyAbi, params.MainnetChainConfig, big.NewInt(0))
+		if err == nil {
+			err = wavm.ValidateModule(module, emptyAbi, wavm.Config{})
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: have error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
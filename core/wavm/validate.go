@@ -0,0 +1,170 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package wavm
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/vntchain/go-vnt/accounts/abi"
+	"github.com/vntchain/go-vnt/core/wavm/gas"
+	"github.com/vntchain/go-vnt/params"
+	"github.com/vntchain/vnt-wasm/disasm"
+	"github.com/vntchain/vnt-wasm/validate"
+	"github.com/vntchain/vnt-wasm/wasm"
+)
+
+// EnvModuleName is the only module the contracts can import from.
+const EnvModuleName = "env"
+
+// ReadModule reads the wasm code of a contract, resolving the imports by the env
+// functions available to the contract of abi at the block. The module is not
+// bound to any chain context, so it can be inspected but not executed.
+func ReadModule(code []byte, contractAbi abi.ABI, chainConfig *params.ChainConfig, blockNumber *big.Int) (module *wasm.Module, err error) {
+	// The env table panics on the unsupported types of abi
+	defer func() {
+		if r := recover(); r != nil {
+			module, err = nil, fmt.Errorf("invalid abi: %v", r)
+		}
+	}()
+	ctx := &ChainContext{
+		BlockNumber: blockNumber,
+		Abi:         contractAbi,
+		Wavm:        &WAVM{chainConfig: chainConfig},
+	}
+	envModule := EnvModule{}
+	envModule.InitModule(ctx)
+	resolve := func(name string) (*wasm.Module, error) {
+		if name != EnvModuleName {
+			return nil, fmt.Errorf("import from module %q, only %q is allowed", name, EnvModuleName)
+		}
+		return envModule.GetModule(), nil
+	}
+	return wasm.ReadModule(bytes.NewReader(code), resolve)
+}
+
+// ValidateModule checks the module read by ReadModule against the WAVM rules:
+// the wasm validation, the limits of memory and table, the floating point
+// instructions if disabled by config, and the exported functions of abi.
+func ValidateModule(module *wasm.Module, contractAbi abi.ABI, config Config) error {
+	if err := validate.VerifyModule(module); err != nil {
+		return err
+	}
+	if module.Export == nil {
+		return fmt.Errorf("module has no export section")
+	}
+
+	maxPages := uint32(config.MaxMemoryPages)
+	if maxPages == 0 {
+		maxPages = maximum_linear_memory / wasm_page_size
+	}
+	if module.Memory != nil {
+		for _, entry := range module.Memory.Entries {
+			if err := checkLimits("memory pages", entry.Limits, maxPages); err != nil {
+				return err
+			}
+		}
+	}
+	maxTable := uint32(config.MaxTableSize)
+	if maxTable == 0 {
+		maxTable = maximum_table_elements
+	}
+	if module.Table != nil {
+		for _, entry := range module.Table.Entries {
+			if err := checkLimits("table elements", entry.Limits, maxTable); err != nil {
+				return err
+			}
+		}
+	}
+
+	if config.DisableFloatingPoint {
+		if err := checkFloatingPoint(module); err != nil {
+			return err
+		}
+	}
+
+	// The methods of abi are called by the exported functions
+	methods := make(map[string]abi.Method)
+	for name, method := range contractAbi.Methods {
+		methods[name] = method
+	}
+	if contractAbi.Constructor.Name != "" {
+		methods[contractAbi.Constructor.Name] = contractAbi.Constructor
+	}
+	for name, method := range methods {
+		entry, ok := module.Export.Entries[name]
+		if !ok || entry.Kind != wasm.ExternalFunction {
+			return fmt.Errorf("method %s of abi is not exported", name)
+		}
+		fn := module.GetFunction(int(entry.Index))
+		if fn == nil || len(fn.Sig.ParamTypes) != len(method.Inputs) {
+			return fmt.Errorf("method %s has %d inputs in abi, mismatched with the exported function", name, len(method.Inputs))
+		}
+	}
+	return nil
+}
+
+func checkLimits(name string, limits wasm.ResizableLimits, max uint32) error {
+	if limits.Initial > max {
+		return fmt.Errorf("initial %s %d exceeds the limit %d", name, limits.Initial, max)
+	}
+	if limits.Flags&1 != 0 && limits.Maximum > max {
+		return fmt.Errorf("maximum %s %d exceeds the limit %d", name, limits.Maximum, max)
+	}
+	return nil
+}
+
+// checkFloatingPoint returns error if any function uses floating point.
+func checkFloatingPoint(module *wasm.Module) error {
+	rule := gas.NewGas(true)
+	isFloat := func(t wasm.ValueType) bool {
+		return t == wasm.ValueTypeF32 || t == wasm.ValueTypeF64
+	}
+	for i, fn := range module.FunctionIndexSpace {
+		if fn.IsHost() {
+			continue
+		}
+		for _, types := range [][]wasm.ValueType{fn.Sig.ParamTypes, fn.Sig.ReturnTypes} {
+			for _, t := range types {
+				if isFloat(t) {
+					return fmt.Errorf("function %d: floating point %s in signature", i, t)
+				}
+			}
+		}
+		for _, local := range fn.Body.Locals {
+			if isFloat(local.Type) {
+				return fmt.Errorf("function %d: floating point %s in locals", i, local.Type)
+			}
+		}
+		d, err := disasm.Disassemble(fn, module)
+		if err != nil {
+			return err
+		}
+		for _, instr := range d.Code {
+			if rule.Rules[rule.Ops[instr.Op.Code]].Metering == gas.MeteringForbidden {
+				return fmt.Errorf("function %d: floating point instruction %s", i, instr.Op.Name)
+			}
+		}
+	}
+	for _, global := range module.GlobalIndexSpace {
+		if isFloat(global.Type.Type) {
+			return fmt.Errorf("floating point %s in globals", global.Type.Type)
+		}
+	}
+	return nil
+}
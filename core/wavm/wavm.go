@@ -23,10 +23,12 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/vntchain/go-vnt/accounts/abi"
 	"github.com/vntchain/go-vnt/common"
 	"github.com/vntchain/go-vnt/core/state"
 	"github.com/vntchain/go-vnt/core/vm"
 	errorsmsg "github.com/vntchain/go-vnt/core/vm"
+	"github.com/vntchain/go-vnt/core/vm/election"
 	inter "github.com/vntchain/go-vnt/core/vm/interface"
 	wasmcontract "github.com/vntchain/go-vnt/core/wavm/contract"
 	"github.com/vntchain/go-vnt/core/wavm/gas"
@@ -35,6 +37,7 @@ import (
 	"github.com/vntchain/go-vnt/crypto"
 	"github.com/vntchain/go-vnt/params"
 	"github.com/vntchain/vnt-wasm/vnt"
+	"github.com/vntchain/vnt-wasm/wasm"
 )
 
 var emptyCodeHash = crypto.Keccak256Hash(nil)
@@ -50,6 +53,9 @@ type WAVM struct {
 	// Mutable is the current call mutable state
 	// -1:init state,0:unmutable,1:mutable
 	mutable int
+	// readOnly is set during a static call, any state modification
+	// in the call and its nested calls will be reverted
+	readOnly bool
 
 	// chainConfig contains information about the current chain
 	chainConfig *params.ChainConfig
@@ -86,30 +92,117 @@ func runWavm(wavm *WAVM, contract *wasmcontract.WASMContract, input []byte, isCr
 	if contract.CodeAddr != nil {
 		precompiles := vm.PrecompiledContractsHubble
 		if p := precompiles[*contract.CodeAddr]; p != nil {
-			return vm.RunPrecompiledContract(wavm, p, input, contract)
+			if wavm.readOnly && *contract.CodeAddr == electionAddress && !election.IsQuery(wavm, input) {
+				return nil, errorsmsg.ErrWriteProtection
+			}
+			return vm.RunPrecompiledContract(wavm.precompileContext(), p, input, contract)
 		}
 	}
 	if len(contract.Code) == 0 {
 		return nil, nil
 	}
+	if isCreate == false {
+		// the deployed code is decoded only once and shared by all the calls
+		cached := getCachedModule(contract.CodeHash)
+		if cached == nil {
+			var err error
+			if cached, err = newCachedModule(wavm, contract); err != nil {
+				return nil, err
+			}
+			putCachedModule(contract.CodeHash, cached)
+		}
+		crx := newChainContext(wavm, contract, cached.code, cached.abi, false)
+		newwawm := NewWavm(crx, wavm.wavmConfig, false)
+		wavm.Wavm = newwawm
+		module, err := cached.instantiate(&newwawm.ChainContext)
+		if err != nil {
+			return nil, err
+		}
+		newwawm.Module = module
+		return newwawm.Apply(input, cached.compiled, cached.mutable)
+	}
+
 	var code wasmcontract.WasmCode
 	decode, vmInput, err := utils.DecodeContractCode(contract.Code)
 	if err != nil {
 		return nil, err
 	}
 	code = decode
-	if isCreate == true {
-		input = vmInput
+	input = vmInput
+
+	abi, err := GetAbi(code.Abi)
+	if err != nil {
+		return nil, err
+	}
+	crx := newChainContext(wavm, contract, code.Code, abi, true)
+	newwawm := NewWavm(crx, wavm.wavmConfig, true)
+	wavm.Wavm = newwawm
+	err = newwawm.InstantiateModule(code.Code, []uint8{})
+	if err != nil {
+		return nil, err
+	}
+	mutable := MutableFunction(abi, newwawm.Module)
+	// compile the wasm code: add gas counter, add statedb r/w
+	compiled, err := CompileModule(newwawm.Module, crx, mutable)
+	if err != nil {
+		return nil, err
+	}
+	res, err := newwawm.Apply(input, compiled, mutable)
+	if err != nil {
+		return nil, err
+	}
+	compileres, err := json.Marshal(compiled)
+	if err != nil {
+		return nil, err
 	}
+	code.Compiled = compileres
+	res = utils.CompressWasmAndAbi(code.Abi, code.Code, code.Compiled)
+	return res, err
+}
 
+// newCachedModule decodes the deployed code of the contract.
+func newCachedModule(wavm *WAVM, contract *wasmcontract.WASMContract) (*cachedModule, error) {
+	code, _, err := utils.DecodeContractCode(contract.Code)
+	if err != nil {
+		return nil, err
+	}
 	abi, err := GetAbi(code.Abi)
 	if err != nil {
 		return nil, err
 	}
+	crx := newChainContext(wavm, contract, code.Code, abi, false)
+	newwawm := NewWavm(crx, wavm.wavmConfig, false)
+	err = newwawm.InstantiateModule(code.Code, []uint8{})
+	if err != nil {
+		return nil, err
+	}
+	var compiled []vnt.Compiled
+	err = json.Unmarshal(code.Compiled, &compiled)
+	if err != nil {
+		return nil, err
+	}
+	module := newwawm.Module
+	// the host functions are bound to the context of this call, drop them
+	// to avoid holding the state in the cache
+	for i, fn := range module.FunctionIndexSpace {
+		if fn.IsHost() {
+			module.FunctionIndexSpace[i] = wasm.Function{Sig: fn.Sig, Body: fn.Body}
+		}
+	}
+	return &cachedModule{
+		code:     code.Code,
+		abi:      abi,
+		module:   module,
+		mutable:  MutableFunction(abi, module),
+		compiled: compiled,
+	}, nil
+}
+
+func newChainContext(wavm *WAVM, contract *wasmcontract.WASMContract, code []byte, abi abi.ABI, isCreate bool) ChainContext {
 	gasRule := gas.NewGas(wavm.wavmConfig.DisableFloatingPoint)
 	gasTable := wavm.ChainConfig().GasTable(wavm.Context.BlockNumber)
 	gasCounter := gas.NewGasCounter(contract, gasTable)
-	crx := ChainContext{
+	return ChainContext{
 		CanTransfer: wavm.Context.CanTransfer,
 		Transfer:    wavm.Context.Transfer,
 		GetHash:     wavm.Context.GetHash,
@@ -125,7 +218,7 @@ func runWavm(wavm *WAVM, contract *wasmcontract.WASMContract, input []byte, isCr
 		Difficulty:     wavm.Context.Difficulty,
 		Contract:       contract,
 		StateDB:        wavm.StateDB.(*state.StateDB),
-		Code:           code.Code,
+		Code:           code,
 		Abi:            abi,
 		Wavm:           wavm,
 		IsCreated:      isCreate,
@@ -134,42 +227,6 @@ func runWavm(wavm *WAVM, contract *wasmcontract.WASMContract, input []byte, isCr
 		GasTable:       gasTable,
 		StorageMapping: make(map[uint64]storage.StorageMapping),
 	}
-	newwawm := NewWavm(crx, wavm.wavmConfig, isCreate)
-	wavm.Wavm = newwawm
-	err = newwawm.InstantiateModule(code.Code, []uint8{})
-	if err != nil {
-		return nil, err
-	}
-	mutable := MutableFunction(abi, newwawm.Module)
-	var res []byte
-	if isCreate == true {
-		// compile the wasm code: add gas counter, add statedb r/w
-		compiled, err := CompileModule(newwawm.Module, crx, mutable)
-		if err != nil {
-			return nil, err
-		}
-		res, err = newwawm.Apply(input, compiled, mutable)
-		if err != nil {
-			return nil, err
-		}
-		compileres, err := json.Marshal(compiled)
-		if err != nil {
-			return nil, err
-		}
-		code.Compiled = compileres
-		res = utils.CompressWasmAndAbi(code.Abi, code.Code, code.Compiled)
-	} else {
-		var compiled []vnt.Compiled
-		err = json.Unmarshal(code.Compiled, &compiled)
-		if err != nil {
-			return nil, err
-		}
-		res, err = newwawm.Apply(input, compiled, mutable)
-		if err != nil {
-			return nil, err
-		}
-	}
-	return res, err
 }
 
 func NewWAVM(ctx vm.Context, statedb inter.StateDB, chainConfig *params.ChainConfig, vmConfig vm.Config) *WAVM {
@@ -193,7 +250,27 @@ func (wavm *WAVM) Cancel() {
 	atomic.StoreInt32(&wavm.abort, 1)
 }
 
+// Create creates a new contract using code as deployment code, the address of
+// the contract is derived from the caller address and nonce.
 func (wavm *WAVM) Create(caller vm.ContractRef, code []byte, gas uint64, value *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
+	return wavm.create(caller, code, gas, value, func(nonce uint64) common.Address {
+		return crypto.CreateAddress(caller.Address(), nonce)
+	})
+}
+
+// Create2 creates a new contract using code as deployment code, the address of
+// the contract is derived from the caller address, salt and the code hash, so
+// it's known before the creation.
+func (wavm *WAVM) Create2(caller vm.ContractRef, code []byte, gas uint64, value *big.Int, salt common.Hash) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
+	return wavm.create(caller, code, gas, value, func(uint64) common.Address {
+		return crypto.CreateAddress2(caller.Address(), salt, crypto.Keccak256(code))
+	})
+}
+
+func (wavm *WAVM) create(caller vm.ContractRef, code []byte, gas uint64, value *big.Int, address func(nonce uint64) common.Address) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
+	if wavm.readOnly {
+		return nil, common.Address{}, gas, errorsmsg.ErrWriteProtection
+	}
 	// Depth check execution. Fail if we're trying to execute above the
 	// limit.
 	if wavm.depth > int(params.CallCreateDepth) {
@@ -203,10 +280,12 @@ func (wavm *WAVM) Create(caller vm.ContractRef, code []byte, gas uint64, value *
 		return nil, common.Address{}, gas, errorsmsg.ErrInsufficientBalance
 	}
 	// Ensure there's no existing contract already at the designated address
+	wavm.captureAccount(caller.Address())
 	nonce := wavm.StateDB.GetNonce(caller.Address())
 	wavm.StateDB.SetNonce(caller.Address(), nonce+1)
 
-	contractAddr = crypto.CreateAddress(caller.Address(), nonce)
+	contractAddr = address(nonce)
+	wavm.captureAccount(contractAddr)
 	contractHash := wavm.StateDB.GetCodeHash(contractAddr)
 	if wavm.StateDB.GetNonce(contractAddr) != 0 || (contractHash != (common.Hash{}) && contractHash != emptyCodeHash) {
 		return nil, common.Address{}, 0, errorsmsg.ErrContractAddressCollision
@@ -229,6 +308,8 @@ func (wavm *WAVM) Create(caller vm.ContractRef, code []byte, gas uint64, value *
 
 	if wavm.wavmConfig.Debug && wavm.depth == 0 {
 		wavm.wavmConfig.Tracer.CaptureStart(caller.Address(), contractAddr, true, code, gas, value)
+	} else {
+		wavm.captureEnter("CREATE", caller.Address(), contractAddr, code, gas, value)
 	}
 	start := time.Now()
 	ret, err = runWavm(wavm, contract, nil, true)
@@ -252,7 +333,7 @@ func (wavm *WAVM) Create(caller vm.ContractRef, code []byte, gas uint64, value *
 	// also counts for code storage gas errors.
 	if maxCodeSizeExceeded || err != nil {
 		wavm.StateDB.RevertToSnapshot(snapshot)
-		if err.Error() != errorsmsg.ErrExecutionReverted.Error() {
+		if err == nil || err.Error() != errorsmsg.ErrExecutionReverted.Error() {
 			contract.UseGas(contract.Gas)
 		}
 	}
@@ -262,6 +343,8 @@ func (wavm *WAVM) Create(caller vm.ContractRef, code []byte, gas uint64, value *
 	}
 	if wavm.wavmConfig.Debug && wavm.depth == 0 {
 		wavm.wavmConfig.Tracer.CaptureEnd(ret, gas-contract.Gas, time.Since(start), err)
+	} else {
+		wavm.captureExit(ret, gas-contract.Gas, err)
 	}
 	return ret, contractAddr, contract.Gas, err
 }
@@ -278,6 +361,10 @@ func (wavm *WAVM) Call(caller vm.ContractRef, addr common.Address, input []byte,
 	if wavm.depth > int(params.CallCreateDepth) {
 		return nil, gas, errorsmsg.ErrDepth
 	}
+	// Fail if we're trying to transfer value in a static call
+	if wavm.readOnly && value.Sign() != 0 {
+		return nil, gas, errorsmsg.ErrWriteProtection
+	}
 	// Fail if we're trying to transfer more than the available balance
 	if !wavm.Context.CanTransfer(wavm.StateDB, caller.Address(), value) {
 		return nil, gas, errorsmsg.ErrInsufficientBalance
@@ -286,6 +373,8 @@ func (wavm *WAVM) Call(caller vm.ContractRef, addr common.Address, input []byte,
 		to       = vm.AccountRef(addr)
 		snapshot = wavm.StateDB.Snapshot()
 	)
+	wavm.captureAccount(caller.Address())
+	wavm.captureAccount(addr)
 	if !wavm.StateDB.Exist(addr) {
 		precompiles := vm.PrecompiledContractsHubble
 		if precompiles[addr] == nil && value.Sign() == 0 {
@@ -317,6 +406,11 @@ func (wavm *WAVM) Call(caller vm.ContractRef, addr common.Address, input []byte,
 		defer func() { // Lazy evaluation of the parameters
 			wavm.wavmConfig.Tracer.CaptureEnd(ret, gas-contract.Gas, time.Since(start), err)
 		}()
+	} else {
+		wavm.captureEnter("CALL", caller.Address(), addr, input, gas, value)
+		defer func() {
+			wavm.captureExit(ret, gas-contract.Gas, err)
+		}()
 	}
 	ret, err = runWavm(wavm, contract, input, false)
 	// When an error was returned by the WAVM or when setting the creation code
@@ -365,6 +459,8 @@ func (wavm *WAVM) CallCode(caller vm.ContractRef, addr common.Address, input []b
 
 	contract.SetCallCode(&addr, wavm.StateDB.GetCodeHash(addr), code)
 
+	wavm.captureAccount(addr)
+	wavm.captureEnter("CALLCODE", caller.Address(), addr, input, gas, value)
 	ret, err = runWavm(wavm, contract, input, false)
 	if err != nil {
 		wavm.StateDB.RevertToSnapshot(snapshot)
@@ -372,6 +468,7 @@ func (wavm *WAVM) CallCode(caller vm.ContractRef, addr common.Address, input []b
 		// 	contract.UseGas(contract.Gas)
 		// }
 	}
+	wavm.captureExit(ret, gas-contract.Gas, err)
 	return ret, contract.Gas, err
 }
 func (wavm *WAVM) DelegateCall(caller vm.ContractRef, addr common.Address, input []byte, gas uint64) (ret []byte, leftOverGas uint64, err error) {
@@ -396,6 +493,8 @@ func (wavm *WAVM) DelegateCall(caller vm.ContractRef, addr common.Address, input
 
 	contract.SetCallCode(&addr, wavm.StateDB.GetCodeHash(addr), code)
 
+	wavm.captureAccount(addr)
+	wavm.captureEnter("DELEGATECALL", caller.Address(), addr, input, gas, nil)
 	ret, err = runWavm(wavm, contract, input, false)
 	if err != nil {
 		wavm.StateDB.RevertToSnapshot(snapshot)
@@ -403,11 +502,61 @@ func (wavm *WAVM) DelegateCall(caller vm.ContractRef, addr common.Address, input
 		// 	contract.UseGas(contract.Gas)
 		// }
 	}
+	wavm.captureExit(ret, gas-contract.Gas, err)
 	return ret, contract.Gas, err
 }
+
+// StaticCall executes the contract associated with the addr with the given input
+// as parameters while disallowing any modifications to the state during the call.
+// Calls to mutable functions, storage writes, value transfers and events of the
+// called contract and its nested calls will be reverted.
 func (wavm *WAVM) StaticCall(caller vm.ContractRef, addr common.Address, input []byte, gas uint64) (ret []byte, leftOverGas uint64, err error) {
-	return nil, 1, nil
+	if wavm.wavmConfig.NoRecursion && wavm.depth > 0 {
+		return nil, gas, nil
+	}
+	// Fail if we're trying to execute above the call depth limit
+	if wavm.depth > int(params.CallCreateDepth) {
+		return nil, gas, errorsmsg.ErrDepth
+	}
+	// Make sure the readonly is only set if we aren't in readonly yet
+	// this makes also sure that the readonly flag isn't removed for
+	// child calls.
+	if !wavm.readOnly {
+		wavm.readOnly = true
+		defer func() { wavm.readOnly = false }()
+	}
+
+	var (
+		to       = vm.AccountRef(addr)
+		snapshot = wavm.StateDB.Snapshot()
+	)
+	// Initialise a new contract and set the code that is to be used by the
+	// WAVM. The contract is a scoped environment for this execution context
+	// only.
+	contract := wasmcontract.NewWASMContract(caller, to, new(big.Int), gas)
+
+	code := wavm.StateDB.GetCode(addr)
+
+	contract.SetCallCode(&addr, wavm.StateDB.GetCodeHash(addr), code)
+
+	wavm.captureAccount(addr)
+	wavm.captureEnter("STATICCALL", caller.Address(), addr, input, gas, nil)
+	ret, err = runWavm(wavm, contract, input, false)
+	if err != nil {
+		wavm.StateDB.RevertToSnapshot(snapshot)
+		if err.Error() != errorsmsg.ErrExecutionReverted.Error() {
+			contract.UseGas(contract.Gas)
+		}
+	}
+	wavm.captureExit(ret, gas-contract.Gas, err)
+	return ret, contract.Gas, err
+}
+
+// ReadOnly reports whether the wavm is executing a static call.
+func (wavm *WAVM) ReadOnly() bool {
+	return wavm.readOnly
 }
+
 func (wavm *WAVM) GetStateDb() inter.StateDB {
 	return wavm.StateDB
 }
@@ -426,3 +575,128 @@ func (wavm *WAVM) GetOrigin() common.Address {
 func (wavm *WAVM) GetTime() *big.Int {
 	return wavm.Time
 }
+
+func (wavm *WAVM) GetBlockNum() *big.Int {
+	return wavm.BlockNumber
+}
+
+// GetWitnesses returns the witnesses list of the ancestor block num.
+func (wavm *WAVM) GetWitnesses(num *big.Int) []common.Address {
+	if wavm.Context.GetWitnesses == nil || !num.IsUint64() {
+		return nil
+	}
+	return wavm.Context.GetWitnesses(num.Uint64())
+}
+
+// captureEnter notifies the call tracer that a nested call or contract
+// creation is entered.
+func (wavm *WAVM) captureEnter(typ string, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if !wavm.wavmConfig.Debug || wavm.depth == 0 {
+		return
+	}
+	if tracer, ok := wavm.wavmConfig.Tracer.(vm.CallTracer); ok {
+		tracer.CaptureEnter(typ, from, to, input, gas, value)
+	}
+}
+
+// captureExit notifies the call tracer that a nested call or contract
+// creation is finished.
+func (wavm *WAVM) captureExit(output []byte, gasUsed uint64, err error) {
+	if !wavm.wavmConfig.Debug || wavm.depth == 0 {
+		return
+	}
+	if tracer, ok := wavm.wavmConfig.Tracer.(vm.CallTracer); ok {
+		tracer.CaptureExit(output, gasUsed, err)
+	}
+}
+
+// captureAccount notifies the state tracer that an account is accessed.
+func (wavm *WAVM) captureAccount(addr common.Address) {
+	if !wavm.wavmConfig.Debug {
+		return
+	}
+	if tracer, ok := wavm.wavmConfig.Tracer.(vm.StateTracer); ok {
+		tracer.CaptureAccount(wavm, addr)
+	}
+}
+
+// captureStorage notifies the state tracer that a storage slot is accessed.
+func (wavm *WAVM) captureStorage(addr common.Address, key common.Hash) {
+	if !wavm.wavmConfig.Debug {
+		return
+	}
+	if tracer, ok := wavm.wavmConfig.Tracer.(vm.StateTracer); ok {
+		tracer.CaptureStorage(wavm, addr, key)
+	}
+}
+
+// precompileContext returns the chain context of the precompiled contracts. The
+// state accesses of them are reported to the state tracer, if there is one.
+func (wavm *WAVM) precompileContext() inter.ChainContext {
+	if !wavm.wavmConfig.Debug {
+		return wavm
+	}
+	if _, ok := wavm.wavmConfig.Tracer.(vm.StateTracer); !ok {
+		return wavm
+	}
+	return &tracedContext{WAVM: wavm, stateDB: &tracedStateDB{StateDB: wavm.StateDB, wavm: wavm}}
+}
+
+// tracedContext is the chain context of the precompiled contracts while tracing.
+type tracedContext struct {
+	*WAVM
+	stateDB inter.StateDB
+}
+
+func (ctx *tracedContext) GetStateDb() inter.StateDB {
+	return ctx.stateDB
+}
+
+// tracedStateDB reports the accounts and storage slots accessed by the precompiled
+// contracts to the state tracer.
+type tracedStateDB struct {
+	inter.StateDB
+	wavm *WAVM
+}
+
+func (db *tracedStateDB) SubBalance(addr common.Address, amount *big.Int) {
+	db.wavm.captureAccount(addr)
+	db.StateDB.SubBalance(addr, amount)
+}
+
+func (db *tracedStateDB) AddBalance(addr common.Address, amount *big.Int) {
+	db.wavm.captureAccount(addr)
+	db.StateDB.AddBalance(addr, amount)
+}
+
+func (db *tracedStateDB) GetBalance(addr common.Address) *big.Int {
+	db.wavm.captureAccount(addr)
+	return db.StateDB.GetBalance(addr)
+}
+
+func (db *tracedStateDB) GetNonce(addr common.Address) uint64 {
+	db.wavm.captureAccount(addr)
+	return db.StateDB.GetNonce(addr)
+}
+
+func (db *tracedStateDB) SetNonce(addr common.Address, nonce uint64) {
+	db.wavm.captureAccount(addr)
+	db.StateDB.SetNonce(addr, nonce)
+}
+
+func (db *tracedStateDB) GetState(addr common.Address, key common.Hash) common.Hash {
+	db.wavm.captureStorage(addr, key)
+	return db.StateDB.GetState(addr, key)
+}
+
+func (db *tracedStateDB) SetState(addr common.Address, key common.Hash, value common.Hash) {
+	db.wavm.captureStorage(addr, key)
+	db.StateDB.SetState(addr, key, value)
+}
+
+func (db *tracedStateDB) ForEachStorage(addr common.Address, cb func(common.Hash, common.Hash) bool) {
+	db.StateDB.ForEachStorage(addr, func(key common.Hash, value common.Hash) bool {
+		db.wavm.captureStorage(addr, key)
+		return cb(key, value)
+	})
+}
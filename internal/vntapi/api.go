@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"strconv"
 	"strings"
 	"time"
 
@@ -547,6 +548,135 @@ func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.A
 	return res[:], state.Error()
 }
 
+// AccountResult is the account with the merkle proof of it in the state trie,
+// and the storage values with the proofs in the storage trie of it.
+type AccountResult struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageResult `json:"storageProof"`
+}
+
+// StorageResult is the storage value of a key with the merkle proof of it.
+type StorageResult struct {
+	Key   common.Hash     `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// GetProof returns the merkle proofs of the account and the storage keys of it
+// in the state of the given block number. The account and storage values are
+// proven by the state root of the block header.
+func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNr rpc.BlockNumber) (*AccountResult, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	accountProof, err := state.GetProof(address)
+	if err != nil {
+		return nil, err
+	}
+
+	// Storage of a non-existent account is empty, which is proven by the absence
+	// of the account.
+	storageHash, codeHash := types.EmptyRootHash, crypto.Keccak256Hash(nil)
+	storageTrie := state.StorageTrie(address)
+	if storageTrie != nil {
+		storageHash, codeHash = storageTrie.Hash(), state.GetCodeHash(address)
+	}
+	storageProof := make([]StorageResult, len(storageKeys))
+	for i, key := range storageKeys {
+		hash := common.HexToHash(key)
+		storageProof[i] = StorageResult{Key: hash, Value: (*hexutil.Big)(new(big.Int)), Proof: []hexutil.Bytes{}}
+		if storageTrie == nil {
+			continue
+		}
+		proof, err := state.GetStorageProof(address, hash)
+		if err != nil {
+			return nil, err
+		}
+		storageProof[i].Value = (*hexutil.Big)(state.GetState(address, hash).Big())
+		storageProof[i].Proof = toHexSlice(proof)
+	}
+
+	return &AccountResult{
+		Address:      address,
+		AccountProof: toHexSlice(accountProof),
+		Balance:      (*hexutil.Big)(state.GetBalance(address)),
+		CodeHash:     codeHash,
+		Nonce:        hexutil.Uint64(state.GetNonce(address)),
+		StorageHash:  storageHash,
+		StorageProof: storageProof,
+	}, state.Error()
+}
+
+func toHexSlice(b [][]byte) []hexutil.Bytes {
+	r := make([]hexutil.Bytes, len(b))
+	for i := range b {
+		r[i] = b[i]
+	}
+	return r
+}
+
+// StorageVariableResult is the decoded value of a storage variable of contract.
+type StorageVariableResult struct {
+	Path     string      `json:"path"`
+	Type     string      `json:"type"`
+	Location common.Hash `json:"location"`
+	Value    interface{} `json:"value"`
+}
+
+// GetStorageVariables returns the storage variables declared in the abi of
+// contract at the given address, with the paths accepted by GetStorageVariable.
+func (s *PublicBlockChainAPI) GetStorageVariables(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) ([]wavm.StorageVariable, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	wasmcode, _, err := utils.DecodeContractCode(state.GetCode(address))
+	if err != nil {
+		return nil, err
+	}
+	abi, err := wavm.GetAbi(wasmcode.Abi)
+	if err != nil {
+		return nil, err
+	}
+	return wavm.StorageVariables(abi)
+}
+
+// GetStorageVariable returns the decoded value of the storage variable at path,
+// e.g. "balances[0x...]" or "orders[3].price", of contract at the given address
+// in the state for the given block number.
+func (s *PublicBlockChainAPI) GetStorageVariable(ctx context.Context, address common.Address, path string, blockNr rpc.BlockNumber) (*StorageVariableResult, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	layout, err := wavm.NewStorageLayout(state.GetCode(address))
+	if err != nil {
+		return nil, err
+	}
+	val, err := layout.Read(state, address, path)
+	if err != nil {
+		return nil, err
+	}
+	res := &StorageVariableResult{Path: path, Type: val.Type, Location: val.Location, Value: val.Value}
+	// 64 bits and 256 bits integers are returned as decimal strings, which are
+	// not safe to be numbers in javascript
+	switch v := val.Value.(type) {
+	case int64:
+		res.Value = strconv.FormatInt(v, 10)
+	case uint64:
+		res.Value = strconv.FormatUint(v, 10)
+	case *big.Int:
+		res.Value = v.String()
+	}
+	return res, state.Error()
+}
+
 // CallArgs represents the arguments for a call.
 type CallArgs struct {
 	From     common.Address  `json:"from"`
@@ -671,10 +801,11 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	return hexutil.Uint64(hi), nil
 }
 
-// GetAllCandidates returns a list of all the candidates.
-func (s *PublicBlockChainAPI) GetAllCandidates(ctx context.Context) ([]rpc.Candidate, error) {
-	// Get stateDB of current block
-	stateDB, err := s.stateDbOfCurrent(ctx)
+// GetAllCandidates returns a list of all the candidates at the given block,
+// or the current block if none requested.
+func (s *PublicBlockChainAPI) GetAllCandidates(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) ([]rpc.Candidate, error) {
+	// Get stateDB of the requested block
+	stateDB, err := s.stateDbOf(ctx, blockNrOrHash)
 	if stateDB == nil || err != nil {
 		return nil, err
 	}
@@ -696,13 +827,15 @@ func (s *PublicBlockChainAPI) GetAllCandidates(ctx context.Context) ([]rpc.Candi
 		rpcCandidates[i].Binder = ca.Binder.String()
 		rpcCandidates[i].Beneficiary = ca.Beneficiary.String()
 		rpcCandidates[i].Bind = ca.Bind
+		rpcCandidates[i].Operator = election.GetOperator(stateDB, ca.Owner).String()
 	}
 	return rpcCandidates, nil
 }
 
-// GetVoter returns a voter's information.
-func (s *PublicBlockChainAPI) GetVoter(ctx context.Context, address common.Address) (*rpc.Voter, error) {
-	stateDB, err := s.stateDbOfCurrent(ctx)
+// GetVoter returns a voter's information at the given block, or the current
+// block if none requested.
+func (s *PublicBlockChainAPI) GetVoter(ctx context.Context, address common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (*rpc.Voter, error) {
+	stateDB, err := s.stateDbOf(ctx, blockNrOrHash)
 	if stateDB == nil || err != nil {
 		return nil, err
 	}
@@ -727,9 +860,10 @@ func (s *PublicBlockChainAPI) GetVoter(ctx context.Context, address common.Addre
 	return voter, nil
 }
 
-// GetStake returns a stake information.
-func (s *PublicBlockChainAPI) GetStake(ctx context.Context, address common.Address) (*rpc.Stake, error) {
-	stateDB, err := s.stateDbOfCurrent(ctx)
+// GetStake returns a stake information at the given block, or the current
+// block if none requested.
+func (s *PublicBlockChainAPI) GetStake(ctx context.Context, address common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (*rpc.Stake, error) {
+	stateDB, err := s.stateDbOf(ctx, blockNrOrHash)
 	if stateDB == nil || err != nil {
 		return nil, err
 	}
@@ -746,13 +880,22 @@ func (s *PublicBlockChainAPI) GetStake(ctx context.Context, address common.Addre
 		Vnt:                (*hexutil.Big)(st.Vnt),
 		LastStakeTimeStamp: (*hexutil.Big)(st.TimeStamp),
 	}
+	if unbonding := election.GetUnbonding(stateDB, address); unbonding != nil {
+		for _, entry := range unbonding.Entries {
+			stake.Unbonding = append(stake.Unbonding, rpc.Unbonding{
+				Vnt:      (*hexutil.Big)(entry.Vnt),
+				Maturity: (*hexutil.Big)(entry.Maturity),
+			})
+		}
+	}
 
 	return stake, nil
 }
 
-// GetRestVNTBounty returns the rest VNT bounty.
-func (s *PublicBlockChainAPI) GetRestVNTBounty(ctx context.Context) (*big.Int, error) {
-	stateDB, err := s.stateDbOfCurrent(ctx)
+// GetRestVNTBounty returns the rest VNT bounty at the given block, or the
+// current block if none requested.
+func (s *PublicBlockChainAPI) GetRestVNTBounty(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) (*big.Int, error) {
+	stateDB, err := s.stateDbOf(ctx, blockNrOrHash)
 	if stateDB == nil || err != nil {
 		return nil, err
 	}
@@ -764,9 +907,14 @@ func (s *PublicBlockChainAPI) GetRestVNTBounty(ctx context.Context) (*big.Int, e
 	}
 }
 
-func (s *PublicBlockChainAPI) stateDbOfCurrent(ctx context.Context) (*state.StateDB, error) {
-	blockNr := rpc.BlockNumber(s.b.CurrentBlock().NumberU64())
-	stateDB, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+// stateDbOf returns the stateDB of the requested block, or the current block
+// if none requested.
+func (s *PublicBlockChainAPI) stateDbOf(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) (*state.StateDB, error) {
+	if blockNrOrHash == nil {
+		current := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(s.b.CurrentBlock().NumberU64()))
+		blockNrOrHash = &current
+	}
+	stateDB, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
 	return stateDB, err
 }
 
@@ -77,6 +77,27 @@ vnt._extend({
 			call: 'admin_removePeer',
 			params: 1
 		}),
+		new vnt._extend.Method({
+			name: 'addTrustedPeer',
+			call: 'admin_addTrustedPeer',
+			params: 1
+		}),
+		new vnt._extend.Method({
+			name: 'removeTrustedPeer',
+			call: 'admin_removeTrustedPeer',
+			params: 1
+		}),
+		new vnt._extend.Method({
+			name: 'banPeer',
+			call: 'admin_banPeer',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new vnt._extend.Method({
+			name: 'unbanPeer',
+			call: 'admin_unbanPeer',
+			params: 1
+		}),
 		new vnt._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
@@ -127,6 +148,14 @@ vnt._extend({
 			name: 'datadir',
 			getter: 'admin_datadir'
 		}),
+		new vnt._extend.Property({
+			name: 'peerScores',
+			getter: 'admin_peerScores'
+		}),
+		new vnt._extend.Property({
+			name: 'bans',
+			getter: 'admin_bans'
+		}),
 	]
 });
 `
@@ -311,6 +340,18 @@ vnt._extend({
 			params: 2,
 			inputFormatter: [null, null]
 		}),
+		new vnt._extend.Method({
+			name: 'profileTransaction',
+			call: 'debug_profileTransaction',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new vnt._extend.Method({
+			name: 'profileCall',
+			call: 'debug_profileCall',
+			params: 2,
+			inputFormatter: [vnt._extend.formatters.inputCallFormatter, vnt._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
 		new vnt._extend.Method({
 			name: 'preimage',
 			call: 'debug_preimage',
@@ -367,6 +408,24 @@ vnt._extend({
 			params: 2,
 			inputFormatter: [vnt._extend.formatters.inputBlockNumberFormatter, vnt._extend.utils.toHex]
 		}),
+		new vnt._extend.Method({
+			name: 'getProof',
+			call: 'core_getProof',
+			params: 3,
+			inputFormatter: [vnt._extend.formatters.inputAddressFormatter, null, vnt._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
+		new vnt._extend.Method({
+			name: 'getStorageVariables',
+			call: 'core_getStorageVariables',
+			params: 2,
+			inputFormatter: [vnt._extend.formatters.inputAddressFormatter, vnt._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
+		new vnt._extend.Method({
+			name: 'getStorageVariable',
+			call: 'core_getStorageVariable',
+			params: 3,
+			inputFormatter: [vnt._extend.formatters.inputAddressFormatter, null, vnt._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
 	],
 	properties: [
 		new vnt._extend.Property({
@@ -573,6 +632,33 @@ vnt._extend({
 			call: 'dpos_getSignersAtHash',
 			params: 1
 		}),
+		new vnt._extend.Method({
+			name: 'getWitnessRewards',
+			call: 'dpos_getWitnessRewards',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new vnt._extend.Method({
+			name: 'getWitnessRewardsAtHash',
+			call: 'dpos_getWitnessRewardsAtHash',
+			params: 1
+		}),
+		new vnt._extend.Method({
+			name: 'getRewards',
+			call: 'dpos_getRewards',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new vnt._extend.Method({
+			name: 'getRewardsAtHash',
+			call: 'dpos_getRewardsAtHash',
+			params: 1
+		}),
+		new vnt._extend.Method({
+			name: 'getRewardsRange',
+			call: 'dpos_getRewardsRange',
+			params: 2
+		}),
 		new vnt._extend.Method({
 			name: 'getPrePrepareMsg',
 			call: 'dpos_getPrePrepareMsg',
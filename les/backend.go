@@ -25,6 +25,7 @@ import (
 	"github.com/vntchain/go-vnt/accounts"
 	"github.com/vntchain/go-vnt/common"
 	"github.com/vntchain/go-vnt/consensus"
+	"github.com/vntchain/go-vnt/consensus/dpos"
 	"github.com/vntchain/go-vnt/core"
 	"github.com/vntchain/go-vnt/core/bloombits"
 	"github.com/vntchain/go-vnt/core/rawdb"
@@ -92,6 +93,12 @@ func New(ctx *node.ServiceContext, config *vnt.Config) (*LightVnt, error) {
 	peers := newPeerSet()
 	quitSync := make(chan struct{})
 
+	// Light client has no state to verify witnesses, verify them by headers
+	engine := vnt.CreateConsensusEngine(ctx, chainConfig, chainDb)
+	if d, ok := engine.(*dpos.Dpos); ok {
+		d.EnableLightVerify()
+	}
+
 	lvnt := &LightVnt{
 		config:           config,
 		chainConfig:      chainConfig,
@@ -100,7 +107,7 @@ func New(ctx *node.ServiceContext, config *vnt.Config) (*LightVnt, error) {
 		peers:            peers,
 		reqDist:          newRequestDistributor(peers, quitSync),
 		accountManager:   ctx.AccountManager,
-		engine:           vnt.CreateConsensusEngine(ctx, chainConfig, chainDb),
+		engine:           engine,
 		shutdownChan:     make(chan bool),
 		networkId:        config.NetworkId,
 		bloomRequests:    make(chan chan *bloombits.Retrieval),
@@ -157,9 +157,9 @@ func (n *Node) Start() error {
 	// if n.serverConfig.StaticNodes == nil {
 	// 	n.serverConfig.StaticNodes = n.config.StaticNodes()
 	// }
-	// if n.serverConfig.TrustedNodes == nil {
-	// 	n.serverConfig.TrustedNodes = n.config.TrustedNodes()
-	// }
+	if n.serverConfig.TrustedNodes == nil {
+		n.serverConfig.TrustedNodes = n.config.TrustedNodes()
+	}
 	if n.serverConfig.NodeDatabase == "" {
 		//n.serverConfig.NodeDatabase = n.config.NodeDB()
 		n.serverConfig.NodeDatabase = n.config.DataDir
@@ -104,6 +104,15 @@ var (
 	AllCliqueProtocolChanges = &ChainConfig{
 		big.NewInt(1337),
 		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
 		&DposConfig{
 			Period:       2,
 			WitnessesNum: 4,
@@ -113,6 +122,15 @@ var (
 	TestChainConfig = &ChainConfig{
 		big.NewInt(1),
 		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
 		&DposConfig{
 			Period:       2,
 			WitnessesNum: 4,
@@ -128,7 +146,16 @@ var (
 type ChainConfig struct {
 	ChainID *big.Int `json:"chainId"` // chainId identifies the current chain and is used for replay protection
 
-	HubbleBlock *big.Int `json:"HubbleBlock,omitempty"` // Hubble switch block (nil = no fork, 0 = already hubble)
+	HubbleBlock         *big.Int `json:"HubbleBlock,omitempty"`         // Hubble switch block (nil = no fork, 0 = already hubble)
+	CryptoBlock         *big.Int `json:"CryptoBlock,omitempty"`         // Crypto host functions switch block (nil = no fork, 0 = already activated)
+	SlashBlock          *big.Int `json:"SlashBlock,omitempty"`          // Witness slashing and jailing switch block (nil = no fork, 0 = already activated)
+	StaticCallBlock     *big.Int `json:"StaticCallBlock,omitempty"`     // Static call host function switch block (nil = no fork, 0 = already activated)
+	HandoverBlock       *big.Int `json:"HandoverBlock,omitempty"`       // Witnesses list handover by the parent's witnesses switch block (nil = no fork, 0 = already activated)
+	CreateContractBlock *big.Int `json:"CreateContractBlock,omitempty"` // Create contract host function switch block (nil = no fork, 0 = already activated)
+	RichEventBlock      *big.Int `json:"RichEventBlock,omitempty"`      // Rich event parameters and abi encoded events switch block (nil = no fork, 0 = already activated)
+	UnbondingBlock      *big.Int `json:"UnbondingBlock,omitempty"`      // Unbonding queue of unStake switch block (nil = no fork, 0 = already activated)
+	OperatorBlock       *big.Int `json:"OperatorBlock,omitempty"`       // Operators of witness candidates switch block (nil = no fork, 0 = already activated)
+	ElectionQueryBlock  *big.Int `json:"ElectionQueryBlock,omitempty"`  // Query methods of election contract switch block (nil = no fork, 0 = already activated)
 
 	// Various consensus engines
 	Dpos *DposConfig `json:"dpos,omitempty"`
@@ -138,6 +165,12 @@ type DposConfig struct {
 	Period       uint64   `json:"period"`       // Number of seconds between blocks to enforce
 	WitnessesNum int      `json:"witnessesnum"` // Number of witnesses
 	WitnessesUrl []string `json:"witnessesUrl"`
+
+	// Number of consecutive missed slots that jails a witness, 0 disables the missed slots tracking
+	MissedSlotsJail uint64 `json:"missedSlotsJail,omitempty"`
+
+	// Number of seconds from unStake to the withdrawal of the stake, 0 means one day
+	UnbondingPeriod uint64 `json:"unbondingPeriod,omitempty"`
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -155,9 +188,18 @@ func (c *ChainConfig) String() string {
 		engine = "unknown"
 	}
 
-	return fmt.Sprintf("{ChainID: %v Hubble: %v Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Hubble: %v Crypto: %v Slash: %v StaticCall: %v Handover: %v CreateContract: %v RichEvent: %v Unbonding: %v Operator: %v ElectionQuery: %v Engine: %v}",
 		c.ChainID,
 		c.HubbleBlock,
+		c.CryptoBlock,
+		c.SlashBlock,
+		c.StaticCallBlock,
+		c.HandoverBlock,
+		c.CreateContractBlock,
+		c.RichEventBlock,
+		c.UnbondingBlock,
+		c.OperatorBlock,
+		c.ElectionQueryBlock,
 		engine,
 	)
 }
@@ -167,6 +209,60 @@ func (c *ChainConfig) IsHubble(num *big.Int) bool {
 	return isForked(c.HubbleBlock, num)
 }
 
+// IsCrypto returns whether num is either equal to the crypto block or greater.
+// The additional cryptographic host functions of WAVM are available since it.
+func (c *ChainConfig) IsCrypto(num *big.Int) bool {
+	return isForked(c.CryptoBlock, num)
+}
+
+// IsSlash returns whether num is either equal to the slash block or greater.
+// The witnesses are jailed and slashed for equivocation and missed slots since it.
+func (c *ChainConfig) IsSlash(num *big.Int) bool {
+	return isForked(c.SlashBlock, num)
+}
+
+// IsStaticCall returns whether num is either equal to the static call block or greater.
+// The contracts can call other contracts read-only by the StaticCall host function since it.
+func (c *ChainConfig) IsStaticCall(num *big.Int) bool {
+	return isForked(c.StaticCallBlock, num)
+}
+
+// IsHandover returns whether num is either equal to the handover block or greater.
+// The block updating the witnesses list is committed by the parent's witnesses since it.
+func (c *ChainConfig) IsHandover(num *big.Int) bool {
+	return isForked(c.HandoverBlock, num)
+}
+
+// IsCreateContract returns whether num is either equal to the create contract block or greater.
+// The contracts can create other contracts by the CreateContract host function since it.
+func (c *ChainConfig) IsCreateContract(num *big.Int) bool {
+	return isForked(c.CreateContractBlock, num)
+}
+
+// IsRichEvent returns whether num is either equal to the rich event block or greater.
+// The events of WAVM support more parameter types and are encoded by the abi since it.
+func (c *ChainConfig) IsRichEvent(num *big.Int) bool {
+	return isForked(c.RichEventBlock, num)
+}
+
+// IsUnbonding returns whether num is either equal to the unbonding block or greater.
+// The stake is unStaked partially into the unbonding queue and claimed after maturity since it.
+func (c *ChainConfig) IsUnbonding(num *big.Int) bool {
+	return isForked(c.UnbondingBlock, num)
+}
+
+// IsOperator returns whether num is either equal to the operator block or greater.
+// The witnesses are the operators authorized by the candidates since it.
+func (c *ChainConfig) IsOperator(num *big.Int) bool {
+	return isForked(c.OperatorBlock, num)
+}
+
+// IsElectionQuery returns whether num is either equal to the election query block or greater.
+// The constant methods of election contract can be called since it.
+func (c *ChainConfig) IsElectionQuery(num *big.Int) bool {
+	return isForked(c.ElectionQueryBlock, num)
+}
+
 // GasTable returns the gas table corresponding to the current phase .
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
@@ -175,6 +271,8 @@ func (c *ChainConfig) GasTable(num *big.Int) GasTable {
 		return GasTableHubble
 	}
 	switch {
+	case c.IsCrypto(num):
+		return GasTableCrypto
 	default:
 		return GasTableHubble
 	}
@@ -202,6 +300,33 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.HubbleBlock, newcfg.HubbleBlock, head) {
 		return newCompatError("Hubble fork block", c.HubbleBlock, newcfg.HubbleBlock)
 	}
+	if isForkIncompatible(c.CryptoBlock, newcfg.CryptoBlock, head) {
+		return newCompatError("Crypto fork block", c.CryptoBlock, newcfg.CryptoBlock)
+	}
+	if isForkIncompatible(c.SlashBlock, newcfg.SlashBlock, head) {
+		return newCompatError("Slash fork block", c.SlashBlock, newcfg.SlashBlock)
+	}
+	if isForkIncompatible(c.StaticCallBlock, newcfg.StaticCallBlock, head) {
+		return newCompatError("StaticCall fork block", c.StaticCallBlock, newcfg.StaticCallBlock)
+	}
+	if isForkIncompatible(c.HandoverBlock, newcfg.HandoverBlock, head) {
+		return newCompatError("Handover fork block", c.HandoverBlock, newcfg.HandoverBlock)
+	}
+	if isForkIncompatible(c.CreateContractBlock, newcfg.CreateContractBlock, head) {
+		return newCompatError("CreateContract fork block", c.CreateContractBlock, newcfg.CreateContractBlock)
+	}
+	if isForkIncompatible(c.RichEventBlock, newcfg.RichEventBlock, head) {
+		return newCompatError("RichEvent fork block", c.RichEventBlock, newcfg.RichEventBlock)
+	}
+	if isForkIncompatible(c.UnbondingBlock, newcfg.UnbondingBlock, head) {
+		return newCompatError("Unbonding fork block", c.UnbondingBlock, newcfg.UnbondingBlock)
+	}
+	if isForkIncompatible(c.OperatorBlock, newcfg.OperatorBlock, head) {
+		return newCompatError("Operator fork block", c.OperatorBlock, newcfg.OperatorBlock)
+	}
+	if isForkIncompatible(c.ElectionQueryBlock, newcfg.ElectionQueryBlock, head) {
+		return newCompatError("ElectionQuery fork block", c.ElectionQueryBlock, newcfg.ElectionQueryBlock)
+	}
 	return nil
 }
 
@@ -268,6 +393,7 @@ func (err *ConfigCompatError) Error() string {
 type Rules struct {
 	ChainID  *big.Int
 	IsHubble bool
+	IsCrypto bool
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -276,5 +402,5 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 	if chainID == nil {
 		chainID = new(big.Int)
 	}
-	return Rules{ChainID: new(big.Int).Set(chainID), IsHubble: c.IsHubble(num)}
+	return Rules{ChainID: new(big.Int).Set(chainID), IsHubble: c.IsHubble(num), IsCrypto: c.IsCrypto(num)}
 }
@@ -33,6 +33,18 @@ type GasTable struct {
 	// to call. May be left nil. Nil means
 	// not charged.
 	CreateBySuicide uint64
+
+	// Cryptographic host functions of WAVM, the word
+	// prices are charged per 32 bytes of the input.
+	Sha256               uint64
+	Sha256Word           uint64
+	Ripemd160            uint64
+	Ripemd160Word        uint64
+	Blake2b              uint64
+	Blake2bWord          uint64
+	Ed25519Verify        uint64
+	Bn256Pairing         uint64
+	Bn256PairingPerPoint uint64
 }
 
 // Variables containing gas prices for different phases.
@@ -50,4 +62,28 @@ var (
 
 		CreateBySuicide: 25000,
 	}
+
+	// GasTableCrypto contain the gas prices for
+	// the crypto phase.
+	GasTableCrypto = GasTable{
+		ExtcodeSize: 700,
+		ExtcodeCopy: 700,
+		Balance:     400,
+		SLoad:       200,
+		Calls:       700,
+		Suicide:     5000,
+		ExpByte:     50,
+
+		CreateBySuicide: 25000,
+
+		Sha256:               Sha256BaseGas,
+		Sha256Word:           Sha256PerWordGas,
+		Ripemd160:            Ripemd160BaseGas,
+		Ripemd160Word:        Ripemd160PerWordGas,
+		Blake2b:              30,
+		Blake2bWord:          6,
+		Ed25519Verify:        2000,
+		Bn256Pairing:         Bn256PairingBaseGas,
+		Bn256PairingPerPoint: Bn256PairingPerPointGas,
+	}
 )
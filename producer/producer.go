@@ -57,11 +57,17 @@ type Producer struct {
 }
 
 func New(vnt Backend, config *params.ChainConfig, mux *event.TypeMux, engine consensus.Engine) *Producer {
+	return NewWithClock(vnt, config, mux, engine, SystemClock)
+}
+
+// NewWithClock creates a producer working by clock, which is used by
+// simulations running with a fake clock.
+func NewWithClock(vnt Backend, config *params.ChainConfig, mux *event.TypeMux, engine consensus.Engine, clock Clock) *Producer {
 	producer := &Producer{
 		vnt:      vnt,
 		mux:      mux,
 		engine:   engine,
-		worker:   newWorker(config, engine, common.Address{}, vnt, mux),
+		worker:   newWorker(config, engine, clock, common.Address{}, vnt, mux),
 		canStart: 1,
 	}
 	go producer.update()
@@ -77,6 +77,7 @@ type Result struct {
 type worker struct {
 	config *params.ChainConfig
 	engine consensus.Engine
+	clock  Clock
 
 	mu sync.Mutex
 
@@ -112,15 +113,16 @@ type worker struct {
 	producing int32
 	atWork    int32
 
-	roundTimer      *time.Timer // Timer to trigger each round of producing block
+	roundTimer      Timer // Timer to trigger each round of producing block
 	resetTimerEvent chan *big.Int
 	producerStop    chan struct{}
 }
 
-func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase common.Address, vnt Backend, mux *event.TypeMux) *worker {
+func newWorker(config *params.ChainConfig, engine consensus.Engine, clock Clock, coinbase common.Address, vnt Backend, mux *event.TypeMux) *worker {
 	worker := &worker{
 		config:          config,
 		engine:          engine,
+		clock:           clock,
 		vnt:             vnt,
 		mux:             mux,
 		txsCh:           make(chan core.NewTxsEvent, txChanSize),
@@ -131,7 +133,7 @@ func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase com
 		proc:            vnt.BlockChain().Validator(),
 		coinbase:        coinbase,
 		unconfirmed:     newUnconfirmedBlocks(vnt.BlockChain(), producingLogAtDepth),
-		roundTimer:      time.NewTimer(time.Second),
+		roundTimer:      clock.NewTimer(time.Second),
 		resetTimerEvent: make(chan *big.Int, 1),
 		producerStop:    make(chan struct{}, 1),
 	}
@@ -200,12 +202,12 @@ func (self *worker) start() {
 	if dp, ok := self.engine.(*dpos.Dpos); ok {
 		dp.InitBft(self.SendBftMsg, self.SendBftPeerChangeMsg, self.chain.VerifyBlockForBft, self.writeBlock)
 		// 刚启动节点的bft节点设置
-		currentRoot := self.chain.CurrentHeader().Root
+		current := self.chain.CurrentHeader()
 		witnessesUrl := self.chain.Config().Dpos.WitnessesUrl
-		if db, err := self.chain.StateAt(currentRoot); err != nil {
+		if db, err := self.chain.StateAt(current.Root); err != nil {
 			log.Error("get current db error", "err", err)
 		} else {
-			_, urls := dp.GetWitnessesFromStateDB(db)
+			_, urls := dp.GetWitnessesFromStateDB(db, self.chain.Config().IsOperator(current.Number))
 			if len(urls) > 0 {
 				witnessesUrl = urls
 			}
@@ -272,7 +274,7 @@ func (self *worker) update() {
 			}
 
 			// Only Dpos using
-		case <-self.roundTimer.C:
+		case <-self.roundTimer.C():
 			if self.config.Dpos != nil {
 				self.commitNewWork()
 			}
@@ -358,7 +360,7 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 		signer:    types.NewHubbleSigner(self.config.ChainID),
 		state:     state,
 		header:    header,
-		createdAt: time.Now(),
+		createdAt: self.clock.Now(),
 	}
 
 	// Keep track of transactions which return errors so they can be removed
@@ -376,7 +378,7 @@ func (self *worker) commitNewWork() {
 	self.currentMu.Lock()
 	defer self.currentMu.Unlock()
 
-	tstart := time.Now()
+	tstart := self.clock.Now()
 	tstamp := tstart.Unix()
 	parent := self.chain.CurrentBlock()
 
@@ -384,7 +386,7 @@ func (self *worker) commitNewWork() {
 	wait := time.Unix(parent.Time().Int64(), 0).Sub(tstart)
 	if wait > 0 {
 		log.Info("CommitNewWork start before parent's block time, wait", "wait", wait)
-		time.Sleep(wait)
+		self.clock.Sleep(wait)
 	}
 
 	num := parent.Number()
@@ -404,7 +406,7 @@ func (self *worker) commitNewWork() {
 	if atomic.LoadInt32(&self.producing) == 1 {
 		self.resetTimerEvent <- header.Time
 	}
-	if time.Unix(header.Time.Int64(), 0).Sub(time.Now()) <= 0 {
+	if time.Unix(header.Time.Int64(), 0).Sub(self.clock.Now()) <= 0 {
 		log.Warn("Prepare use too much time, missing out your turn")
 		return
 	}
@@ -435,7 +437,7 @@ func (self *worker) commitNewWork() {
 	log.Debug("worker", "func", "commitNewWork", "block header", string(blockheaderjson), "block tx", string(blocktxjson))
 	// We only care about logging if we're actually producing.
 	if atomic.LoadInt32(&self.producing) == 1 {
-		log.Info("Commit new producing work", "number", work.Block.Number(), "txs", work.tcount, "elapsed", common.PrettyDuration(time.Since(tstart)))
+		log.Info("Commit new producing work", "number", work.Block.Number(), "txs", work.tcount, "elapsed", common.PrettyDuration(self.clock.Now().Sub(tstart)))
 		self.unconfirmed.Shift(work.Block.NumberU64() - 1)
 	}
 
@@ -456,18 +458,19 @@ func (self *worker) commitNewWork() {
 
 // Reset the clock for the next period
 func (self *worker) resetRoundTimer(nextRoundTime *big.Int) {
-	dur := time.Unix(nextRoundTime.Int64(), 0).Sub(time.Now())
+	now := self.clock.Now()
+	dur := time.Unix(nextRoundTime.Int64(), 0).Sub(now)
 	// Always make sure timer stoped and cleaned before Reset()
 	self.stopRoundTimer()
 	self.roundTimer.Reset(dur)
-	log.Debug("Reset round timer", "header.time", nextRoundTime, "time", time.Now().Unix(), "dur", dur)
+	log.Debug("Reset round timer", "header.time", nextRoundTime, "time", now.Unix(), "dur", dur)
 }
 
 func (self *worker) stopRoundTimer() {
 	// The stop command may be happen when the round timer is timeout but not deal with
 	// the timeout event, so cleaning the channel of roundTimer is needed.
-	if false == self.roundTimer.Stop() && len(self.roundTimer.C) > 0 {
-		<-self.roundTimer.C
+	if false == self.roundTimer.Stop() && len(self.roundTimer.C()) > 0 {
+		<-self.roundTimer.C()
 		log.Warn("worker.roundTimer.C still has a expired event, now has been cleaned")
 	}
 }
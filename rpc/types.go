@@ -17,6 +17,7 @@
 package rpc
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"reflect"
@@ -165,6 +166,78 @@ func (bn BlockNumber) Int64() int64 {
 	return (int64)(bn)
 }
 
+// BlockNumberOrHash selects a block by either the block number or the block
+// hash, used by the APIs querying the historical state.
+type BlockNumberOrHash struct {
+	BlockNumber *BlockNumber `json:"blockNumber,omitempty"`
+	BlockHash   *common.Hash `json:"blockHash,omitempty"`
+}
+
+// UnmarshalJSON parses the given JSON fragment into a BlockNumberOrHash. It
+// supports:
+// - the block hash as a string argument
+// - all the arguments supported by BlockNumber
+// - an object with either blockNumber or blockHash field
+func (bnh *BlockNumberOrHash) UnmarshalJSON(data []byte) error {
+	type object BlockNumberOrHash
+	var obj object
+	if err := json.Unmarshal(data, &obj); err == nil {
+		if obj.BlockNumber != nil && obj.BlockHash != nil {
+			return fmt.Errorf("cannot specify both BlockHash and BlockNumber, choose one or the other")
+		}
+		if obj.BlockNumber == nil && obj.BlockHash == nil {
+			return fmt.Errorf("neither BlockHash nor BlockNumber is specified")
+		}
+		*bnh = BlockNumberOrHash(obj)
+		return nil
+	}
+
+	var input string
+	if err := json.Unmarshal(data, &input); err != nil {
+		return err
+	}
+	if len(input) == 2+2*common.HashLength {
+		var hash common.Hash
+		if err := hash.UnmarshalText([]byte(input)); err != nil {
+			return err
+		}
+		*bnh = BlockNumberOrHash{BlockHash: &hash}
+		return nil
+	}
+	var number BlockNumber
+	if err := number.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	*bnh = BlockNumberOrHash{BlockNumber: &number}
+	return nil
+}
+
+// Number returns the block number, if the block is selected by number.
+func (bnh *BlockNumberOrHash) Number() (BlockNumber, bool) {
+	if bnh.BlockNumber != nil {
+		return *bnh.BlockNumber, true
+	}
+	return BlockNumber(0), false
+}
+
+// Hash returns the block hash, if the block is selected by hash.
+func (bnh *BlockNumberOrHash) Hash() (common.Hash, bool) {
+	if bnh.BlockHash != nil {
+		return *bnh.BlockHash, true
+	}
+	return common.Hash{}, false
+}
+
+// BlockNumberOrHashWithNumber selects the block by number.
+func BlockNumberOrHashWithNumber(number BlockNumber) BlockNumberOrHash {
+	return BlockNumberOrHash{BlockNumber: &number}
+}
+
+// BlockNumberOrHashWithHash selects the block by hash.
+func BlockNumberOrHashWithHash(hash common.Hash) BlockNumberOrHash {
+	return BlockNumberOrHash{BlockHash: &hash}
+}
+
 // Candidate is the information of a witness candidate
 // Using hexutil.Big to replace big.Int for client
 // can read the value as string
@@ -178,6 +251,7 @@ type Candidate struct {
 	Binder      string       `json:"binder"`      // 锁仓人/绑定人
 	Beneficiary string       `json:"beneficiary"` // 收益受益人
 	Bind        bool         `json:"bind"`        // 是否被绑定
+	Operator    string       `json:"operator"`    // 出块及签名BFT消息的账号
 }
 
 // Voter is the information of who has vote witness candidate
@@ -198,4 +272,37 @@ type Stake struct {
 	StakeCount         *hexutil.Big   `json:"stakeCount"`         // 会被计入票数的VNT数量，取整
 	Vnt                *hexutil.Big   `json:"vnt"`                // 抵押的代币数量
 	LastStakeTimeStamp *hexutil.Big   `json:"lastStakeTimeStamp"` // 上次抵押时间戳
+	Unbonding          []Unbonding    `json:"unbonding"`          // 解锁中的抵押
+}
+
+// Unbonding is a withdrawal of stake waiting for maturity
+type Unbonding struct {
+	Vnt      *hexutil.Big `json:"vnt"`      // 解锁中的代币数量
+	Maturity *hexutil.Big `json:"maturity"` // 可以提取的时间戳
+}
+
+// WitnessRewards is the witness list of a block and the rewards granted in
+// the block, including the producing reward and the vote rewards
+type WitnessRewards struct {
+	Number    *hexutil.Big                    `json:"number"`    // 区块高度
+	Hash      common.Hash                     `json:"hash"`      // 区块哈希
+	Producer  common.Address                  `json:"producer"`  // 出块见证人
+	Witnesses []common.Address                `json:"witnesses"` // 见证人列表
+	Rewards   map[common.Address]*hexutil.Big `json:"rewards"`   // 各候选人获得的激励
+}
+
+// Reward is an amount of VNT granted to a candidate in a block
+type Reward struct {
+	Candidate   common.Address `json:"candidate"`   // 候选人地址
+	Beneficiary common.Address `json:"beneficiary"` // 收益受益人
+	Type        string         `json:"type"`        // 激励类型：producing或vote
+	Amount      *hexutil.Big   `json:"amount"`      // 激励金额
+}
+
+// BlockRewards is the record of the rewards granted in a block
+type BlockRewards struct {
+	Number     *hexutil.Big `json:"number"`     // 区块高度
+	Hash       common.Hash  `json:"hash"`       // 区块哈希
+	Rewards    []Reward     `json:"rewards"`    // 发放的激励
+	RestBounty *hexutil.Big `json:"restBounty"` // 发放后的剩余激励
 }
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/vntchain/go-vnt/common"
 	"github.com/vntchain/go-vnt/common/math"
 )
 
@@ -64,3 +65,45 @@ func TestBlockNumberJSONUnmarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestBlockNumberOrHashJSONUnmarshal(t *testing.T) {
+	hash := common.HexToHash("0x1ba4c0c9e4d8d7da64e1e5cb2d35c05d1ca2d1ac7f5b3eb6c7c28a7d7c3f2b6f")
+	tests := []struct {
+		input    string
+		mustFail bool
+		expected BlockNumberOrHash
+	}{
+		0: {`"0x12"`, false, BlockNumberOrHashWithNumber(18)},
+		1: {`"latest"`, false, BlockNumberOrHashWithNumber(LatestBlockNumber)},
+		2: {`"` + hash.Hex() + `"`, false, BlockNumberOrHashWithHash(hash)},
+		3: {`{"blockNumber":"0x1"}`, false, BlockNumberOrHashWithNumber(1)},
+		4: {`{"blockHash":"` + hash.Hex() + `"}`, false, BlockNumberOrHashWithHash(hash)},
+		5: {`{"blockNumber":"0x1","blockHash":"` + hash.Hex() + `"}`, true, BlockNumberOrHash{}},
+		6: {`{}`, true, BlockNumberOrHash{}},
+		7: {`"0x00"`, true, BlockNumberOrHash{}},
+		8: {`"ff"`, true, BlockNumberOrHash{}},
+	}
+
+	for i, test := range tests {
+		var bnh BlockNumberOrHash
+		err := json.Unmarshal([]byte(test.input), &bnh)
+		if test.mustFail && err == nil {
+			t.Errorf("Test %d should fail", i)
+			continue
+		}
+		if !test.mustFail && err != nil {
+			t.Errorf("Test %d should pass but got err: %v", i, err)
+			continue
+		}
+		if test.mustFail {
+			continue
+		}
+		gotNum, gotIsNum := bnh.Number()
+		wantNum, wantIsNum := test.expected.Number()
+		gotHash, _ := bnh.Hash()
+		wantHash, _ := test.expected.Hash()
+		if gotNum != wantNum || gotIsNum != wantIsNum || gotHash != wantHash {
+			t.Errorf("Test %d got unexpected value, want %+v, got %+v", i, test.expected, bnh)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package vnt
 
 import (
 	"context"
+	"errors"
 	"math/big"
 
 	"github.com/vntchain/go-vnt/accounts"
@@ -97,6 +98,19 @@ func (b *VntAPIBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.
 	return stateDb, header, err
 }
 
+func (b *VntAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	if blockNr, ok := blockNrOrHash.Number(); ok {
+		return b.StateAndHeaderByNumber(ctx, blockNr)
+	}
+	hash, _ := blockNrOrHash.Hash()
+	header := b.vnt.blockchain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, nil, errors.New("header for hash not found")
+	}
+	stateDb, err := b.vnt.BlockChain().StateAt(header.Root)
+	return stateDb, header, err
+}
+
 func (b *VntAPIBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
 	return b.vnt.blockchain.GetBlockByHash(hash), nil
 }
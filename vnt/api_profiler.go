@@ -0,0 +1,106 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package vnt
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core"
+	"github.com/vntchain/go-vnt/core/rawdb"
+	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/core/vm"
+	"github.com/vntchain/go-vnt/core/wavm"
+	"github.com/vntchain/go-vnt/internal/vntapi"
+	"github.com/vntchain/go-vnt/rpc"
+)
+
+// ProfileResult is the gas and time spent in the functions of the contracts
+// executed by a transaction or a call. The flamegraphs are in the folded
+// format, which can be rendered by flamegraph.pl or the compatible tools.
+type ProfileResult struct {
+	Gas            uint64                 `json:"gas"`
+	Failed         bool                   `json:"failed"`
+	ReturnValue    string                 `json:"returnValue"`
+	Functions      []wavm.FunctionProfile `json:"functions"`
+	GasFlamegraph  string                 `json:"gasFlamegraph"`
+	TimeFlamegraph string                 `json:"timeFlamegraph"`
+}
+
+// ProfileTransaction re-executes the transaction and returns the gas and time
+// spent in each function of the contracts.
+func (api *PrivateDebugAPI) ProfileTransaction(ctx context.Context, hash common.Hash, config *TraceConfig) (*ProfileResult, error) {
+	tx, blockHash, _, index := rawdb.ReadTransaction(api.vnt.ChainDb(), hash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %x not found", hash)
+	}
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	msg, vmctx, statedb, err := api.computeTxEnv(blockHash, int(index), reexec)
+	if err != nil {
+		return nil, err
+	}
+	profiler := wavm.NewProfiler()
+	vmenv := core.GetVM(msg, vmctx, statedb, api.config, vm.Config{Debug: true, Tracer: profiler})
+	return profileMessage(ctx, msg, vmenv, profiler)
+}
+
+// ProfileCall executes the call on the state of the given block, and returns
+// the gas and time spent in each function of the contracts.
+func (api *PrivateDebugAPI) ProfileCall(ctx context.Context, args vntapi.CallArgs, blockNr rpc.BlockNumber) (*ProfileResult, error) {
+	statedb, header, err := api.vnt.APIBackend.StateAndHeaderByNumber(ctx, blockNr)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	gas := uint64(args.Gas)
+	if gas == 0 {
+		gas = math.MaxUint64 / 2
+	}
+	msg := types.NewMessage(args.From, args.To, 0, args.Value.ToInt(), gas, args.GasPrice.ToInt(), args.Data, false)
+	profiler := wavm.NewProfiler()
+	vmenv, _, err := api.vnt.APIBackend.GetVM(ctx, msg, statedb, header, vm.Config{Debug: true, Tracer: profiler})
+	if err != nil {
+		return nil, err
+	}
+	return profileMessage(ctx, msg, vmenv, profiler)
+}
+
+// profileMessage executes the message in the VM with the profiler.
+func profileMessage(ctx context.Context, message core.Message, vmenv vm.VM, profiler *wavm.Profiler) (*ProfileResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTraceTimeout)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		vmenv.Cancel()
+	}()
+	ret, gas, failed, err := core.ApplyMessage(vmenv, message, new(core.GasPool).AddGas(message.Gas()))
+	if err != nil {
+		return nil, fmt.Errorf("profiling failed: %v", err)
+	}
+	return &ProfileResult{
+		Gas:            gas,
+		Failed:         failed,
+		ReturnValue:    fmt.Sprintf("%x", ret),
+		Functions:      profiler.Functions(),
+		GasFlamegraph:  profiler.GasFlamegraph(),
+		TimeFlamegraph: profiler.TimeFlamegraph(),
+	}, nil
+}
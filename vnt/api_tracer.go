@@ -39,6 +39,7 @@ import (
 	"github.com/vntchain/go-vnt/rlp"
 	"github.com/vntchain/go-vnt/rpc"
 	"github.com/vntchain/go-vnt/trie"
+	"github.com/vntchain/go-vnt/vnt/tracers"
 )
 
 const (
@@ -55,7 +56,7 @@ const (
 // TraceConfig holds extra parameters to trace functions.
 type TraceConfig struct {
 	*vm.LogConfig
-	Tracer  *string
+	Tracer  *string // name of a registered tracer, e.g. callTracer and prestateTracer
 	Timeout *string
 	Reexec  *uint64
 }
@@ -566,6 +567,11 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 	case config == nil:
 		tracer = wavm.NewWasmLogger(nil)
 
+	case config.Tracer != nil:
+		if tracer, err = tracers.New(*config.Tracer, vmctx, statedb); err != nil {
+			return nil, err
+		}
+
 	default:
 		tracer = wavm.NewWasmLogger(config.LogConfig)
 	}
@@ -587,6 +593,10 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 			StructLogs:  slogs,
 			DebugLogs:   dlogs,
 		}, nil
+
+	case tracers.Tracer:
+		return tracer.GetResult()
+
 	default:
 		panic(fmt.Sprintf("bad tracer type %T", tracer))
 	}
@@ -17,7 +17,6 @@
 package vnt
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -56,8 +55,27 @@ const (
 // not compatible (low protocol version restrictions and high requirements).
 var errIncompatibleConfig = errors.New("incompatible configuration")
 
+// protocolError is the error of the message breaching the protocol.
+type protocolError struct {
+	code errCode
+	msg  string
+}
+
+func (e *protocolError) Error() string {
+	return fmt.Sprintf("%v - %v", e.code, e.msg)
+}
+
 func errResp(code errCode, format string, v ...interface{}) error {
-	return fmt.Errorf("%v - %v", code, fmt.Sprintf(format, v...))
+	return &protocolError{code: code, msg: fmt.Sprintf(format, v...)}
+}
+
+// protocolErrorEvents maps the protocol errors to the events lowering the
+// score of peer.
+var protocolErrorEvents = map[errCode]vntp2p.ScoreEvent{
+	ErrMsgTooLarge:    vntp2p.ScoreOversizedMsg,
+	ErrDecode:         vntp2p.ScoreDecodeError,
+	ErrInvalidMsgCode: vntp2p.ScoreDecodeError,
+	ErrInvalidBftMsg:  vntp2p.ScoreInvalidBftMsg,
 }
 
 type ProtocolManager struct {
@@ -83,7 +101,8 @@ type ProtocolManager struct {
 	txsSub           event.Subscription
 	producedBlockSub *event.TypeMuxSubscription
 	bftMsgSub        *event.TypeMuxSubscription
-	bftPeerSub       *event.TypeMuxSubscription
+	chainHeadCh      chan core.ChainHeadEvent
+	chainHeadSub     event.Subscription
 
 	// channels for fetcher, syncer, txsyncLoop
 	newPeerCh   chan *peer
@@ -94,8 +113,6 @@ type ProtocolManager struct {
 	// wait group is used for graceful shutdowns during downloading
 	// and processing
 	wg sync.WaitGroup
-
-	urlsCh chan []string // 传递p2p urls of witnesses
 }
 
 // NewProtocolManager returns a new VNT sub protocol manager. The VNT sub protocol manages peers capable
@@ -114,8 +131,6 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		txsyncCh:    make(chan *txsync),
 		quitSync:    make(chan struct{}),
 		node:        node,
-
-		urlsCh: make(chan []string),
 	}
 	// Figure out whether to allow fast sync or not
 	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > 0 {
@@ -135,9 +150,10 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		// Compatible; initialise the sub-protocol
 		version := version // Closure for the run
 		manager.SubProtocols = append(manager.SubProtocols, vntp2p.Protocol{
-			Name:    ProtocolName,
-			Version: version,
-			Length:  ProtocolLengths[i],
+			Name:       ProtocolName,
+			Version:    version,
+			Length:     ProtocolLengths[i],
+			MaxMsgSize: ProtocolMaxMsgSize,
 			Run: func(p *vntp2p.Peer, rw vntp2p.MsgReadWriter) error {
 				peer := manager.newPeer(int(version), p, rw)
 				select {
@@ -164,7 +180,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		return nil, errIncompatibleConfig
 	}
 	// Construct the different synchronisation mechanisms
-	manager.downloader = downloader.New(mode, chaindb, manager.eventMux, blockchain, nil, manager.removePeer)
+	manager.downloader = downloader.New(mode, chaindb, manager.eventMux, blockchain, nil, manager.dropStallingPeer)
 
 	validator := func(header *types.Header) error {
 		return engine.VerifyHeader(blockchain, header, true)
@@ -181,7 +197,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		atomic.StoreUint32(&manager.acceptTxs, 1) // Mark initial sync done on any fetcher import
 		return manager.blockchain.InsertChain(blocks)
 	}
-	manager.fetcher = fetcher.New(blockchain.GetBlockByHash, validator, manager.BroadcastBlock, heighter, inserter, manager.removePeer)
+	manager.fetcher = fetcher.New(blockchain.GetBlockByHash, validator, manager.BroadcastBlock, heighter, inserter, manager.dropInvalidBlockPeer)
 
 	return manager, nil
 }
@@ -205,34 +221,22 @@ func (pm *ProtocolManager) removePeer(id libp2p.ID) {
 	}
 }
 
-// resetBftPeer update current bft peer connection. If node not has connection
-// will them, will connecting to them. url format is:
-// /ip4/192.168.102.2/tcp/5216/ipfs/1kHBzN17vVE75rwZA7vKAFfxUYS8XMh6QBYS6JWF13xHGX9
-func (pm *ProtocolManager) resetBftPeer(urls []string) {
-	pm.peers.lock.Lock()
-	defer pm.peers.lock.Unlock()
-
-	// Clean old records
-	pm.peers.bftPeers = make(map[libp2p.ID]struct{})
-
-	// Add new records, and addPeer if not connect
-	selfID := pm.node.Server().NodeInfo().ID
-	for _, url := range urls {
-		node, err := vntp2p.ParseNode(url)
-		if err != nil {
-			log.Error("resetBftPeer invalid vnode:", "error", err)
-			continue
-		}
-		if node.Id.ToString() == selfID {
-			continue
-		}
+// dropStallingPeer lowers the score of the peer stalling or failing the
+// synchronisation, and removes it.
+func (pm *ProtocolManager) dropStallingPeer(id libp2p.ID) {
+	if peer := pm.peers.Peer(id); peer != nil {
+		peer.Report(vntp2p.ScoreTimeout)
+	}
+	pm.removePeer(id)
+}
 
-		pm.peers.bftPeers[node.Id] = struct{}{}
-		if _, exists := pm.peers.peers[node.Id]; !exists {
-			log.Debug("Reset bft peer, connecting to", "peer", url)
-			go pm.node.Server().AddPeer(context.Background(), node)
-		}
+// dropInvalidBlockPeer lowers the score of the peer propagating invalid
+// blocks, and removes it.
+func (pm *ProtocolManager) dropInvalidBlockPeer(id libp2p.ID) {
+	if peer := pm.peers.Peer(id); peer != nil {
+		peer.Report(vntp2p.ScoreInvalidBlock)
 	}
+	pm.removePeer(id)
 }
 
 func (pm *ProtocolManager) Start(maxPeers int) {
@@ -246,16 +250,17 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 	// broadcast produced blocks
 	pm.producedBlockSub = pm.eventMux.Subscribe(core.NewProducedBlockEvent{})
 	pm.bftMsgSub = pm.eventMux.Subscribe(core.SendBftMsgEvent{})
-	pm.bftPeerSub = pm.eventMux.Subscribe(core.BftPeerChangeEvent{})
 	go pm.producedBroadcastLoop()
 	go pm.bftBroadcastLoop()
 
-	go pm.resetBftPeerLoop()
+	// maintain the connections among witnesses
+	pm.chainHeadCh = make(chan core.ChainHeadEvent, chainHeadChanSize)
+	pm.chainHeadSub = pm.blockchain.SubscribeChainHeadEvent(pm.chainHeadCh)
+	go pm.witnessLoop()
 
 	// start sync handlers
 	go pm.syncer()
 	go pm.txsyncLoop()
-	go pm.bftPeerLoop()
 }
 
 func (pm *ProtocolManager) Stop() {
@@ -264,9 +269,7 @@ func (pm *ProtocolManager) Stop() {
 	pm.txsSub.Unsubscribe()           // quits txBroadcastLoop
 	pm.producedBlockSub.Unsubscribe() // quits blockBroadcastLoop
 	pm.bftMsgSub.Unsubscribe()
-	pm.bftPeerSub.Unsubscribe()
-
-	close(pm.urlsCh)
+	pm.chainHeadSub.Unsubscribe() // quits witnessLoop
 
 	// Quit the sync loop.
 	// After this send has completed, no new peers will be accepted.
@@ -336,6 +339,11 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	for {
 		if err := pm.handleMsg(p); err != nil {
 			p.Log().Debug("VNT message handling failed", "err", err)
+			if e, ok := err.(*protocolError); ok {
+				if event, ok := protocolErrorEvents[e.code]; ok {
+					p.Report(event)
+				}
+			}
 			return err
 		}
 	}
@@ -637,7 +645,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	case msg.Body.Type == NewBlockMsg:
 		// This message is forbid. The peer is malicious and will be removed.
 		log.Info("Receive NewBlockMsg from", "peer", p.id)
-		pm.removePeer(p.id)
+		pm.dropInvalidBlockPeer(p.id)
 
 	case msg.Body.Type == TxMsg:
 		// Transactions arrived, make sure we have a valid and fresh chain to handle them
@@ -661,21 +669,28 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		bftMsg := types.PreprepareMsg{}
 		if err := msg.Decode(&bftMsg); err != nil {
 			log.Error("Decode bftMsg Error", "err", err)
-			return errResp(ErrDecode, "msg %v: %v", msg, err)
+			return errResp(ErrInvalidBftMsg, "msg %v: %v", msg, err)
 		}
 		pm.postRecBftEvent(&bftMsg)
 	case msg.Body.Type == BftPrepareMsg:
 		bftMsg := types.PrepareMsg{}
 		if err := msg.Decode(&bftMsg); err != nil {
 			log.Error("Decode bftMsg Error", "err", err)
-			return errResp(ErrDecode, "msg %v: %v", msg, err)
+			return errResp(ErrInvalidBftMsg, "msg %v: %v", msg, err)
 		}
 		pm.postRecBftEvent(&bftMsg)
 	case msg.Body.Type == BftCommitMsg:
 		bftMsg := types.CommitMsg{}
 		if err := msg.Decode(&bftMsg); err != nil {
 			log.Error("Decode bftMsg Error", "err", err)
-			return errResp(ErrDecode, "msg %v: %v", msg, err)
+			return errResp(ErrInvalidBftMsg, "msg %v: %v", msg, err)
+		}
+		pm.postRecBftEvent(&bftMsg)
+	case p.version >= vnt64 && msg.Body.Type == BftRoundChangeMsg:
+		bftMsg := types.RoundChangeMsg{}
+		if err := msg.Decode(&bftMsg); err != nil {
+			log.Error("Decode bftMsg Error", "err", err)
+			return errResp(ErrInvalidBftMsg, "msg %v: %v", msg, err)
 		}
 		pm.postRecBftEvent(&bftMsg)
 	default:
@@ -754,6 +769,10 @@ func (pm *ProtocolManager) BroadcastBftMsg(bftMsg types.BftMsg) {
 	log.Trace("BroadcastBftMsg", "type", bftMsg.BftType, "hash", bftMsg.Msg.Hash(), "number of bft peer", len(peers))
 
 	for _, p := range peers {
+		// Round change msg is only known by the peers since vnt/64
+		if bftMsg.BftType == types.BftRoundChangeMessage && p.version < vnt64 {
+			continue
+		}
 		// using goroutine for each peer for peer may connection
 		go func(p *peer) {
 			log.Trace("BroadcastBftMsg", "to peer", p.id.ToString())
@@ -803,17 +822,6 @@ func (pm *ProtocolManager) bftBroadcastLoop() {
 	}
 }
 
-func (pm *ProtocolManager) bftPeerLoop() {
-	for obj := range pm.bftPeerSub.Chan() {
-		switch ev := obj.Data.(type) {
-		case core.BftPeerChangeEvent:
-			log.Trace("Receive BftPeerChangeEvent")
-			pm.urlsCh <- ev.Urls
-			// pm.resetBftPeer(ev.Urls) // First propagate block to peers
-		}
-	}
-}
-
 // NodeInfo represents a short summary of the VNT sub-protocol metadata
 // known about the host peer.
 type NodeInfo struct {
@@ -835,12 +843,3 @@ func (pm *ProtocolManager) NodeInfo() *NodeInfo {
 		Head:       currentBlock.Hash(),
 	}
 }
-
-func (pm *ProtocolManager) resetBftPeerLoop() {
-	log.Debug("resetBftPeerLoop start")
-	defer log.Debug("resetBftPeerLoop exit")
-
-	for urls := range pm.urlsCh {
-		pm.resetBftPeer(urls)
-	}
-}
@@ -0,0 +1,139 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package vnt
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	libp2p "github.com/libp2p/go-libp2p-peer"
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/consensus/mock"
+	"github.com/vntchain/go-vnt/core"
+	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/core/vm"
+	"github.com/vntchain/go-vnt/event"
+	"github.com/vntchain/go-vnt/params"
+	"github.com/vntchain/go-vnt/vnt/downloader"
+	"github.com/vntchain/go-vnt/vntdb"
+	"github.com/vntchain/go-vnt/vntp2p"
+)
+
+// testTxPool is a transaction pool without any transaction.
+type testTxPool struct {
+	txFeed event.Feed
+}
+
+func (p *testTxPool) AddRemotes(txs []*types.Transaction) []error {
+	return make([]error, len(txs))
+}
+
+func (p *testTxPool) Pending() (map[common.Address]types.Transactions, error) {
+	return nil, nil
+}
+
+func (p *testTxPool) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
+	return p.txFeed.Subscribe(ch)
+}
+
+// newTestNode creates a protocol manager with a genesis only chain, and runs
+// its protocols by a p2p server listening on a random port.
+func newTestNode(t *testing.T) (*ProtocolManager, *event.TypeMux, *vntp2p.Server) {
+	var (
+		db     = vntdb.NewMemDatabase()
+		engine = mock.NewMock()
+		mux    = new(event.TypeMux)
+	)
+	(&core.Genesis{Config: params.TestChainConfig}).MustCommit(db)
+	blockchain, err := core.NewBlockChain(db, nil, params.TestChainConfig, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	pm, err := NewProtocolManager(params.TestChainConfig, downloader.FullSync, DefaultConfig.NetworkId, mux, &testTxPool{}, engine, blockchain, db, nil)
+	if err != nil {
+		t.Fatalf("failed to create protocol manager: %v", err)
+	}
+	pm.Start(10)
+
+	server := &vntp2p.Server{Config: vntp2p.Config{
+		MaxPeers:    10,
+		NoDiscovery: true,
+		ListenAddr:  ":0",
+		Protocols:   pm.SubProtocols,
+	}}
+	if err := server.Start(); err != nil {
+		pm.Stop()
+		t.Fatalf("failed to start p2p server: %v", err)
+	}
+	return pm, mux, server
+}
+
+// waitPeer waits until the peer is registered by the protocol manager.
+func waitPeer(t *testing.T, pm *ProtocolManager, id libp2p.ID) *peer {
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if p := pm.peers.Peer(id); p != nil {
+			return p
+		}
+	}
+	t.Fatalf("peer %v is not registered", id)
+	return nil
+}
+
+// Tests that the peers supporting vnt/64 negotiate it through the p2p server,
+// and the round change messages are delivered between them.
+func TestRoundChangeDelivery(t *testing.T) {
+	pmA, _, serverA := newTestNode(t)
+	defer serverA.Stop()
+	defer pmA.Stop()
+	pmB, muxB, serverB := newTestNode(t)
+	defer serverB.Stop()
+	defer pmB.Stop()
+
+	nodeA, nodeB := serverA.Self(), serverB.Self()
+	serverA.AddPeer(context.Background(), nodeB)
+
+	peerB := waitPeer(t, pmA, nodeB.Id)
+	peerA := waitPeer(t, pmB, nodeA.Id)
+	if peerB.version != vnt64 || peerA.version != vnt64 {
+		t.Fatalf("protocol version mismatch: have %d and %d, want %d", peerB.version, peerA.version, vnt64)
+	}
+
+	pmA.peers.lock.Lock()
+	pmA.peers.bftPeers = map[libp2p.ID]struct{}{nodeB.Id: {}}
+	pmA.peers.lock.Unlock()
+
+	sub := muxB.Subscribe(core.RecBftMsgEvent{})
+	defer sub.Unsubscribe()
+
+	msg := &types.RoundChangeMsg{Round: 2, BlockNumber: big.NewInt(1)}
+	pmA.BroadcastBftMsg(types.BftMsg{BftType: types.BftRoundChangeMessage, Msg: msg})
+
+	select {
+	case ev := <-sub.Chan():
+		bftMsg := ev.Data.(core.RecBftMsgEvent).BftMsg
+		if bftMsg.BftType != types.BftRoundChangeMessage {
+			t.Fatalf("message type mismatch: have %v, want %v", bftMsg.BftType, types.BftRoundChangeMessage)
+		}
+		if have := bftMsg.Msg.(*types.RoundChangeMsg); have.Hash() != msg.Hash() {
+			t.Errorf("round change message mismatch: have %v, want %v", have, msg)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("round change message is not delivered")
+	}
+}
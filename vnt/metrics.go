@@ -56,6 +56,12 @@ var (
 	miscOutTrafficMeter       = metrics.NewRegisteredMeter("vnt/misc/out/traffic", nil)
 )
 
+var (
+	witnessActiveGauge    = metrics.NewRegisteredGauge("vnt/witness/active", nil)    // 除本节点外的当前见证人数量
+	witnessConnectedGauge = metrics.NewRegisteredGauge("vnt/witness/connected", nil) // 已连接的见证人数量
+	witnessQuorumGauge    = metrics.NewRegisteredGauge("vnt/witness/quorum", nil)    // 已连接的见证人是否达到BFT法定人数
+)
+
 // meteredMsgReadWriter is a wrapper around a p2p.MsgReadWriter, capable of
 // accumulating the above defined metrics based on the data stream contents.
 type meteredMsgReadWriter struct {
@@ -278,6 +278,8 @@ func (p *peer) SendBftMsg(bftMsg types.BftMsg) error {
 		msgType = BftPrepareMsg
 	case types.BftCommitMessage:
 		msgType = BftCommitMsg
+	case types.BftRoundChangeMessage:
+		msgType = BftRoundChangeMsg
 	}
 	return vntp2p.Send(p.rw, ProtocolName, msgType, bftMsg.Msg)
 }
@@ -513,6 +515,19 @@ func (ps *peerSet) PeersForBft() []*peer {
 	return list
 }
 
+// bftConnectivity returns the number of witnesses and the connected ones.
+func (ps *peerSet) bftConnectivity() (active int, connected int) {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	for p := range ps.bftPeers {
+		if _, exists := ps.peers[p]; exists {
+			connected++
+		}
+	}
+	return len(ps.bftPeers), connected
+}
+
 // BestPeer retrieves the known peer with the currently highest total difficulty.
 func (ps *peerSet) BestPeer() *peer {
 	ps.lock.RLock()
@@ -32,16 +32,17 @@ import (
 const (
 	vnt62 = 62
 	vnt63 = 63
+	vnt64 = 64
 )
 
 // ProtocolName is the official short name of the protocol used during capability negotiation.
 var ProtocolName = "vnt"
 
 // ProtocolVersions are the upported versions of the vnt protocol (first is primary).
-var ProtocolVersions = []uint{vnt63, vnt62}
+var ProtocolVersions = []uint{vnt64, vnt63, vnt62}
 
 // ProtocolLengths are the number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{20, 8}
+var ProtocolLengths = []uint64{21, 20, 8}
 
 const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -65,6 +66,9 @@ const (
 	BftPreprepareMsg = 0x11
 	BftPrepareMsg    = 0x12
 	BftCommitMsg     = 0x13
+
+	// Protocol messages belonging to vnt/64
+	BftRoundChangeMsg = 0x14
 )
 
 type errCode int
@@ -79,6 +83,7 @@ const (
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
 	ErrSuspendedPeer
+	ErrInvalidBftMsg
 )
 
 func (e errCode) String() string {
@@ -96,6 +101,7 @@ var errorToString = map[int]string{
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
 	ErrSuspendedPeer:           "Suspended peer",
+	ErrInvalidBftMsg:           "Invalid bft message",
 }
 
 type txPool interface {
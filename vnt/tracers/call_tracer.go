@@ -0,0 +1,153 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/common/hexutil"
+	"github.com/vntchain/go-vnt/core/state"
+	"github.com/vntchain/go-vnt/core/vm"
+	"github.com/vntchain/go-vnt/core/vm/election"
+	inter "github.com/vntchain/go-vnt/core/vm/interface"
+)
+
+var (
+	errIncompleteTrace = errors.New("incomplete trace")
+
+	electionAddress = common.BytesToAddress([]byte{9})
+)
+
+// callFrame is a call or contract creation in the call tree.
+type callFrame struct {
+	Type    string         `json:"type"`
+	From    common.Address `json:"from"`
+	To      common.Address `json:"to"`
+	Value   *hexutil.Big   `json:"value,omitempty"`
+	Gas     hexutil.Uint64 `json:"gas"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Input   hexutil.Bytes  `json:"input"`
+	Output  hexutil.Bytes  `json:"output,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Method  string         `json:"method,omitempty"` // method name of the election contract
+	Calls   []*callFrame   `json:"calls,omitempty"`
+}
+
+func newCallFrame(typ string, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) *callFrame {
+	frame := &callFrame{
+		Type:  typ,
+		From:  from,
+		To:    to,
+		Gas:   hexutil.Uint64(gas),
+		Input: common.CopyBytes(input),
+	}
+	if value != nil {
+		frame.Value = (*hexutil.Big)(new(big.Int).Set(value))
+	}
+	if to == electionAddress && len(input) >= 4 {
+		if electionABI, err := election.GetElectionABI(); err == nil {
+			if method, err := electionABI.MethodById(input[:4]); err == nil {
+				frame.Method = method.Name
+			}
+		}
+	}
+	return frame
+}
+
+func (f *callFrame) finish(output []byte, gasUsed uint64, err error) {
+	f.GasUsed = hexutil.Uint64(gasUsed)
+	f.Output = common.CopyBytes(output)
+	if err != nil {
+		f.Error = err.Error()
+	}
+}
+
+// callTracer reports the tree of the calls made during the execution,
+// including the nested contract calls, value transfers and the calls to the
+// election contract.
+type callTracer struct {
+	root  *callFrame
+	stack []*callFrame
+}
+
+func newCallTracer(vmctx vm.Context, statedb *state.StateDB) Tracer {
+	return &callTracer{}
+}
+
+func (t *callTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	typ := "CALL"
+	if create {
+		typ = "CREATE"
+	}
+	t.root = newCallFrame(typ, from, to, input, gas, value)
+	t.stack = []*callFrame{t.root}
+	return nil
+}
+
+func (t *callTracer) CaptureState(env vm.VM, pc uint64, op vm.OPCode, gas, cost uint64, contract inter.Contract, depth int, err error) error {
+	return nil
+}
+
+func (t *callTracer) CaptureLog(env vm.VM, msg string) error {
+	return nil
+}
+
+func (t *callTracer) CaptureFault(env vm.VM, pc uint64, op vm.OPCode, gas, cost uint64, contract inter.Contract, depth int, err error) error {
+	return nil
+}
+
+func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	if t.root == nil {
+		return errIncompleteTrace
+	}
+	t.root.finish(output, gasUsed, err)
+	return nil
+}
+
+// CaptureEnter implements vm.CallTracer, adding a nested call to the current frame.
+func (t *callTracer) CaptureEnter(typ string, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) error {
+	if len(t.stack) == 0 {
+		return errIncompleteTrace
+	}
+	frame := newCallFrame(typ, from, to, input, gas, value)
+	parent := t.stack[len(t.stack)-1]
+	parent.Calls = append(parent.Calls, frame)
+	t.stack = append(t.stack, frame)
+	return nil
+}
+
+// CaptureExit implements vm.CallTracer, finishing the current frame.
+func (t *callTracer) CaptureExit(output []byte, gasUsed uint64, err error) error {
+	// The root frame is finished by CaptureEnd
+	if len(t.stack) <= 1 {
+		return errIncompleteTrace
+	}
+	t.stack[len(t.stack)-1].finish(output, gasUsed, err)
+	t.stack = t.stack[:len(t.stack)-1]
+	return nil
+}
+
+func (t *callTracer) GetResult() (json.RawMessage, error) {
+	if t.root == nil {
+		return nil, errIncompleteTrace
+	}
+	return json.Marshal(t.root)
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/common/hexutil"
+	"github.com/vntchain/go-vnt/core/state"
+	"github.com/vntchain/go-vnt/core/vm"
+	inter "github.com/vntchain/go-vnt/core/vm/interface"
+)
+
+// prestateAccount is the state of an account before the execution.
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   uint64                      `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// prestateTracer records every account and storage slot touched during the
+// execution, with the values before the execution.
+type prestateTracer struct {
+	statedb  *state.StateDB // copy of the state before the execution
+	coinbase common.Address
+	prestate map[common.Address]*prestateAccount
+}
+
+func newPrestateTracer(vmctx vm.Context, statedb *state.StateDB) Tracer {
+	return &prestateTracer{
+		statedb:  statedb.Copy(),
+		coinbase: vmctx.Coinbase,
+		prestate: make(map[common.Address]*prestateAccount),
+	}
+}
+
+func (t *prestateTracer) lookupAccount(addr common.Address) *prestateAccount {
+	if account, ok := t.prestate[addr]; ok {
+		return account
+	}
+	account := &prestateAccount{
+		Balance: (*hexutil.Big)(new(big.Int).Set(t.statedb.GetBalance(addr))),
+		Nonce:   t.statedb.GetNonce(addr),
+		Code:    common.CopyBytes(t.statedb.GetCode(addr)),
+	}
+	t.prestate[addr] = account
+	return account
+}
+
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	account := t.lookupAccount(addr)
+	if account.Storage == nil {
+		account.Storage = make(map[common.Hash]common.Hash)
+	}
+	if _, ok := account.Storage[key]; !ok {
+		account.Storage[key] = t.statedb.GetState(addr, key)
+	}
+}
+
+func (t *prestateTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.lookupAccount(from)
+	t.lookupAccount(to)
+	t.lookupAccount(t.coinbase)
+	return nil
+}
+
+func (t *prestateTracer) CaptureState(env vm.VM, pc uint64, op vm.OPCode, gas, cost uint64, contract inter.Contract, depth int, err error) error {
+	return nil
+}
+
+func (t *prestateTracer) CaptureLog(env vm.VM, msg string) error {
+	return nil
+}
+
+func (t *prestateTracer) CaptureFault(env vm.VM, pc uint64, op vm.OPCode, gas, cost uint64, contract inter.Contract, depth int, err error) error {
+	return nil
+}
+
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// CaptureAccount implements vm.StateTracer.
+func (t *prestateTracer) CaptureAccount(env vm.VM, addr common.Address) error {
+	t.lookupAccount(addr)
+	return nil
+}
+
+// CaptureStorage implements vm.StateTracer.
+func (t *prestateTracer) CaptureStorage(env vm.VM, addr common.Address, key common.Hash) error {
+	t.lookupStorage(addr, key)
+	return nil
+}
+
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	return json.Marshal(t.prestate)
+}
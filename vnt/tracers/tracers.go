@@ -0,0 +1,89 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package tracers is a collection of named structured tracers which can be
+// selected by the debug_trace* RPC methods.
+package tracers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/vntchain/go-vnt/core/state"
+	"github.com/vntchain/go-vnt/core/vm"
+)
+
+// Tracer is a vm.Tracer which produces a JSON result after the execution.
+type Tracer interface {
+	vm.Tracer
+
+	// GetResult returns the JSON encoded result of the trace.
+	GetResult() (json.RawMessage, error)
+}
+
+// Constructor creates a new tracer for a transaction executed in the given
+// context on top of the given state. The state is the one before the execution,
+// tracers must not modify it.
+type Constructor func(vmctx vm.Context, statedb *state.StateDB) Tracer
+
+var (
+	lock     sync.RWMutex
+	registry = make(map[string]Constructor)
+)
+
+func init() {
+	Register("callTracer", newCallTracer)
+	Register("prestateTracer", newPrestateTracer)
+}
+
+// Register makes a tracer available by the provided name. It panics if the
+// name is already registered.
+func Register(name string, ctor Constructor) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("tracer %s is already registered", name))
+	}
+	registry[name] = ctor
+}
+
+// New creates a tracer by the registered name.
+func New(name string, vmctx vm.Context, statedb *state.StateDB) (Tracer, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	ctor, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("tracer %s not found", name)
+	}
+	return ctor(vmctx, statedb), nil
+}
+
+// Names returns the sorted names of all the registered tracers.
+func Names() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,189 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core"
+	"github.com/vntchain/go-vnt/core/state"
+	"github.com/vntchain/go-vnt/core/vm"
+	"github.com/vntchain/go-vnt/core/vm/election"
+	"github.com/vntchain/go-vnt/core/wavm"
+	"github.com/vntchain/go-vnt/params"
+	"github.com/vntchain/go-vnt/vntdb"
+)
+
+var (
+	addrA = common.HexToAddress("0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+	addrB = common.HexToAddress("0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+	addrC = common.HexToAddress("0xcccccccccccccccccccccccccccccccccccccccc")
+)
+
+func newTestState() *state.StateDB {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(vntdb.NewMemDatabase()))
+	return statedb
+}
+
+func TestRegistry(t *testing.T) {
+	if names := Names(); !reflect.DeepEqual(names, []string{"callTracer", "prestateTracer"}) {
+		t.Errorf("registered tracers mismatch, got %v", names)
+	}
+	if _, err := New("unknownTracer", vm.Context{}, newTestState()); err == nil {
+		t.Error("unknown tracer should not be created")
+	}
+}
+
+func TestCallTracer(t *testing.T) {
+	tracer, err := New("callTracer", vm.Context{}, newTestState())
+	if err != nil {
+		t.Fatal(err)
+	}
+	callTracer := tracer.(vm.CallTracer)
+
+	electionABI, _ := election.GetElectionABI()
+	voteInput, _ := election.PackInput(electionABI, "voteWitnesses", []common.Address{addrA})
+
+	tracer.CaptureStart(addrA, addrB, false, []byte{0x01}, 100000, big.NewInt(0))
+	callTracer.CaptureEnter("CALL", addrB, addrC, []byte{0x02}, 50000, big.NewInt(10))
+	callTracer.CaptureExit(nil, 1000, vm.ErrExecutionReverted)
+	callTracer.CaptureEnter("CALL", addrB, electionAddress, voteInput, 50000, big.NewInt(0))
+	callTracer.CaptureExit([]byte{0x03}, 2000, nil)
+	tracer.CaptureEnd([]byte{0x04}, 30000, 0, nil)
+
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var root callFrame
+	if err := json.Unmarshal(res, &root); err != nil {
+		t.Fatal(err)
+	}
+	if root.Type != "CALL" || root.From != addrA || root.To != addrB || uint64(root.GasUsed) != 30000 {
+		t.Errorf("root frame mismatch: %s", res)
+	}
+	if len(root.Calls) != 2 {
+		t.Fatalf("nested calls mismatch, got %d, want 2", len(root.Calls))
+	}
+	if call := root.Calls[0]; call.To != addrC || call.Value.ToInt().Int64() != 10 || call.Error != vm.ErrExecutionReverted.Error() {
+		t.Errorf("reverted transfer mismatch: %s", res)
+	}
+	if call := root.Calls[1]; call.To != electionAddress || call.Method != "voteWitnesses" || call.Error != "" {
+		t.Errorf("election call mismatch: %s", res)
+	}
+}
+
+func TestPrestateTracer(t *testing.T) {
+	statedb := newTestState()
+	statedb.SetBalance(addrA, big.NewInt(100))
+	statedb.SetNonce(addrA, 1)
+	statedb.SetCode(addrB, []byte{0x01, 0x02})
+	statedb.SetState(addrB, common.HexToHash("0x01"), common.HexToHash("0x11"))
+
+	tracer, err := New("prestateTracer", vm.Context{Coinbase: addrC}, statedb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateTracer := tracer.(vm.StateTracer)
+
+	tracer.CaptureStart(addrA, addrB, false, nil, 100000, big.NewInt(0))
+	// Modifications after the tracer is created are not reported
+	statedb.SetBalance(addrA, big.NewInt(50))
+	statedb.SetState(addrB, common.HexToHash("0x01"), common.HexToHash("0x22"))
+	stateTracer.CaptureStorage(nil, addrB, common.HexToHash("0x01"))
+	stateTracer.CaptureStorage(nil, addrB, common.HexToHash("0x02"))
+	tracer.CaptureEnd(nil, 30000, 0, nil)
+
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prestate map[common.Address]*prestateAccount
+	if err := json.Unmarshal(res, &prestate); err != nil {
+		t.Fatal(err)
+	}
+	if len(prestate) != 3 {
+		t.Errorf("touched accounts mismatch, got %d, want 3", len(prestate))
+	}
+	if a := prestate[addrA]; a == nil || a.Balance.ToInt().Int64() != 100 || a.Nonce != 1 {
+		t.Errorf("sender prestate mismatch: %s", res)
+	}
+	b := prestate[addrB]
+	if b == nil || len(b.Code) != 2 || len(b.Storage) != 2 || b.Storage[common.HexToHash("0x01")] != common.HexToHash("0x11") {
+		t.Errorf("contract prestate mismatch: %s", res)
+	}
+}
+
+func TestPrestateTracerElection(t *testing.T) {
+	statedb := newTestState()
+	statedb.SetBalance(addrA, new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18)))
+	electionABI, _ := election.GetElectionABI()
+	stakeInput, _ := election.PackInput(electionABI, "$stake")
+	stake := new(big.Int).Mul(big.NewInt(10), big.NewInt(1e18))
+
+	newWAVM := func(vmconfig vm.Config) vm.VM {
+		ctx := vm.Context{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			Origin:      addrA,
+			Coinbase:    addrC,
+			BlockNumber: big.NewInt(1),
+			Time:        big.NewInt(1546300800),
+		}
+		return wavm.NewWAVM(ctx, statedb, params.TestChainConfig, vmconfig)
+	}
+	if _, _, err := newWAVM(vm.Config{}).Call(vm.AccountRef(addrA), electionAddress, stakeInput, 100000, stake); err != nil {
+		t.Fatalf("stake error: %v", err)
+	}
+	pre := statedb.Copy()
+
+	// 再次抵押时记录抵押信息修改前的值
+	tracer, err := New("prestateTracer", vm.Context{Coinbase: addrC}, statedb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := newWAVM(vm.Config{Debug: true, Tracer: tracer}).Call(vm.AccountRef(addrA), electionAddress, stakeInput, 100000, stake); err != nil {
+		t.Fatalf("stake error: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prestate map[common.Address]*prestateAccount
+	if err := json.Unmarshal(res, &prestate); err != nil {
+		t.Fatal(err)
+	}
+	account := prestate[electionAddress]
+	if account == nil || len(account.Storage) == 0 {
+		t.Fatalf("election storage is not traced: %s", res)
+	}
+	if account.Balance.ToInt().Cmp(stake) != 0 || account.Nonce != pre.GetNonce(electionAddress) {
+		t.Errorf("election account prestate mismatch: %s", res)
+	}
+	for key, value := range account.Storage {
+		if want := pre.GetState(electionAddress, key); value != want {
+			t.Errorf("election storage %x mismatch, got %x, want %x", key, value, want)
+		}
+	}
+	if got := election.GetStake(pre, addrA); got == nil || got.Vnt.Cmp(stake) != 0 {
+		t.Errorf("stake before the trace mismatch: %v", got)
+	}
+}
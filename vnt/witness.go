@@ -0,0 +1,187 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package vnt
+
+import (
+	"context"
+	"time"
+
+	libp2p "github.com/libp2p/go-libp2p-peer"
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/core/vm/election"
+	"github.com/vntchain/go-vnt/log"
+	"github.com/vntchain/go-vnt/vntp2p"
+)
+
+// 见证人之间直接两两连接，组成传递BFT消息的覆盖网络。见证人列表来自链头区块的
+// Header.Witnesses，节点地址为候选人注册的Url，见证人列表变化时更新连接。
+// 连接只由witnessLoop根据链头更新。
+
+const (
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+
+	// witnessMetricsInterval is the interval of reporting the witness connectivity.
+	witnessMetricsInterval = 10 * time.Second
+)
+
+// resetBftPeer update current bft peer connection. If node not has connection
+// will them, will connecting to them. url format is:
+// /ip4/192.168.102.2/tcp/5216/ipfs/1kHBzN17vVE75rwZA7vKAFfxUYS8XMh6QBYS6JWF13xHGX9
+// The witnesses are kept connected by the p2p server, and reconnected if dropped.
+func (pm *ProtocolManager) resetBftPeer(urls []string) {
+	selfID := pm.node.Server().NodeInfo().ID
+	bftPeers := make(map[libp2p.ID]struct{})
+	nodes := make([]*vntp2p.Node, 0, len(urls))
+	for _, url := range urls {
+		node, err := vntp2p.ParseNode(url)
+		if err != nil {
+			log.Error("resetBftPeer invalid vnode:", "error", err)
+			continue
+		}
+		if node.Id.ToString() == selfID {
+			continue
+		}
+		if _, exists := bftPeers[node.Id]; exists {
+			continue
+		}
+		bftPeers[node.Id] = struct{}{}
+		nodes = append(nodes, node)
+	}
+
+	// Replace old records
+	pm.peers.lock.Lock()
+	pm.peers.bftPeers = bftPeers
+	pm.peers.lock.Unlock()
+
+	log.Debug("Reset bft peers", "count", len(nodes))
+	pm.node.Server().SetWitnesses(context.Background(), nodes)
+	pm.reportWitnessConnectivity()
+}
+
+// witnessLoop keeps the overlay network of witnesses with the witnesses list
+// of chain head, and reports the witness connectivity periodically. It's the
+// only one updating the witness connections.
+func (pm *ProtocolManager) witnessLoop() {
+	ticker := time.NewTicker(witnessMetricsInterval)
+	defer ticker.Stop()
+
+	// The witnesses list is retried on next chain head if failed
+	var current []common.Address
+	update := func(header *types.Header) {
+		if pm.node == nil || sameWitnesses(current, header.Witnesses) {
+			return
+		}
+		urls, err := pm.witnessUrls(header)
+		if err != nil {
+			log.Error("Get witness urls failed", "number", header.Number, "err", err)
+			return
+		}
+		current = header.Witnesses
+		pm.resetBftPeer(urls)
+	}
+	update(pm.blockchain.CurrentHeader())
+
+	for {
+		select {
+		case ev := <-pm.chainHeadCh:
+			update(ev.Block.Header())
+
+		case <-ticker.C:
+			pm.reportWitnessConnectivity()
+
+		// Err() channel will be closed when unsubscribing.
+		case <-pm.chainHeadSub.Err():
+			return
+		}
+	}
+}
+
+// witnessUrls returns the urls of the witnesses of header if this node is one
+// of them, otherwise returns nil as the node needs no witness connection.
+// The url of genesis witnesses not registered is from the chain config.
+func (pm *ProtocolManager) witnessUrls(header *types.Header) ([]string, error) {
+	stateDB, err := pm.blockchain.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	genesisUrls := make(map[common.Address]string)
+	if dpos := pm.chainconfig.Dpos; dpos != nil {
+		for i, witness := range pm.blockchain.Genesis().Header().Witnesses {
+			if i < len(dpos.WitnessesUrl) {
+				genesisUrls[witness] = dpos.WitnessesUrl[i]
+			}
+		}
+	}
+
+	selfID := pm.node.Server().NodeInfo().ID
+	isWitness := false
+	urls := make([]string, 0, len(header.Witnesses))
+	for _, witness := range header.Witnesses {
+		url := election.GetWitnessUrl(stateDB, witness)
+		if url == "" {
+			url = genesisUrls[witness]
+		}
+		node, err := vntp2p.ParseNode(url)
+		if err != nil {
+			log.Warn("Invalid witness url", "witness", witness.Hex(), "url", url, "err", err)
+			continue
+		}
+		if node.Id.ToString() == selfID {
+			isWitness = true
+		}
+		urls = append(urls, url)
+	}
+	if !isWitness {
+		return nil, nil
+	}
+	return urls, nil
+}
+
+// reportWitnessConnectivity updates the metrics of the connections to the
+// witnesses, and whether the witnesses connected reach the bft quorum.
+func (pm *ProtocolManager) reportWitnessConnectivity() {
+	active, connected := pm.peers.bftConnectivity()
+	witnessActiveGauge.Update(int64(active))
+	witnessConnectedGauge.Update(int64(connected))
+
+	reachable := int64(0)
+	if dpos := pm.chainconfig.Dpos; dpos != nil && active > 0 {
+		n := dpos.WitnessesNum
+		quorum := n - (n-1)/3
+		// 本节点也是见证人
+		if connected+1 >= quorum {
+			reachable = 1
+		} else {
+			log.Debug("Bft quorum is not reachable", "witnesses", active, "connected", connected, "quorum", quorum)
+		}
+	}
+	witnessQuorumGauge.Update(reachable)
+}
+
+func sameWitnesses(a, b []common.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
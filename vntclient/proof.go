@@ -0,0 +1,129 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package vntclient
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/common/hexutil"
+	"github.com/vntchain/go-vnt/core/state"
+	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/crypto"
+	"github.com/vntchain/go-vnt/rlp"
+	"github.com/vntchain/go-vnt/trie"
+	"github.com/vntchain/go-vnt/vntdb"
+)
+
+// AccountResult is the account with the merkle proof of it in the state trie,
+// and the storage values with the proofs in the storage trie of it.
+type AccountResult struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageResult `json:"storageProof"`
+}
+
+// StorageResult is the storage value of a key with the merkle proof of it.
+type StorageResult struct {
+	Key   common.Hash     `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// ProofAt returns the account and the storage values of keys with the merkle
+// proofs of them. The block number can be nil, in which case the latest known
+// block is used. The result should be checked by VerifyProof.
+func (ec *Client) ProofAt(ctx context.Context, account common.Address, keys []common.Hash, blockNumber *big.Int) (*AccountResult, error) {
+	storageKeys := make([]string, len(keys))
+	for i, key := range keys {
+		storageKeys[i] = key.Hex()
+	}
+	var res AccountResult
+	err := ec.c.CallContext(ctx, &res, "core_getProof", account, storageKeys, toBlockNumArg(blockNumber))
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// VerifyProof checks the account and storage values of res against the state
+// root of header. The header should be committed by BFT and verified before,
+// e.g. by a light client, then the values are proven without trusting the node.
+func VerifyProof(header *types.Header, res *AccountResult) error {
+	val, err := verifyTrieProof(header.Root, crypto.Keccak256(res.Address.Bytes()), res.AccountProof)
+	if err != nil {
+		return fmt.Errorf("invalid account proof: %v", err)
+	}
+	account := state.Account{Balance: new(big.Int), Root: types.EmptyRootHash, CodeHash: crypto.Keccak256(nil)}
+	if val != nil {
+		if err := rlp.DecodeBytes(val, &account); err != nil {
+			return fmt.Errorf("invalid account: %v", err)
+		}
+	}
+	if res.Balance == nil || account.Balance.Cmp(res.Balance.ToInt()) != 0 {
+		return fmt.Errorf("balance mismatch: have %v, want %v", res.Balance, account.Balance)
+	}
+	if account.Nonce != uint64(res.Nonce) {
+		return fmt.Errorf("nonce mismatch: have %d, want %d", res.Nonce, account.Nonce)
+	}
+	if account.Root != res.StorageHash {
+		return fmt.Errorf("storage hash mismatch: have %x, want %x", res.StorageHash, account.Root)
+	}
+	if common.BytesToHash(account.CodeHash) != res.CodeHash {
+		return fmt.Errorf("code hash mismatch: have %x, want %x", res.CodeHash, account.CodeHash)
+	}
+
+	for _, sr := range res.StorageProof {
+		val, err := verifyTrieProof(res.StorageHash, crypto.Keccak256(sr.Key.Bytes()), sr.Proof)
+		if err != nil {
+			return fmt.Errorf("invalid storage proof of %x: %v", sr.Key, err)
+		}
+		value := new(big.Int)
+		if val != nil {
+			_, content, _, err := rlp.Split(val)
+			if err != nil {
+				return fmt.Errorf("invalid storage value of %x: %v", sr.Key, err)
+			}
+			value.SetBytes(content)
+		}
+		if sr.Value == nil || value.Cmp(sr.Value.ToInt()) != 0 {
+			return fmt.Errorf("storage value of %x mismatch: have %v, want %v", sr.Key, sr.Value, value)
+		}
+	}
+	return nil
+}
+
+// verifyTrieProof returns the value of key proven by the proof nodes in the
+// trie of root, or nil if the absence of key is proven.
+func verifyTrieProof(root common.Hash, key []byte, proof []hexutil.Bytes) ([]byte, error) {
+	// Empty trie has no node to prove
+	if root == types.EmptyRootHash {
+		return nil, nil
+	}
+	db := vntdb.NewMemDatabase()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	val, _, err := trie.VerifyProof(root, key, db)
+	return val, err
+}
@@ -0,0 +1,142 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package vntclient
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/vntchain/go-vnt/common"
+	"github.com/vntchain/go-vnt/common/hexutil"
+	"github.com/vntchain/go-vnt/core/state"
+	"github.com/vntchain/go-vnt/core/types"
+	"github.com/vntchain/go-vnt/crypto"
+	"github.com/vntchain/go-vnt/vntdb"
+)
+
+// makeProof makes the result of core_getProof from statedb.
+func makeProof(t *testing.T, statedb *state.StateDB, addr common.Address, keys ...common.Hash) *AccountResult {
+	accountProof, err := statedb.GetProof(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := &AccountResult{
+		Address:      addr,
+		AccountProof: toHex(accountProof),
+		Balance:      (*hexutil.Big)(statedb.GetBalance(addr)),
+		CodeHash:     crypto.Keccak256Hash(nil),
+		Nonce:        hexutil.Uint64(statedb.GetNonce(addr)),
+		StorageHash:  types.EmptyRootHash,
+	}
+	storageTrie := statedb.StorageTrie(addr)
+	if storageTrie != nil {
+		res.CodeHash, res.StorageHash = statedb.GetCodeHash(addr), storageTrie.Hash()
+	}
+	for _, key := range keys {
+		sr := StorageResult{Key: key, Value: (*hexutil.Big)(statedb.GetState(addr, key).Big())}
+		if storageTrie != nil {
+			proof, err := statedb.GetStorageProof(addr, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sr.Proof = toHex(proof)
+		}
+		res.StorageProof = append(res.StorageProof, sr)
+	}
+
+	// Results are always sent by json
+	data, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec AccountResult
+	if err := json.Unmarshal(data, &dec); err != nil {
+		t.Fatal(err)
+	}
+	return &dec
+}
+
+func toHex(b [][]byte) []hexutil.Bytes {
+	r := make([]hexutil.Bytes, len(b))
+	for i := range b {
+		r[i] = b[i]
+	}
+	return r
+}
+
+func TestVerifyProof(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(vntdb.NewMemDatabase()))
+	var (
+		contract = common.HexToAddress("0x01")
+		user     = common.HexToAddress("0x02")
+		missing  = common.HexToAddress("0x03")
+		key1     = common.HexToHash("0x01")
+		key2     = common.HexToHash("0x02")
+		unset    = common.HexToHash("0x03")
+	)
+	statedb.SetBalance(contract, big.NewInt(100))
+	statedb.SetNonce(contract, 1)
+	statedb.SetCode(contract, []byte{0x01, 0x02})
+	statedb.SetState(contract, key1, common.HexToHash("0x2a"))
+	statedb.SetState(contract, key2, common.BytesToHash(crypto.Keccak256([]byte("value"))))
+	statedb.SetBalance(user, big.NewInt(7))
+	for i := int64(0); i < 20; i++ {
+		statedb.SetBalance(common.BigToAddress(big.NewInt(i+100)), big.NewInt(i))
+	}
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := &types.Header{Root: root}
+
+	for _, res := range []*AccountResult{
+		makeProof(t, statedb, contract, key1, key2, unset),
+		makeProof(t, statedb, user, key1),
+		makeProof(t, statedb, missing, key1),
+	} {
+		if err := VerifyProof(header, res); err != nil {
+			t.Errorf("verify proof of %x failed: %v", res.Address, err)
+		}
+	}
+
+	// Tampered results should be rejected
+	tampers := []func(res *AccountResult){
+		func(res *AccountResult) { res.Balance = (*hexutil.Big)(big.NewInt(101)) },
+		func(res *AccountResult) { res.Nonce = 2 },
+		func(res *AccountResult) { res.CodeHash = common.Hash{} },
+		func(res *AccountResult) { res.StorageHash = types.EmptyRootHash },
+		func(res *AccountResult) { res.Address = user },
+		func(res *AccountResult) { res.AccountProof = res.AccountProof[:1] },
+		func(res *AccountResult) { res.StorageProof[0].Value = (*hexutil.Big)(big.NewInt(43)) },
+		func(res *AccountResult) { res.StorageProof[2].Value = (*hexutil.Big)(big.NewInt(1)) },
+		func(res *AccountResult) { res.StorageProof[0].Key = key2 },
+		func(res *AccountResult) { res.StorageProof[1].Proof = nil },
+	}
+	for i, tamper := range tampers {
+		res := makeProof(t, statedb, contract, key1, key2, unset)
+		tamper(res)
+		if err := VerifyProof(header, res); err == nil {
+			t.Errorf("tamper %d: verify proof succeeded", i)
+		}
+	}
+	res := makeProof(t, statedb, missing)
+	res.Balance = (*hexutil.Big)(big.NewInt(1))
+	if err := VerifyProof(header, res); err == nil {
+		t.Errorf("verify balance of missing account succeeded")
+	}
+}
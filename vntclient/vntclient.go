@@ -538,8 +538,14 @@ func packInput(abiobj abi.ABI, name string, args ...interface{}) ([]byte, error)
 
 // StakeAt returns the stake information of the given account.
 func (ec *Client) StakeAt(ctx context.Context, account common.Address) (*rpc.Stake, error) {
+	return ec.StakeAtBlock(ctx, account, nil)
+}
+
+// StakeAtBlock returns the stake information of the given account at the given block.
+// The block number can be nil, in which case the stake is taken from the latest known block.
+func (ec *Client) StakeAtBlock(ctx context.Context, account common.Address, blockNumber *big.Int) (*rpc.Stake, error) {
 	var ret *rpc.Stake
-	err := ec.c.CallContext(ctx, &ret, "core_getStake", account)
+	err := ec.c.CallContext(ctx, &ret, "core_getStake", account, toBlockNumArg(blockNumber))
 	if err != nil {
 		return nil, err
 	} else if ret == nil {
@@ -550,8 +556,14 @@ func (ec *Client) StakeAt(ctx context.Context, account common.Address) (*rpc.Sta
 
 // VoteAt returns the vote information of the given account.
 func (ec *Client) VoteAt(ctx context.Context, account common.Address) (*rpc.Voter, error) {
+	return ec.VoteAtBlock(ctx, account, nil)
+}
+
+// VoteAtBlock returns the vote information of the given account at the given block.
+// The block number can be nil, in which case the vote is taken from the latest known block.
+func (ec *Client) VoteAtBlock(ctx context.Context, account common.Address, blockNumber *big.Int) (*rpc.Voter, error) {
 	var ret *rpc.Voter
-	err := ec.c.CallContext(ctx, &ret, "core_getVoter", account)
+	err := ec.c.CallContext(ctx, &ret, "core_getVoter", account, toBlockNumArg(blockNumber))
 	if err != nil {
 		return nil, err
 	} else if ret == nil {
@@ -562,8 +574,14 @@ func (ec *Client) VoteAt(ctx context.Context, account common.Address) (*rpc.Vote
 
 // WitnessCandidates returns a list of witness candidates.
 func (ec *Client) WitnessCandidates(ctx context.Context) ([]rpc.Candidate, error) {
+	return ec.WitnessCandidatesAtBlock(ctx, nil)
+}
+
+// WitnessCandidatesAtBlock returns a list of witness candidates at the given block.
+// The block number can be nil, in which case the list is taken from the latest known block.
+func (ec *Client) WitnessCandidatesAtBlock(ctx context.Context, blockNumber *big.Int) ([]rpc.Candidate, error) {
 	var ret []rpc.Candidate
-	err := ec.c.CallContext(ctx, &ret, "core_getAllCandidates")
+	err := ec.c.CallContext(ctx, &ret, "core_getAllCandidates", toBlockNumArg(blockNumber))
 	if err != nil {
 		return nil, err
 	} else if ret == nil {
@@ -574,7 +592,26 @@ func (ec *Client) WitnessCandidates(ctx context.Context) ([]rpc.Candidate, error
 
 // RestVNTBounty return a integer of the left VNT bounty in wei.
 func (ec *Client) RestVNTBounty(ctx context.Context) (*big.Int, error) {
+	return ec.RestVNTBountyAtBlock(ctx, nil)
+}
+
+// RestVNTBountyAtBlock return a integer of the left VNT bounty in wei at the given block.
+// The block number can be nil, in which case the bounty is taken from the latest known block.
+func (ec *Client) RestVNTBountyAtBlock(ctx context.Context, blockNumber *big.Int) (*big.Int, error) {
 	var ret big.Int
-	err := ec.c.CallContext(ctx, &ret, "core_getRestVNTBounty")
+	err := ec.c.CallContext(ctx, &ret, "core_getRestVNTBounty", toBlockNumArg(blockNumber))
 	return &ret, err
 }
+
+// WitnessRewardsAt returns the witness list and the rewards granted in the given block.
+// The block number can be nil, in which case the latest known block is used.
+func (ec *Client) WitnessRewardsAt(ctx context.Context, blockNumber *big.Int) (*rpc.WitnessRewards, error) {
+	var ret *rpc.WitnessRewards
+	err := ec.c.CallContext(ctx, &ret, "dpos_getWitnessRewards", toBlockNumArg(blockNumber))
+	if err != nil {
+		return nil, err
+	} else if ret == nil {
+		return nil, hubble.NotFound
+	}
+	return ret, err
+}
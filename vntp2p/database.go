@@ -22,6 +22,7 @@ import (
 	ds "github.com/ipfs/go-datastore"
 	"github.com/ipfs/go-datastore/query"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/vntchain/go-vnt/common"
 )
 
 // LevelDB vntdb object
@@ -38,10 +39,11 @@ func GetDatastore(path string) (*LevelDB, error) {
 	if vntdb != nil && vntdb.path == path {
 		return vntdb, nil
 	}
-	vntdb, err := newDatastore(path)
+	db, err := newDatastore(path)
 	if err != nil {
 		return nil, err
 	}
+	vntdb = db
 	return vntdb, nil
 }
 
@@ -108,11 +110,16 @@ func (d *LevelDB) Delete(key ds.Key) (err error) {
 func (d *LevelDB) Query(q query.Query) (query.Results, error) {
 	var re []query.Entry
 	iter := d.db.NewIterator(nil, nil)
+	defer iter.Release()
 	for iter.Next() {
 		keyByte := iter.Key()
-		valueByte := iter.Value()
+		// 迭代器的值在下次迭代时会被覆盖，需要复制
+		valueByte := common.CopyBytes(iter.Value())
 		re = append(re, query.Entry{Key: string(keyByte), Value: valueByte})
 	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
 	r := query.ResultsWithEntries(q, re)
 	r = query.NaiveQueryApply(q, r)
 	return r, nil
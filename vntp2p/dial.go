@@ -29,6 +29,7 @@ import (
 var (
 	errAlreadyDialing   = errors.New("already dialing")
 	errAlreadyConnected = errors.New("already connected")
+	errBannedPeer       = errors.New("peer is banned")
 )
 
 type taskstate struct {
@@ -38,7 +39,10 @@ type taskstate struct {
 	lookupNode    []peer.ID
 	lookupRunning bool
 	static        map[peer.ID]*dialTask
+	trusted       map[peer.ID]*dialTask
+	witnesses     map[peer.ID]*dialTask
 	dialmap       map[peer.ID]dialFlag
+	reputation    *reputation
 }
 
 type task interface {
@@ -82,10 +86,13 @@ func (s *taskstate) newTasks(peers map[peer.ID]*Peer) []task {
 	}
 
 	// newtasks = append(newtasks, &dialTask{})
-	for id, task := range s.static {
-		if err := s.checkDial(id, peers); err == nil {
-			s.dialmap[id] = task.flag
-			newtasks = append(newtasks, task)
+	// 静态节点、可信节点和见证人节点断开后重新连接
+	for _, tasks := range []map[peer.ID]*dialTask{s.static, s.trusted, s.witnesses} {
+		for id, task := range tasks {
+			if err := s.checkDial(id, peers); err == nil {
+				s.dialmap[id] = task.flag
+				newtasks = append(newtasks, task)
+			}
 		}
 	}
 
@@ -149,6 +156,8 @@ func (s *taskstate) checkDial(n peer.ID, peers map[peer.ID]*Peer) error {
 		return errAlreadyDialing
 	case peers[n] != nil:
 		return errAlreadyConnected
+	case s.trusted[n] == nil && s.reputation != nil && s.reputation.isBanned(n, nil):
+		return errBannedPeer
 	}
 	return nil
 }
@@ -157,6 +166,22 @@ func (s *taskstate) removeStatic(n *Node) {
 	delete(s.static, n.Id)
 }
 
+func (s *taskstate) addTrusted(n *Node) {
+	s.trusted[n.Id] = &dialTask{flag: trustedDialedDail, target: n.Id, pid: PID}
+}
+
+func (s *taskstate) removeTrusted(n *Node) {
+	delete(s.trusted, n.Id)
+}
+
+// setWitnesses replaces the witnesses to keep connected with.
+func (s *taskstate) setWitnesses(nodes []*Node) {
+	s.witnesses = make(map[peer.ID]*dialTask, len(nodes))
+	for _, n := range nodes {
+		s.witnesses[n.Id] = &dialTask{flag: witnessDialedDail, target: n.Id, pid: PID}
+	}
+}
+
 func (s *taskstate) taskDone(t task) {
 	switch t := t.(type) {
 	case *dialTask:
@@ -171,13 +196,16 @@ func (s *taskstate) addStatic(n *Node) {
 	s.static[n.Id] = &dialTask{flag: staticDialedDail, target: n.Id, pid: PID}
 }
 
-func newTaskState(maxdial int, bootnodes []peer.ID, dht DhtTable) *taskstate {
+func newTaskState(maxdial int, bootnodes []peer.ID, dht DhtTable, rep *reputation) *taskstate {
 	s := &taskstate{
 		maxDynDials: maxdial,
 		bootnodes:   make([]peer.ID, len(bootnodes)),
 		dialmap:     make(map[peer.ID]dialFlag),
 		static:      make(map[peer.ID]*dialTask),
+		trusted:     make(map[peer.ID]*dialTask),
+		witnesses:   make(map[peer.ID]*dialTask),
 		table:       dht,
+		reputation:  rep,
 	}
 
 	copy(s.bootnodes, bootnodes)
@@ -0,0 +1,92 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package vntp2p
+
+import (
+	"testing"
+	"time"
+
+	peer "github.com/libp2p/go-libp2p-peer"
+)
+
+// dialTargets returns the targets of the dial tasks, and finishes the tasks.
+func dialTargets(s *taskstate, peers map[peer.ID]*Peer) map[peer.ID]dialFlag {
+	targets := make(map[peer.ID]dialFlag)
+	for _, t := range s.newTasks(peers) {
+		if t, ok := t.(*dialTask); ok {
+			targets[t.target] = t.flag
+		}
+		s.taskDone(t)
+	}
+	return targets
+}
+
+func TestDialTrusted(t *testing.T) {
+	now := time.Now()
+	rep := newTestReputation(nil, &now)
+	s := newTaskState(0, nil, nil, rep)
+
+	trusted := &Node{Id: decodeTestID(t, testPeerID)}
+	static := &Node{Id: decodeTestID(t, testPeerID2)}
+	s.addTrusted(trusted)
+	s.addStatic(static)
+	for _, n := range []*Node{trusted, static} {
+		if err := rep.ban(&Ban{ID: n.Id, Expires: now.Add(time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 被禁止的可信节点仍然重连，静态节点不再连接
+	targets := dialTargets(s, nil)
+	if flag, ok := targets[trusted.Id]; !ok || flag != trustedDialedDail {
+		t.Errorf("trusted peer should be dialed, have %v", targets)
+	}
+	if _, ok := targets[static.Id]; ok {
+		t.Errorf("banned static peer should not be dialed")
+	}
+
+	// 已连接的可信节点不再重复连接
+	if targets := dialTargets(s, map[peer.ID]*Peer{trusted.Id: {}}); len(targets) != 0 {
+		t.Errorf("connected peer should not be dialed, have %v", targets)
+	}
+
+	s.removeTrusted(trusted)
+	if targets := dialTargets(s, nil); len(targets) != 0 {
+		t.Errorf("removed trusted peer should not be dialed, have %v", targets)
+	}
+}
+
+func TestDialWitnesses(t *testing.T) {
+	s := newTaskState(0, nil, nil, nil)
+	first := &Node{Id: decodeTestID(t, testPeerID)}
+	second := &Node{Id: decodeTestID(t, testPeerID2)}
+
+	s.setWitnesses([]*Node{first})
+	if targets := dialTargets(s, nil); len(targets) != 1 || targets[first.Id] != witnessDialedDail {
+		t.Errorf("witness should be dialed, have %v", targets)
+	}
+
+	// 见证人列表更新后只连接新的见证人
+	s.setWitnesses([]*Node{second})
+	if targets := dialTargets(s, nil); len(targets) != 1 || targets[second.Id] != witnessDialedDail {
+		t.Errorf("new witness should be dialed, have %v", targets)
+	}
+	s.setWitnesses(nil)
+	if targets := dialTargets(s, nil); len(targets) != 0 {
+		t.Errorf("no witness should be dialed, have %v", targets)
+	}
+}
@@ -0,0 +1,370 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package vntp2p
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/vntchain/go-vnt/rlp"
+)
+
+// 消息帧由5字节的消息头和消息体组成，消息头的前4字节为小端序的消息体长度，
+// 第5字节为消息帧的类型。旧版本节点的消息头第5字节总为0，消息体为json编码的MsgBody。
+//
+// 通过CompactPID建立的连接使用紧凑的二进制消息帧，避免json和base64编码的开销：
+//
+//	协议表: rlp([]string)，发送方的子协议名称列表，在发送第一个消息前发送
+//	消息:   flags | uvarint(协议序号) | uvarint(消息类型) | uvarint(载荷长度) | 载荷
+//
+// 协议序号为子协议在发送方协议表中的下标。载荷不小于compressThreshold时使用snappy
+// 压缩，flags标记载荷是否被压缩，载荷长度为压缩前的长度。
+//
+// 通过VersionedPID建立的连接同样使用紧凑的二进制消息帧，双方在任何消息之前先发送
+// 能力表rlp([]Cap)，即发送方支持的全部子协议名称及版本，每个子协议运行双方都支持
+// 的最高版本。
+const (
+	jsonFrame       byte = iota // json编码的MsgBody
+	compactFrame                // 紧凑二进制编码的消息
+	protoTableFrame             // 发送方的子协议列表
+	capsFrame                   // 发送方支持的子协议名称及版本
+)
+
+const (
+	// compressThreshold is the minimum payload size to be snappy compressed.
+	compressThreshold = 256
+
+	flagSnappy byte = 1 << 0
+
+	// compactOverhead is the maximum size of compact message body except the payload.
+	compactOverhead = 1 + 3*binary.MaxVarintLen64
+	// jsonOverhead is the size of json message body except the payload, which
+	// is enlarged by the base64 encoding and the list headers.
+	jsonOverhead = 4096
+	// maxCapsSize is the maximum size of the caps frame body.
+	maxCapsSize = 4096
+)
+
+var (
+	errUnknownFrame      = errors.New("unknown message frame")
+	errInvalidFrame      = errors.New("invalid compact message frame")
+	errUnknownProtoIndex = errors.New("unknown protocol index")
+	errMsgTooLarge       = errors.New("message too large")
+	errNoCaps            = errors.New("first frame is not caps")
+)
+
+func newMsgHeader(kind byte, bodySize int) MsgHeader {
+	var header MsgHeader
+	binary.LittleEndian.PutUint32(header[:], uint32(bodySize))
+	header[MessageHeaderLength-1] = kind
+	return header
+}
+
+// kind returns the frame kind of the message.
+func (h MsgHeader) kind() byte {
+	return h[MessageHeaderLength-1]
+}
+
+func encodeJSONFrame(body MsgBody) ([]byte, error) {
+	enc, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	header := newMsgHeader(jsonFrame, len(enc))
+	return append(header[:], enc...), nil
+}
+
+func encodeProtoTable(names []string) ([]byte, error) {
+	enc, err := rlp.EncodeToBytes(names)
+	if err != nil {
+		return nil, err
+	}
+	header := newMsgHeader(protoTableFrame, len(enc))
+	return append(header[:], enc...), nil
+}
+
+func encodeCaps(caps []Cap) ([]byte, error) {
+	enc, err := rlp.EncodeToBytes(caps)
+	if err != nil {
+		return nil, err
+	}
+	header := newMsgHeader(capsFrame, len(enc))
+	return append(header[:], enc...), nil
+}
+
+// readCaps reads the caps of the remote peer, which must be the first frame
+// on the streams of VersionedPID.
+func readCaps(r io.Reader) ([]Cap, error) {
+	var header MsgHeader
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("read caps header error: %v", err)
+	}
+	if header.kind() != capsFrame {
+		return nil, errNoCaps
+	}
+	size := binary.LittleEndian.Uint32(header[:])
+	if size > maxCapsSize {
+		return nil, errMsgTooLarge
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("read caps error: %v", err)
+	}
+	var caps []Cap
+	if err := rlp.DecodeBytes(body, &caps); err != nil {
+		return nil, fmt.Errorf("decode caps error: %v", err)
+	}
+	return caps, nil
+}
+
+func encodeCompactFrame(index uint64, msgType MessageType, payload []byte) []byte {
+	var flags byte
+	data := payload
+	if len(payload) >= compressThreshold {
+		if compressed := snappy.Encode(nil, payload); len(compressed) < len(payload) {
+			flags |= flagSnappy
+			data = compressed
+		}
+	}
+
+	buf := make([]byte, MessageHeaderLength+compactOverhead+len(data))
+	n := MessageHeaderLength
+	buf[n] = flags
+	n++
+	n += binary.PutUvarint(buf[n:], index)
+	n += binary.PutUvarint(buf[n:], uint64(msgType))
+	n += binary.PutUvarint(buf[n:], uint64(len(payload)))
+	n += copy(buf[n:], data)
+
+	header := newMsgHeader(compactFrame, n-MessageHeaderLength)
+	copy(buf, header[:])
+	return buf[:n]
+}
+
+func decodeJSONBody(body []byte) (*MsgBody, error) {
+	msgBody := &MsgBody{Payload: &rlp.EncReader{}}
+	if err := json.Unmarshal(body, msgBody); err != nil {
+		return nil, err
+	}
+	return msgBody, nil
+}
+
+// decodeCompactBody decodes the compact message body, the payload larger than
+// limit is rejected before decompressing.
+func decodeCompactBody(body []byte, protocols []string, limit func(string) uint32) (*MsgBody, error) {
+	if len(body) == 0 {
+		return nil, errInvalidFrame
+	}
+	flags := body[0]
+	r := bytes.NewReader(body[1:])
+	index, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, errInvalidFrame
+	}
+	msgType, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, errInvalidFrame
+	}
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, errInvalidFrame
+	}
+	if index >= uint64(len(protocols)) {
+		return nil, errUnknownProtoIndex
+	}
+	if size > uint64(limit(protocols[index])) {
+		return nil, errMsgTooLarge
+	}
+
+	data := body[len(body)-r.Len():]
+	if flags&flagSnappy != 0 {
+		if n, err := snappy.DecodedLen(data); err != nil || uint64(n) != size {
+			return nil, errInvalidFrame
+		}
+		if data, err = snappy.Decode(nil, data); err != nil {
+			return nil, err
+		}
+	}
+	if uint64(len(data)) != size {
+		return nil, errInvalidFrame
+	}
+
+	return &MsgBody{
+		ProtocolID:  protocols[index],
+		Type:        MessageType(msgType),
+		PayloadSize: uint32(size),
+		Payload:     bytes.NewReader(data),
+	}, nil
+}
+
+// frameReader reads the messages of both frame formats from a stream, and
+// keeps the protocol table sent by the remote peer. The size of messages is
+// checked before allocating the buffer for it.
+type frameReader struct {
+	r         io.Reader
+	protocols []string
+	limits    map[string]uint32 // 各子协议消息载荷的最大长度
+	maxSize   uint32            // 所有子协议中消息载荷的最大长度
+}
+
+func newFrameReader(r io.Reader, protocols []Protocol) *frameReader {
+	fr := &frameReader{
+		r:      r,
+		limits: make(map[string]uint32),
+	}
+	for _, proto := range protocols {
+		limit := proto.maxMsgSize()
+		fr.limits[proto.Name] = limit
+		if limit > fr.maxSize {
+			fr.maxSize = limit
+		}
+	}
+	if fr.maxSize == 0 {
+		fr.maxSize = DefaultMaxMsgSize
+	}
+	return fr
+}
+
+// limit returns the maximum payload size of the protocol.
+func (fr *frameReader) limit(protocol string) uint32 {
+	if limit, ok := fr.limits[protocol]; ok {
+		return limit
+	}
+	return DefaultMaxMsgSize
+}
+
+// bodyLimit returns the maximum body size of the frame kind.
+func (fr *frameReader) bodyLimit(kind byte) uint64 {
+	switch kind {
+	case jsonFrame:
+		return uint64(fr.maxSize)*2 + jsonOverhead
+	case compactFrame:
+		return uint64(fr.maxSize) + compactOverhead
+	default:
+		return uint64(fr.maxSize)
+	}
+}
+
+func (fr *frameReader) readMsg() (Msg, error) {
+	for {
+		var header MsgHeader
+		if _, err := io.ReadFull(fr.r, header[:]); err != nil {
+			return Msg{}, fmt.Errorf("read msg header error: %v", err)
+		}
+		size := binary.LittleEndian.Uint32(header[:])
+		if uint64(size) > fr.bodyLimit(header.kind()) {
+			return Msg{}, errMsgTooLarge
+		}
+		body := make([]byte, size)
+		if _, err := io.ReadFull(fr.r, body); err != nil {
+			return Msg{}, fmt.Errorf("read msg body error: %v", err)
+		}
+
+		var (
+			msgBody *MsgBody
+			err     error
+		)
+		switch header.kind() {
+		case jsonFrame:
+			if msgBody, err = decodeJSONBody(body); err == nil && msgBody.PayloadSize > fr.limit(msgBody.ProtocolID) {
+				return Msg{}, errMsgTooLarge
+			}
+		case compactFrame:
+			msgBody, err = decodeCompactBody(body, fr.protocols, fr.limit)
+		case protoTableFrame:
+			if err := rlp.DecodeBytes(body, &fr.protocols); err != nil {
+				return Msg{}, fmt.Errorf("decode protocol table error: %v", err)
+			}
+			continue
+		default:
+			err = errUnknownFrame
+		}
+		if err == errMsgTooLarge {
+			return Msg{}, err
+		}
+		if err != nil {
+			return Msg{}, fmt.Errorf("decode msg body error: %v", err)
+		}
+		msgBody.ReceivedAt = time.Now()
+		return Msg{Header: header, Body: *msgBody}, nil
+	}
+}
+
+// frameWriter encodes the messages of a peer in the frame format negotiated
+// on its stream. The protocol table is written before the first compact message.
+type frameWriter struct {
+	compact bool
+	index   map[string]uint64
+	table   []string
+
+	once     sync.Once
+	tableErr error
+}
+
+func newFrameWriter(protocols []Protocol, compact bool) *frameWriter {
+	fw := &frameWriter{
+		compact: compact,
+		index:   make(map[string]uint64),
+	}
+	for i, proto := range protocols {
+		fw.index[proto.Name] = uint64(i)
+		fw.table = append(fw.table, proto.Name)
+	}
+	return fw
+}
+
+// writeTable writes the protocol table to w only once. The callers are
+// blocked until the table is written.
+func (fw *frameWriter) writeTable(w io.Writer) error {
+	if !fw.compact {
+		return nil
+	}
+	fw.once.Do(func() {
+		enc, err := encodeProtoTable(fw.table)
+		if err == nil {
+			_, err = w.Write(enc)
+		}
+		fw.tableErr = err
+	})
+	return fw.tableErr
+}
+
+func (fw *frameWriter) encode(msg Msg) ([]byte, error) {
+	if !fw.compact {
+		return encodeJSONFrame(msg.Body)
+	}
+	index, ok := fw.index[msg.Body.ProtocolID]
+	if !ok {
+		return nil, fmt.Errorf("unknown protocol %q", msg.Body.ProtocolID)
+	}
+	var payload []byte
+	if msg.Body.Payload != nil {
+		var err error
+		if payload, err = ioutil.ReadAll(msg.Body.Payload); err != nil {
+			return nil, err
+		}
+	}
+	return encodeCompactFrame(index, msg.Body.Type, payload), nil
+}
@@ -0,0 +1,335 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package vntp2p
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/vntchain/go-vnt/rlp"
+)
+
+// testTx has the layout of a transaction, to measure the frames of the
+// transaction batches and blocks.
+type testTx struct {
+	Nonce    uint64
+	Price    *big.Int
+	GasLimit uint64
+	To       [20]byte
+	Amount   *big.Int
+	Payload  []byte
+	V, R, S  *big.Int
+}
+
+func newTestTxs(n int) []*testTx {
+	rnd := rand.New(rand.NewSource(1))
+	random := func(size int) []byte {
+		b := make([]byte, size)
+		rnd.Read(b)
+		return b
+	}
+	txs := make([]*testTx, n)
+	for i := range txs {
+		tx := &testTx{
+			Nonce:    uint64(i),
+			Price:    big.NewInt(18000000000),
+			GasLimit: 90000,
+			Amount:   new(big.Int).SetBytes(random(8)),
+			Payload:  make([]byte, 68),
+			V:        big.NewInt(28),
+			R:        new(big.Int).SetBytes(random(32)),
+			S:        new(big.Int).SetBytes(random(32)),
+		}
+		copy(tx.To[:], random(20))
+		copy(tx.Payload[16:], random(20))
+		txs[i] = tx
+	}
+	return txs
+}
+
+func newTestMsg(t testing.TB, protocol string, msgType MessageType, data interface{}) Msg {
+	size, r, err := rlp.EncodeToReader(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Msg{Body: MsgBody{ProtocolID: protocol, Type: msgType, PayloadSize: uint32(size), Payload: r}}
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	protocols := []Protocol{{Name: "vnt"}, {Name: "les"}}
+	tests := []struct {
+		name    string
+		compact bool
+	}{
+		{"json", false},
+		{"compact", true},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		fw := newFrameWriter(protocols, test.compact)
+		if err := fw.writeTable(&buf); err != nil {
+			t.Fatalf("%s: write table error: %v", test.name, err)
+		}
+		// The table is written only once
+		if err := fw.writeTable(&buf); err != nil {
+			t.Fatalf("%s: write table error: %v", test.name, err)
+		}
+
+		msgs := []struct {
+			protocol string
+			msgType  MessageType
+			data     interface{}
+		}{
+			{"les", 3, []uint{1, 2, 3}},
+			{"vnt", 2, newTestTxs(100)},
+			{"vnt", 0, []byte{}},
+		}
+		for _, msg := range msgs {
+			enc, err := fw.encode(newTestMsg(t, msg.protocol, msg.msgType, msg.data))
+			if err != nil {
+				t.Fatalf("%s: encode error: %v", test.name, err)
+			}
+			buf.Write(enc)
+		}
+
+		fr := newFrameReader(&buf, protocols)
+		for i, want := range msgs {
+			msg, err := fr.readMsg()
+			if err != nil {
+				t.Fatalf("%s: msg %d: read error: %v", test.name, i, err)
+			}
+			if msg.Body.ProtocolID != want.protocol || msg.Body.Type != want.msgType {
+				t.Errorf("%s: msg %d: have %s/%d, want %s/%d", test.name, i, msg.Body.ProtocolID, msg.Body.Type, want.protocol, want.msgType)
+			}
+			if err := ExpectMsg(&testMsgReader{msg}, want.msgType, want.data); err != nil {
+				t.Errorf("%s: msg %d: %v", test.name, i, err)
+			}
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%s: %d bytes left", test.name, buf.Len())
+		}
+	}
+}
+
+func TestCompactFrameInvalid(t *testing.T) {
+	payload := bytes.Repeat([]byte{0x80}, compressThreshold)
+	enc := encodeCompactFrame(1, 2, payload)
+	if enc[MessageHeaderLength]&flagSnappy == 0 {
+		t.Fatalf("payload should be compressed")
+	}
+	body := enc[MessageHeaderLength:]
+
+	limit := func(string) uint32 { return compressThreshold }
+	if _, err := decodeCompactBody(body, []string{"vnt"}, limit); err != errUnknownProtoIndex {
+		t.Errorf("unknown protocol index: have %v", err)
+	}
+	if _, err := decodeCompactBody(body[:len(body)-1], []string{"vnt", "les"}, limit); err == nil {
+		t.Errorf("truncated payload should be invalid")
+	}
+	if _, err := decodeCompactBody(body[:2], []string{"vnt", "les"}, limit); err != errInvalidFrame {
+		t.Errorf("truncated header: have %v", err)
+	}
+	// The payload is rejected before decompressing
+	small := func(string) uint32 { return compressThreshold - 1 }
+	if _, err := decodeCompactBody(body, []string{"vnt", "les"}, small); err != errMsgTooLarge {
+		t.Errorf("oversized payload: have %v", err)
+	}
+	msgBody, err := decodeCompactBody(body, []string{"vnt", "les"}, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ioutil.ReadAll(msgBody.Payload); !bytes.Equal(data, payload) {
+		t.Errorf("payload mismatch")
+	}
+}
+
+func TestFrameSizeLimit(t *testing.T) {
+	protocols := []Protocol{{Name: "vnt", MaxMsgSize: 1024}, {Name: "les", MaxMsgSize: 64}}
+	payload := make([]byte, 100)
+	rand.New(rand.NewSource(1)).Read(payload)
+
+	tests := []struct {
+		name    string
+		compact bool
+		frame   []byte
+	}{
+		// The body size is checked before reading the body
+		{"json header", false, func() []byte {
+			header := newMsgHeader(jsonFrame, 2*1024+jsonOverhead+1)
+			return header[:]
+		}()},
+		{"compact header", true, func() []byte {
+			header := newMsgHeader(compactFrame, 1024+compactOverhead+1)
+			return header[:]
+		}()},
+	}
+	for _, compact := range []bool{false, true} {
+		var buf bytes.Buffer
+		fw := newFrameWriter(protocols, compact)
+		if err := fw.writeTable(&buf); err != nil {
+			t.Fatal(err)
+		}
+		enc, err := fw.encode(newTestMsg(t, "les", 1, payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(enc)
+		tests = append(tests, struct {
+			name    string
+			compact bool
+			frame   []byte
+		}{"protocol limit", compact, buf.Bytes()})
+	}
+
+	for _, test := range tests {
+		fr := newFrameReader(bytes.NewReader(test.frame), protocols)
+		if _, err := fr.readMsg(); err != errMsgTooLarge {
+			t.Errorf("%s (compact %v): have error %v, want %v", test.name, test.compact, err, errMsgTooLarge)
+		}
+	}
+}
+
+func TestDeliverSlowConsumer(t *testing.T) {
+	defer func(timeout time.Duration) { slowConsumerTimeout = timeout }(slowConsumerTimeout)
+	slowConsumerTimeout = 50 * time.Millisecond
+
+	rw := &VNTMsger{in: make(chan Msg, 1)}
+	quit := make(chan struct{})
+	if err := rw.deliver(Msg{}, quit); err != nil {
+		t.Fatalf("deliver error: %v", err)
+	}
+
+	// The message is delivered once consumed
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-rw.in
+	}()
+	if err := rw.deliver(Msg{}, quit); err != nil {
+		t.Fatalf("deliver error: %v", err)
+	}
+
+	// The queue is full and not consumed
+	if err := rw.deliver(Msg{}, quit); err != errSlowConsumer {
+		t.Errorf("have error %v, want %v", err, errSlowConsumer)
+	}
+	close(quit)
+	if err := rw.deliver(Msg{}, quit); err != errServerStopped {
+		t.Errorf("have error %v, want %v", err, errServerStopped)
+	}
+}
+
+type testMsgReader struct {
+	msg Msg
+}
+
+func (r *testMsgReader) ReadMsg() (Msg, error) {
+	return r.msg, nil
+}
+
+func benchmarkFrame(b *testing.B, compact bool, n int) {
+	protocols := []Protocol{{Name: "vnt"}}
+	txs := newTestTxs(n)
+	fw := newFrameWriter(protocols, compact)
+	var table bytes.Buffer
+	if err := fw.writeTable(&table); err != nil {
+		b.Fatal(err)
+	}
+
+	var size int
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		enc, err := fw.encode(newTestMsg(b, "vnt", 2, txs))
+		if err != nil {
+			b.Fatal(err)
+		}
+		size = len(enc)
+
+		fr := newFrameReader(bytes.NewReader(append(table.Bytes(), enc...)), protocols)
+		msg, err := fr.readMsg()
+		if err != nil {
+			b.Fatal(err)
+		}
+		var decoded []*testTx
+		if err := msg.Decode(&decoded); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.SetBytes(int64(size))
+}
+
+func BenchmarkJSONFrameTx(b *testing.B)       { benchmarkFrame(b, false, 1) }
+func BenchmarkCompactFrameTx(b *testing.B)    { benchmarkFrame(b, true, 1) }
+func BenchmarkJSONFrameBlock(b *testing.B)    { benchmarkFrame(b, false, 500) }
+func BenchmarkCompactFrameBlock(b *testing.B) { benchmarkFrame(b, true, 500) }
+
+func TestNegotiateVersions(t *testing.T) {
+	local := []Protocol{{Name: "vnt", Version: 64}, {Name: "vnt", Version: 63}, {Name: "vnt", Version: 62}, {Name: "les", Version: 1}}
+	tests := []struct {
+		caps []Cap
+		want []Cap
+	}{
+		{[]Cap{{"vnt", 62}, {"vnt", 63}, {"vnt", 64}, {"les", 1}}, []Cap{{"vnt", 64}, {"les", 1}}},
+		{[]Cap{{"vnt", 63}, {"vnt", 62}}, []Cap{{"vnt", 63}}},
+		{[]Cap{{"vnt", 65}, {"vnt", 62}, {"les", 2}}, []Cap{{"vnt", 62}}},
+		{[]Cap{{"shh", 5}}, nil},
+	}
+	for i, tt := range tests {
+		// The caps are sent in the first frame of the stream
+		enc, err := encodeCaps(tt.caps)
+		if err != nil {
+			t.Fatalf("test %d: failed to encode caps: %v", i, err)
+		}
+		caps, err := readCaps(bytes.NewReader(enc))
+		if err != nil {
+			t.Fatalf("test %d: failed to read caps: %v", i, err)
+		}
+		var have []Cap
+		for _, proto := range matchProtocols(local, caps) {
+			have = append(have, proto.cap())
+		}
+		if len(have) != len(tt.want) {
+			t.Errorf("test %d: protocols mismatch: have %v, want %v", i, have, tt.want)
+			continue
+		}
+		for j := range have {
+			if have[j] != tt.want[j] {
+				t.Errorf("test %d: protocols mismatch: have %v, want %v", i, have, tt.want)
+				break
+			}
+		}
+	}
+
+	// The streams not exchanging caps run the last version as before
+	legacy := legacyProtocols(local)
+	if len(legacy) != 2 || legacy[0].cap() != (Cap{"vnt", 62}) || legacy[1].cap() != (Cap{"les", 1}) {
+		t.Errorf("legacy protocols mismatch: %v", legacy)
+	}
+
+	// The messages before caps are rejected
+	enc, err := encodeProtoTable([]string{"vnt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCaps(bytes.NewReader(enc)); err != errNoCaps {
+		t.Errorf("read caps error mismatch: have %v, want %v", err, errNoCaps)
+	}
+}
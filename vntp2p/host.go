@@ -55,8 +55,16 @@ import (
 )
 
 const (
-	// PID vnt protocol basic id
-	PID                 = "/p2p/1.0.0"
+	// PID vnt protocol basic id, whose messages are framed in json
+	PID = "/p2p/1.0.0"
+	// CompactPID vnt protocol id whose messages are framed in compact binary,
+	// it's preferred to PID when both peers support it
+	CompactPID = "/p2p/2.0.0"
+	// VersionedPID vnt protocol id whose messages are framed in compact binary,
+	// the peers exchange the versions of sub-protocols before any message and
+	// run the highest common ones, it's preferred to CompactPID
+	VersionedPID = "/p2p/3.0.0"
+
 	persistDataInterval = 10 * time.Second
 )
 
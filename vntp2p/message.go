@@ -19,7 +19,6 @@ package vntp2p
 import (
 	"bytes"
 	"encoding/binary"
-	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -44,7 +43,8 @@ type MsgWriter interface {
 	WriteMsg(Msg) error
 }
 
-// MessageHeaderLength define message header length
+// MessageHeaderLength define message header length, the first 4 bytes is the
+// size of body and the last byte is the kind of frame
 const MessageHeaderLength = 5
 
 // MessageType define vnt p2p protocol message type
@@ -99,27 +99,14 @@ func Send(w MsgWriter, protocolID string, msgType MessageType, data interface{})
 		return err
 	}
 
-	msgBody := MsgBody{
-		ProtocolID:  protocolID,
-		Type:        msgType,
-		PayloadSize: uint32(size),
-		Payload:     r,
-	}
-	msgBodyByte, err := json.Marshal(msgBody)
-	if err != nil {
-		log.Error("Send()", "marshal msgBody error", err)
-		return err
-	}
-	msgBodySize := len(msgBodyByte)
-	msgHeaderByte := make([]byte, MessageHeaderLength)
-	binary.LittleEndian.PutUint32(msgHeaderByte, uint32(msgBodySize))
-
-	var msgHeader MsgHeader
-	copy(msgHeader[:], msgHeaderByte)
-
+	// 消息头在写入时根据连接协商的消息帧格式生成
 	msg := Msg{
-		Header: msgHeader,
-		Body:   msgBody,
+		Body: MsgBody{
+			ProtocolID:  protocolID,
+			Type:        msgType,
+			PayloadSize: uint32(size),
+			Payload:     r,
+		},
 	}
 
 	return w.WriteMsg(msg)
@@ -159,15 +146,15 @@ type VNTMsger struct {
 
 // WriteMsg implement MsgReadWriter interface
 func (rw *VNTMsger) WriteMsg(msg Msg) (err error) {
-	msgHeaderByte := msg.Header[:]
-	msgBodyByte, err := json.Marshal(msg.Body)
+	m, err := rw.peer.frame.encode(msg)
 	if err != nil {
-		rw.peer.log.Error("Write message", "marshal msgbody error", err)
+		rw.peer.log.Error("Write message", "encode msg error", err)
 		return err
 	}
-	m := append(msgHeaderByte, msgBodyByte...)
 
-	_, err = rw.w.Write(m)
+	if err = rw.peer.frame.writeTable(rw.w); err == nil {
+		_, err = rw.w.Write(m)
+	}
 	if err != nil {
 		rw.peer.log.Error("Write message", "write msg error", err)
 		if atomic.LoadInt32(&rw.peer.reseted) == 0 {
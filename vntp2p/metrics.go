@@ -0,0 +1,28 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package vntp2p
+
+import (
+	"github.com/vntchain/go-vnt/metrics"
+)
+
+var (
+	inboundOversizedMeter = metrics.NewRegisteredMeter("p2p/inbound/oversized", nil) // 超过长度限制的消息
+	inboundDroppedMeter   = metrics.NewRegisteredMeter("p2p/inbound/dropped", nil)   // 不支持的子协议的消息
+	inboundSlowMeter      = metrics.NewRegisteredMeter("p2p/inbound/slow", nil)      // 子协议处理消息超时
+	inboundBlockedMeter   = metrics.NewRegisteredMeter("p2p/inbound/blocked", nil)   // 接收队列已满，暂停读取消息
+)
@@ -23,6 +23,7 @@ import (
 	"crypto/ecdsa"
 	"encoding/json"
 	"net"
+	"sort"
 	"sync"
 	"sync/atomic"
 
@@ -74,6 +75,7 @@ type PeerInfo struct {
 		RemoteAddress string `json:"remoteAddress"` // Remote endpoint of the TCP data connection
 		Inbound       bool   `json:"inbound"`
 		Trusted       bool   `json:"trusted"`
+		Witness       bool   `json:"witness"`
 		Static        bool   `json:"static"`
 	} `json:"network"`
 	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
@@ -82,10 +84,13 @@ type PeerInfo struct {
 type Peer struct {
 	rw      inet.Stream // libp2p stream
 	reseted int32       // Whether stream reseted
+	trusted int32       // Whether the peer is trusted
+	witness int32       // Whether the peer is an active witness
 	log     log.Logger
 	events  *event.Feed
 	err     chan error
-	msgers  map[string]*VNTMsger // protocolName - vntMessenger
+	msgers  map[string]*VNTMsger // protocolName - vntMessenger of the negotiated version
+	frame   *frameWriter         // encode messages in the negotiated frame format
 	server  *Server
 	wg      sync.WaitGroup
 }
@@ -96,7 +101,7 @@ func newPeer(s *Stream, server *Server) *Peer {
 		proto := s.Protocols[i]
 		vntMessenger := &VNTMsger{
 			protocol: proto,
-			in:       make(chan Msg),
+			in:       make(chan Msg, inboundQueueSize),
 			err:      make(chan error, 100),
 			w:        s.stream,
 		}
@@ -109,6 +114,7 @@ func newPeer(s *Stream, server *Server) *Peer {
 		err:     make(chan error),
 		reseted: 0,
 		msgers:  m,
+		frame:   newFrameWriter(s.Protocols, s.stream.Protocol() != PID),
 		server:  server,
 	}
 	for _, msger := range p.msgers {
@@ -118,6 +124,15 @@ func newPeer(s *Stream, server *Server) *Peer {
 	return p
 }
 
+// protocols returns the protocols running on the peer.
+func (p *Peer) protocols() []Protocol {
+	protocols := make([]Protocol, 0, len(p.msgers))
+	for _, msger := range p.msgers {
+		protocols = append(protocols, msger.protocol)
+	}
+	return protocols
+}
+
 // Drop this peer forever because of protocol mismatch
 func (p *Peer) Drop() {
 	p.rw.Conn().Close()
@@ -204,16 +219,48 @@ func (p *Peer) Reset() {
 	}
 }
 
+// Trusted returns whether the peer is a trusted peer.
+func (p *Peer) Trusted() bool {
+	return atomic.LoadInt32(&p.trusted) == 1
+}
+
+func (p *Peer) setTrusted(trusted bool) {
+	var v int32
+	if trusted {
+		v = 1
+	}
+	atomic.StoreInt32(&p.trusted, v)
+}
+
+// Witness returns whether the peer is an active witness.
+func (p *Peer) Witness() bool {
+	return atomic.LoadInt32(&p.witness) == 1
+}
+
+func (p *Peer) setWitness(witness bool) {
+	var v int32
+	if witness {
+		v = 1
+	}
+	atomic.StoreInt32(&p.witness, v)
+}
+
 func (p *Peer) Info() *PeerInfo {
 	info := &PeerInfo{
 		ID: p.RemoteID().String(),
 	}
+	for _, proto := range p.protocols() {
+		info.Caps = append(info.Caps, proto.cap().String())
+	}
+	sort.Strings(info.Caps)
 	info.Network.LocalAddress = p.rw.Conn().LocalMultiaddr().String()
 	info.Network.RemoteAddress = p.rw.Conn().RemoteMultiaddr().String()
 
+	info.Network.Trusted = p.Trusted()
+	info.Network.Witness = p.Witness()
+
 	// 此处暂时不处理状态
 	// info.Network.Static = p.rw.Conn().RemotePeer()
-	// info.Network.Trusted =
 	// info.Network.Inbound =
 
 	return info
@@ -17,33 +17,197 @@
 package vntp2p
 
 import (
-	"encoding/binary"
-	"encoding/json"
-	"io"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	inet "github.com/libp2p/go-libp2p-net"
 	libp2p "github.com/libp2p/go-libp2p-peer"
 	"github.com/vntchain/go-vnt/log"
-	"github.com/vntchain/go-vnt/rlp"
+)
+
+// DefaultMaxMsgSize is the maximum payload size of messages of the protocols
+// not specifying MaxMsgSize.
+const DefaultMaxMsgSize = 10 * 1024 * 1024
+
+const (
+	// inboundQueueSize is the number of received messages buffered for each
+	// protocol of a peer.
+	inboundQueueSize = 64
+	// maxUnknownMsgs is the number of messages of unsupported protocols
+	// tolerated on a stream.
+	maxUnknownMsgs = 16
+)
+
+// slowConsumerTimeout is the time waiting for the protocol to consume the
+// message when the inbound queue is full.
+var slowConsumerTimeout = 20 * time.Second
+
+var (
+	errSlowConsumer       = errors.New("protocol is too slow to handle messages")
+	errTooManyUnknownMsgs = errors.New("too many messages of unknown protocols")
+	errNoCommonProtocol   = errors.New("no common protocol")
 )
 
 // 目前依然沿用原有的子协议结构，减少上层的改动
 type Protocol struct {
-	Name     string
-	Version  uint
-	Length   uint64
-	Run      func(peer *Peer, rw MsgReadWriter) error
-	NodeInfo func() interface{}
-	PeerInfo func(id libp2p.ID) interface{}
+	Name    string
+	Version uint
+	Length  uint64
+	// MaxMsgSize is the maximum payload size of the messages, the peer sending
+	// larger messages is disconnected. DefaultMaxMsgSize is used if it's zero.
+	MaxMsgSize uint32
+	Run        func(peer *Peer, rw MsgReadWriter) error
+	NodeInfo   func() interface{}
+	PeerInfo   func(id libp2p.ID) interface{}
+}
+
+func (p Protocol) maxMsgSize() uint32 {
+	if p.MaxMsgSize == 0 {
+		return DefaultMaxMsgSize
+	}
+	return p.MaxMsgSize
+}
+
+func (p Protocol) cap() Cap {
+	return Cap{Name: p.Name, Version: p.Version}
+}
+
+// Cap is the name and version of a sub-protocol supported by the peer.
+type Cap struct {
+	Name    string
+	Version uint
+}
+
+func (cap Cap) String() string {
+	return fmt.Sprintf("%s/%d", cap.Name, cap.Version)
+}
+
+// matchProtocols returns the highest version of each protocol supported by
+// both the local protocols and the remote caps.
+func matchProtocols(protocols []Protocol, caps []Cap) []Protocol {
+	remote := make(map[Cap]bool, len(caps))
+	for _, cap := range caps {
+		remote[cap] = true
+	}
+	var (
+		matched []Protocol
+		index   = make(map[string]int)
+	)
+	for _, proto := range protocols {
+		if !remote[proto.cap()] {
+			continue
+		}
+		if i, ok := index[proto.Name]; ok {
+			if proto.Version > matched[i].Version {
+				matched[i] = proto
+			}
+			continue
+		}
+		index[proto.Name] = len(matched)
+		matched = append(matched, proto)
+	}
+	return matched
+}
+
+// legacyProtocols returns the protocols running on the streams without caps
+// exchanged. The versions are unknown, so the last one of the protocols with
+// the same name is used as the nodes not negotiating versions do.
+func legacyProtocols(protocols []Protocol) []Protocol {
+	var (
+		legacy []Protocol
+		index  = make(map[string]int)
+	)
+	for _, proto := range protocols {
+		if i, ok := index[proto.Name]; ok {
+			legacy[i] = proto
+			continue
+		}
+		index[proto.Name] = len(legacy)
+		legacy = append(legacy, proto)
+	}
+	return legacy
+}
+
+// negotiate returns the protocols running on the stream. The peers exchange
+// their caps before any message on the streams of VersionedPID, and run the
+// highest common version of each protocol.
+func (server *Server) negotiate(s inet.Stream) ([]Protocol, error) {
+	if s.Protocol() != VersionedPID {
+		return legacyProtocols(server.Protocols), nil
+	}
+	caps := make([]Cap, len(server.Protocols))
+	for i, proto := range server.Protocols {
+		caps[i] = proto.cap()
+	}
+	enc, err := encodeCaps(caps)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.Write(enc); err != nil {
+		return nil, err
+	}
+
+	if err := s.SetReadDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		return nil, err
+	}
+	remote, err := readCaps(s)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.SetReadDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+
+	protocols := matchProtocols(server.Protocols, remote)
+	if len(protocols) == 0 {
+		return nil, errNoCommonProtocol
+	}
+	return protocols, nil
+}
+
+// handleInboundStream handles the streams opened by remote peers. The inbound
+// peers are pending until their first message is received or handshakeTimeout
+// elapsed, and the pending peers are limited by MaxPendingPeers, the exceeding
+// streams are reset. The slow peers are not pending any more after the timeout,
+// but their streams are kept.
+func (server *Server) handleInboundStream(s inet.Stream) {
+	select {
+	case server.pending <- struct{}{}:
+	default:
+		log.Debug("Too many pending peers", "remotePeerID", s.Conn().RemotePeer())
+		_ = s.Reset()
+		return
+	}
+	var once sync.Once
+	server.serveStream(s, func() { once.Do(func() { <-server.pending }) })
 }
 
 // HandleStream handle all message which is from anywhere
-// 主、被动连接都走的流程
 func (server *Server) HandleStream(s inet.Stream) {
-	// peer信息只获取1次即可
+	server.serveStream(s, nil)
+}
+
+// serveStream reads the messages from stream until error, 主、被动连接都走的流程。
+// handshaked is called once the first message is received, handshakeTimeout
+// elapsed or the stream fails, it must be safe to be called more than once.
+func (server *Server) serveStream(s inet.Stream, handshaked func()) {
+	if handshaked != nil {
+		timer := time.AfterFunc(handshakeTimeout, handshaked)
+		defer timer.Stop()
+		defer handshaked()
+	}
+
+	// 协商子协议版本，peer信息只获取1次即可
 	log.Debug("Stream data coming...")
-	peer := server.GetPeerByRemoteID(s)
+	protocols, err := server.negotiate(s)
+	if err != nil {
+		log.Debug("HandleStream", "remotePeerID", s.Conn().RemotePeer(), "negotiate protocols error", err)
+		_ = s.Reset()
+		return
+	}
+	peer := server.GetPeerByRemoteID(&Stream{stream: s, Protocols: protocols})
 	if peer == nil {
 		log.Debug("HandleStream", "remotePeerID", s.Conn().RemotePeer(), "this remote peer is nil, don't handle it")
 		_ = s.Reset()
@@ -56,53 +220,69 @@ func (server *Server) HandleStream(s inet.Stream) {
 		peer.Reset()
 	}()
 
-	// stream未关闭则连接正常可持续读取消息
+	// stream未关闭则连接正常可持续读取消息，兼容json和紧凑二进制两种消息帧
+	reader := newFrameReader(s, peer.protocols())
+	unknown := 0
 	for {
-		// 读取消息
-		msgHeaderByte := make([]byte, MessageHeaderLength)
-		_, err := io.ReadFull(s, msgHeaderByte)
+		// 读取消息，超过长度限制的消息在分配内存前拒绝
+		msg, err := reader.readMsg()
+		if handshaked != nil {
+			handshaked()
+			handshaked = nil
+		}
 		if err != nil {
-			peer.log.Error("HandleStream", "read msg header error", err)
+			if err == errMsgTooLarge {
+				inboundOversizedMeter.Mark(1)
+				peer.Report(ScoreOversizedMsg)
+			}
+			peer.log.Error("HandleStream", "read msg error", err)
 			notifyError(peer.msgers, err)
 			return
 		}
-		bodySize := binary.LittleEndian.Uint32(msgHeaderByte)
 
-		msgBodyByte := make([]byte, bodySize)
-		_, err = io.ReadFull(s, msgBodyByte)
-		if err != nil {
-			peer.log.Error("HandleStream", "read msg Body error", err)
-			notifyError(peer.msgers, err)
-			return
+		msger, ok := peer.msgers[msg.Body.ProtocolID]
+		if !ok {
+			inboundDroppedMeter.Mark(1)
+			peer.Report(ScoreUnknownProtocol)
+			peer.log.Warn("HandleStream", "receive unknown message", msg.Body.ProtocolID, "type", msg.Body.Type)
+			if unknown++; unknown > maxUnknownMsgs {
+				notifyError(peer.msgers, errTooManyUnknownMsgs)
+				return
+			}
+			continue
 		}
-		msgBody := &MsgBody{Payload: &rlp.EncReader{}}
-		err = json.Unmarshal(msgBodyByte, msgBody)
-		if err != nil {
-			peer.log.Error("HandleStream", "unmarshal msg Body error", err)
+
+		// 传递给msger，接收队列已满时不再读取stream，从而限制对方发送消息
+		if err := msger.deliver(msg, server.quit); err != nil {
+			peer.log.Warn("HandleStream", "deliver msg error", err, "protocol", msg.Body.ProtocolID, "type", msg.Body.Type)
 			notifyError(peer.msgers, err)
 			return
 		}
-		msgBody.ReceivedAt = time.Now()
+	}
+}
 
-		// 传递给msger
-		var msgHeader MsgHeader
-		copy(msgHeader[:], msgHeaderByte)
+// deliver passes the message to the protocol through the bounded inbound
+// queue. The stream is not read while the queue is full, so the remote peer is
+// blocked from sending more, and the peer is disconnected if the protocol
+// doesn't consume the message in slowConsumerTimeout.
+func (rw *VNTMsger) deliver(msg Msg, quit <-chan struct{}) error {
+	select {
+	case rw.in <- msg:
+		return nil
+	default:
+	}
 
-		msg := Msg{
-			Header: msgHeader,
-			Body:   *msgBody,
-		}
-		if msger, ok := peer.msgers[msgBody.ProtocolID]; ok { // this node support protocolID
-			// 非阻塞向上层协议传递消息，如果2s还未被读取，认为上层协议有故障
-			select {
-			case msger.in <- msg:
-				peer.log.Trace("HandleStream send message to messager success")
-			case <-time.NewTimer(time.Second * 2).C:
-				peer.log.Trace("HandleStream send message to messager timeout")
-			}
-		} else {
-			peer.log.Warn("HandleStream", "receive unknown message", msg)
-		}
+	inboundBlockedMeter.Mark(1)
+	timer := time.NewTimer(slowConsumerTimeout)
+	defer timer.Stop()
+	select {
+	case rw.in <- msg:
+		return nil
+	case <-timer.C:
+		inboundSlowMeter.Mark(1)
+		return errSlowConsumer
+	case <-quit:
+		return errServerStopped
 	}
 }
 
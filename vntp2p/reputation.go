@@ -0,0 +1,460 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package vntp2p
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	peer "github.com/libp2p/go-libp2p-peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/vntchain/go-vnt/log"
+)
+
+// ScoreEvent is the behaviour of a peer which affects its score.
+type ScoreEvent uint
+
+const (
+	// ScoreDecodeError is reported when the message of peer can't be decoded
+	ScoreDecodeError ScoreEvent = iota
+	// ScoreInvalidBlock is reported when the peer propagates an invalid block
+	ScoreInvalidBlock
+	// ScoreInvalidBftMsg is reported when the peer sends an invalid bft message
+	ScoreInvalidBftMsg
+	// ScoreTimeout is reported when the peer times out or stalls the requests
+	ScoreTimeout
+	// ScoreOversizedMsg is reported when the peer sends a message too large
+	ScoreOversizedMsg
+	// ScoreUnknownProtocol is reported when the peer sends messages of
+	// unsupported protocols
+	ScoreUnknownProtocol
+)
+
+var scoreEventToString = map[ScoreEvent]string{
+	ScoreDecodeError:     "decode error",
+	ScoreInvalidBlock:    "invalid block",
+	ScoreInvalidBftMsg:   "invalid bft message",
+	ScoreTimeout:         "timeout",
+	ScoreOversizedMsg:    "oversized message",
+	ScoreUnknownProtocol: "unknown protocol",
+}
+
+// scoreDeltas is the change of score for each event.
+var scoreDeltas = map[ScoreEvent]float64{
+	ScoreDecodeError:     -25,
+	ScoreInvalidBlock:    -50,
+	ScoreInvalidBftMsg:   -50,
+	ScoreTimeout:         -10,
+	ScoreOversizedMsg:    -100,
+	ScoreUnknownProtocol: -5,
+}
+
+func (e ScoreEvent) String() string {
+	if str, ok := scoreEventToString[e]; ok {
+		return str
+	}
+	return "unknown event"
+}
+
+const (
+	// banThreshold is the score at which the peer is banned
+	banThreshold = -100
+	// scoreHalfLife is the time in which the score decays by half towards zero
+	scoreHalfLife = 10 * time.Minute
+
+	// DefaultBanDuration is the duration of the bans caused by low score
+	DefaultBanDuration = time.Hour
+
+	banKeyPrefix = "/bans/"
+)
+
+var errInvalidBanTarget = errors.New("invalid ban target, should be peer id, node url, ip or cidr")
+
+// Ban is a temporary ban of a peer or an ip network.
+type Ban struct {
+	ID      peer.ID   `json:"id,omitempty"`
+	Net     string    `json:"net,omitempty"`
+	Expires time.Time `json:"expires"`
+	Reason  string    `json:"reason"`
+}
+
+// key returns the peer id or the network the ban applied to.
+func (b *Ban) key() string {
+	if b.Net != "" {
+		return b.Net
+	}
+	return b.ID.Pretty()
+}
+
+// PeerScore is the score of a peer.
+type PeerScore struct {
+	ID        string  `json:"id"`
+	Score     float64 `json:"score"`
+	Connected bool    `json:"connected"`
+}
+
+type peerScore struct {
+	score   float64
+	updated time.Time
+}
+
+// decay decays the score towards zero since last update.
+func (s *peerScore) decay(now time.Time) {
+	if elapsed := now.Sub(s.updated); elapsed > 0 {
+		s.score *= math.Pow(0.5, float64(elapsed)/float64(scoreHalfLife))
+		s.updated = now
+	}
+}
+
+// reputation scores the peers by their behaviour and keeps the bans of peers
+// and ip networks. The bans are persisted in the node datastore if exists.
+type reputation struct {
+	lock   sync.Mutex
+	scores map[peer.ID]*peerScore
+	bans   map[string]*Ban       // 被禁止的节点和网段
+	nets   map[string]*net.IPNet // 被禁止的网段
+	db     *LevelDB
+	clock  func() time.Time
+}
+
+func newReputation(db *LevelDB) *reputation {
+	r := &reputation{
+		scores: make(map[peer.ID]*peerScore),
+		bans:   make(map[string]*Ban),
+		nets:   make(map[string]*net.IPNet),
+		db:     db,
+		clock:  time.Now,
+	}
+	r.load()
+	return r
+}
+
+func banKey(key string) ds.Key {
+	return ds.NewKey(banKeyPrefix + hex.EncodeToString([]byte(key)))
+}
+
+// load recovers the unexpired bans from datastore.
+func (r *reputation) load() {
+	if r.db == nil {
+		return
+	}
+	results, err := r.db.Query(query.Query{Prefix: banKeyPrefix})
+	if err != nil {
+		log.Error("Load peer bans failed", "err", err)
+		return
+	}
+	entries, err := results.Rest()
+	if err != nil {
+		log.Error("Load peer bans failed", "err", err)
+		return
+	}
+
+	now := r.clock()
+	for _, entry := range entries {
+		var ban Ban
+		if err := json.Unmarshal(entry.Value.([]byte), &ban); err != nil || !now.Before(ban.Expires) {
+			_ = r.db.Delete(ds.NewKey(entry.Key))
+			continue
+		}
+		if err := r.addBan(&ban); err != nil {
+			log.Error("Invalid peer ban", "ban", ban.key(), "err", err)
+		}
+	}
+}
+
+func (r *reputation) addBan(ban *Ban) error {
+	if ban.Net != "" {
+		_, ipnet, err := net.ParseCIDR(ban.Net)
+		if err != nil {
+			return err
+		}
+		ban.Net = ipnet.String()
+		r.nets[ban.Net] = ipnet
+	}
+	r.bans[ban.key()] = ban
+	return nil
+}
+
+func (r *reputation) saveBan(ban *Ban) {
+	if r.db == nil {
+		return
+	}
+	enc, err := json.Marshal(ban)
+	if err == nil {
+		err = r.db.Put(banKey(ban.key()), enc)
+	}
+	if err != nil {
+		log.Error("Save peer ban failed", "ban", ban.key(), "err", err)
+	}
+}
+
+// removeBan removes the ban of key, the lock must be held.
+func (r *reputation) removeBan(key string) bool {
+	if _, ok := r.bans[key]; !ok {
+		return false
+	}
+	delete(r.bans, key)
+	delete(r.nets, key)
+	if r.db != nil {
+		if err := r.db.Delete(banKey(key)); err != nil {
+			log.Error("Delete peer ban failed", "ban", key, "err", err)
+		}
+	}
+	return true
+}
+
+// ban bans the peer id or the ip network until expires.
+func (r *reputation) ban(ban *Ban) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.addBan(ban); err != nil {
+		return err
+	}
+	r.saveBan(ban)
+	if ban.ID != "" {
+		delete(r.scores, ban.ID)
+	}
+	log.Info("Peer banned", "target", ban.key(), "expires", ban.Expires, "reason", ban.Reason)
+	return nil
+}
+
+// unban removes the ban of the peer id or the ip network.
+func (r *reputation) unban(key string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.removeBan(key)
+}
+
+// isBanned returns whether the peer or its ip is banned. The ip may be nil if
+// it's unknown.
+func (r *reputation) isBanned(id peer.ID, ip net.IP) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.clock()
+	if ban, ok := r.bans[id.Pretty()]; ok {
+		if now.Before(ban.Expires) {
+			return true
+		}
+		r.removeBan(ban.key())
+	}
+	if ip == nil {
+		return false
+	}
+	for key, ipnet := range r.nets {
+		if !ipnet.Contains(ip) {
+			continue
+		}
+		if now.Before(r.bans[key].Expires) {
+			return true
+		}
+		r.removeBan(key)
+	}
+	return false
+}
+
+// report updates the score of peer by the event, and bans the peer if the
+// score reaches banThreshold. Returns whether the peer is banned.
+func (r *reputation) report(id peer.ID, event ScoreEvent) bool {
+	r.lock.Lock()
+	now := r.clock()
+	s, ok := r.scores[id]
+	if !ok {
+		s = &peerScore{updated: now}
+		r.scores[id] = s
+	}
+	s.decay(now)
+	s.score = math.Max(s.score+scoreDeltas[event], banThreshold)
+	score := s.score
+	r.lock.Unlock()
+
+	log.Debug("Peer score updated", "peer", id, "event", event, "score", score)
+	if score > banThreshold {
+		return false
+	}
+	err := r.ban(&Ban{ID: id, Expires: now.Add(DefaultBanDuration), Reason: event.String()})
+	return err == nil
+}
+
+// score returns the current score of peer.
+func (r *reputation) score(id peer.ID) float64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	s, ok := r.scores[id]
+	if !ok {
+		return 0
+	}
+	s.decay(r.clock())
+	return s.score
+}
+
+// list returns the scored peers and the unexpired bans.
+func (r *reputation) list() (map[peer.ID]float64, []*Ban) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.clock()
+	scores := make(map[peer.ID]float64)
+	for id, s := range r.scores {
+		s.decay(now)
+		// 分数已衰减到接近0的节点不再记录
+		if math.Abs(s.score) < 0.5 {
+			delete(r.scores, id)
+			continue
+		}
+		scores[id] = s.score
+	}
+	var bans []*Ban
+	for key, ban := range r.bans {
+		if !now.Before(ban.Expires) {
+			r.removeBan(key)
+			continue
+		}
+		copied := *ban
+		bans = append(bans, &copied)
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Expires.Before(bans[j].Expires) })
+	return scores, bans
+}
+
+// parseBanTarget parses the peer id, node url, ip or cidr to ban.
+func parseBanTarget(target string) (*Ban, error) {
+	if _, ipnet, err := net.ParseCIDR(target); err == nil {
+		return &Ban{Net: ipnet.String()}, nil
+	}
+	if ip := net.ParseIP(target); ip != nil {
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		return &Ban{Net: (&net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}).String()}, nil
+	}
+	if strings.HasPrefix(target, "/") {
+		if node, err := ParseNode(target); err == nil {
+			return &Ban{ID: node.Id}, nil
+		}
+	}
+	if id, err := peer.IDB58Decode(target); err == nil {
+		return &Ban{ID: id}, nil
+	}
+	return nil, errInvalidBanTarget
+}
+
+// multiaddrIP returns the ip of the multiaddr, nil if it has no ip.
+func multiaddrIP(addr ma.Multiaddr) net.IP {
+	if addr == nil {
+		return nil
+	}
+	switch a := parseMultiaddr(addr).(type) {
+	case *net.TCPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	}
+	return nil
+}
+
+// Report records the behaviour of the peer in its score, the peer is banned
+// and disconnected once the score reaches the threshold. The trusted peers
+// are not scored.
+func (p *Peer) Report(event ScoreEvent) {
+	if p.server == nil || p.server.reputation == nil || p.Trusted() {
+		return
+	}
+	if p.server.reputation.report(p.RemoteID(), event) {
+		p.log.Warn("Disconnect banned peer", "reason", event)
+		p.Disconnect(DiscUselessPeer)
+	}
+}
+
+// PeerScores returns the scores of the connected and the scored peers.
+func (server *Server) PeerScores() []*PeerScore {
+	if server.reputation == nil {
+		return nil
+	}
+	scores, _ := server.reputation.list()
+	result := make([]*PeerScore, 0, len(scores))
+	for _, p := range server.Peers() {
+		id := p.RemoteID()
+		result = append(result, &PeerScore{ID: id.Pretty(), Score: scores[id], Connected: true})
+		delete(scores, id)
+	}
+	for id, score := range scores {
+		result = append(result, &PeerScore{ID: id.Pretty(), Score: score})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Score < result[j].Score })
+	return result
+}
+
+// Bans returns the unexpired bans.
+func (server *Server) Bans() []*Ban {
+	if server.reputation == nil {
+		return nil
+	}
+	_, bans := server.reputation.list()
+	return bans
+}
+
+// BanPeer bans the peer id, node url, ip or cidr for the duration, and
+// disconnects the matched peers except the trusted ones.
+func (server *Server) BanPeer(target string, duration time.Duration, reason string) error {
+	if server.reputation == nil {
+		return errServerStopped
+	}
+	ban, err := parseBanTarget(target)
+	if err != nil {
+		return err
+	}
+	ban.Expires = time.Now().Add(duration)
+	ban.Reason = reason
+	if err := server.reputation.ban(ban); err != nil {
+		return err
+	}
+	for _, p := range server.Peers() {
+		if !p.Trusted() && server.reputation.isBanned(p.RemoteID(), multiaddrIP(p.rw.Conn().RemoteMultiaddr())) {
+			p.Disconnect(DiscUselessPeer)
+		}
+	}
+	return nil
+}
+
+// UnbanPeer removes the ban of the peer id, node url, ip or cidr. Returns
+// false if it's not banned.
+func (server *Server) UnbanPeer(target string) (bool, error) {
+	if server.reputation == nil {
+		return false, errServerStopped
+	}
+	ban, err := parseBanTarget(target)
+	if err != nil {
+		return false, err
+	}
+	return server.reputation.unban(ban.key()), nil
+}
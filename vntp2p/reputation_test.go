@@ -0,0 +1,238 @@
+// Copyright 2019 The go-vnt Authors
+// This file is part of the go-vnt library.
+//
+// The go-vnt library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-vnt library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-vnt library. If not, see <http://www.gnu.org/licenses/>.
+
+package vntp2p
+
+import (
+	"io/ioutil"
+	"math"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	peer "github.com/libp2p/go-libp2p-peer"
+)
+
+const (
+	testPeerID  = "1kHBXTtDX4JqStm4qBNCcCZar9isCyu74BPgX6b2odm7zw7"
+	testPeerID2 = "1kHBa9E1onVKmruWvefJHSNFGokkNc3ESZebnq2oJFRDFDG"
+)
+
+// newTestReputation creates the reputation with a manual clock.
+func newTestReputation(db *LevelDB, now *time.Time) *reputation {
+	r := &reputation{
+		scores: make(map[peer.ID]*peerScore),
+		bans:   make(map[string]*Ban),
+		nets:   make(map[string]*net.IPNet),
+		db:     db,
+		clock:  func() time.Time { return *now },
+	}
+	r.load()
+	return r
+}
+
+func decodeTestID(t *testing.T, id string) peer.ID {
+	pid, err := peer.IDB58Decode(id)
+	if err != nil {
+		t.Fatalf("decode peer id error: %v", err)
+	}
+	return pid
+}
+
+func TestScoreDecay(t *testing.T) {
+	now := time.Unix(1546300800, 0)
+	r := newTestReputation(nil, &now)
+	id := decodeTestID(t, testPeerID)
+
+	if r.report(id, ScoreInvalidBlock) {
+		t.Fatal("peer should not be banned")
+	}
+	if score := r.score(id); score != scoreDeltas[ScoreInvalidBlock] {
+		t.Errorf("score mismatch: have %v, want %v", score, scoreDeltas[ScoreInvalidBlock])
+	}
+	// 分数每个半衰期衰减一半
+	now = now.Add(scoreHalfLife)
+	if score, want := r.score(id), scoreDeltas[ScoreInvalidBlock]/2; math.Abs(score-want) > 1e-9 {
+		t.Errorf("decayed score mismatch: have %v, want %v", score, want)
+	}
+	// 分数衰减到接近0后不再记录
+	now = now.Add(10 * scoreHalfLife)
+	if scores, _ := r.list(); len(scores) != 0 {
+		t.Errorf("decayed scores should be dropped, have %v", scores)
+	}
+}
+
+func TestScoreBan(t *testing.T) {
+	now := time.Unix(1546300800, 0)
+	r := newTestReputation(nil, &now)
+	id := decodeTestID(t, testPeerID)
+	other := decodeTestID(t, testPeerID2)
+
+	for i := 0; i < 3; i++ {
+		if r.report(id, ScoreDecodeError) {
+			t.Fatalf("peer should not be banned after %d reports", i+1)
+		}
+	}
+	if !r.report(id, ScoreDecodeError) {
+		t.Fatal("peer should be banned at the threshold")
+	}
+	if !r.isBanned(id, nil) {
+		t.Error("peer should be banned")
+	}
+	if r.isBanned(other, nil) {
+		t.Error("other peer should not be banned")
+	}
+	_, bans := r.list()
+	if len(bans) != 1 || bans[0].ID != id || bans[0].Reason != ScoreDecodeError.String() {
+		t.Errorf("ban mismatch: %v", bans)
+	}
+	// 被禁止的节点分数重新计算
+	if score := r.score(id); score != 0 {
+		t.Errorf("score should be reset after ban, have %v", score)
+	}
+
+	// 禁止到期后自动解除
+	now = now.Add(DefaultBanDuration)
+	if r.isBanned(id, nil) {
+		t.Error("ban should be expired")
+	}
+	if _, bans := r.list(); len(bans) != 0 {
+		t.Errorf("expired bans should be removed, have %v", bans)
+	}
+}
+
+func TestBanNetwork(t *testing.T) {
+	now := time.Unix(1546300800, 0)
+	r := newTestReputation(nil, &now)
+	id := decodeTestID(t, testPeerID)
+
+	for _, target := range []string{"10.0.0.0/8", "192.168.1.1", "fe80::1"} {
+		ban, err := parseBanTarget(target)
+		if err != nil {
+			t.Fatalf("parse %s error: %v", target, err)
+		}
+		ban.Expires = now.Add(time.Minute)
+		if err := r.ban(ban); err != nil {
+			t.Fatalf("ban %s error: %v", target, err)
+		}
+	}
+	tests := []struct {
+		ip     string
+		banned bool
+	}{
+		{"10.1.2.3", true},
+		{"11.1.2.3", false},
+		{"192.168.1.1", true},
+		{"192.168.1.2", false},
+		{"fe80::1", true},
+		{"fe80::2", false},
+	}
+	for _, test := range tests {
+		if banned := r.isBanned(id, net.ParseIP(test.ip)); banned != test.banned {
+			t.Errorf("%s: have banned %v, want %v", test.ip, banned, test.banned)
+		}
+	}
+
+	if !r.unban("10.0.0.0/8") {
+		t.Fatal("network should be unbanned")
+	}
+	if r.unban("10.0.0.0/8") {
+		t.Error("network is unbanned twice")
+	}
+	if r.isBanned(id, net.ParseIP("10.1.2.3")) {
+		t.Error("unbanned network should be allowed")
+	}
+}
+
+func TestBanPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vntp2p-bans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := newDatastore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Unix(1546300800, 0)
+	r := newTestReputation(db, &now)
+	id := decodeTestID(t, testPeerID)
+	other := decodeTestID(t, testPeerID2)
+	bans := []*Ban{
+		{ID: id, Expires: now.Add(time.Hour), Reason: "test"},
+		{ID: other, Expires: now.Add(time.Minute), Reason: "test"},
+		{Net: "172.16.0.0/12", Expires: now.Add(time.Hour), Reason: "test"},
+	}
+	for _, ban := range bans {
+		if err := r.ban(ban); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 重新加载时恢复未到期的禁止，删除已到期的禁止
+	now = now.Add(30 * time.Minute)
+	reloaded := newTestReputation(db, &now)
+	if !reloaded.isBanned(id, nil) || !reloaded.isBanned(other, net.ParseIP("172.16.1.1")) {
+		t.Error("unexpired bans should be reloaded")
+	}
+	if reloaded.isBanned(other, nil) {
+		t.Error("expired ban should not be reloaded")
+	}
+	if _, loaded := reloaded.list(); len(loaded) != 2 {
+		t.Errorf("reloaded bans mismatch: %v", loaded)
+	}
+
+	// 解除的禁止不再加载
+	if !reloaded.unban(id.Pretty()) {
+		t.Fatal("peer should be unbanned")
+	}
+	if newTestReputation(db, &now).isBanned(id, nil) {
+		t.Error("unbanned peer should not be reloaded")
+	}
+}
+
+func TestParseBanTarget(t *testing.T) {
+	id := decodeTestID(t, testPeerID)
+	tests := []struct {
+		target string
+		key    string
+		err    error
+	}{
+		{testPeerID, testPeerID, nil},
+		{"/ip4/127.0.0.1/tcp/3001/ipfs/" + testPeerID, testPeerID, nil},
+		{"10.1.2.3/8", "10.0.0.0/8", nil},
+		{"127.0.0.1", "127.0.0.1/32", nil},
+		{"::1", "::1/128", nil},
+		{"node", "", errInvalidBanTarget},
+		{"/ip4/127.0.0.1/tcp/3001", "", errInvalidBanTarget},
+	}
+	for _, test := range tests {
+		ban, err := parseBanTarget(test.target)
+		if err != test.err {
+			t.Errorf("%s: have error %v, want %v", test.target, err, test.err)
+			continue
+		}
+		if err == nil && ban.key() != test.key {
+			t.Errorf("%s: have key %s, want %s", test.target, ban.key(), test.key)
+		}
+	}
+	if ban, _ := parseBanTarget(testPeerID); ban.ID != id {
+		t.Errorf("peer id mismatch: have %v, want %v", ban.ID, id)
+	}
+}
@@ -22,11 +22,13 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	libp2p "github.com/libp2p/go-libp2p"
 	p2phost "github.com/libp2p/go-libp2p-host"
-	inet "github.com/libp2p/go-libp2p-net"
 	protocol "github.com/libp2p/go-libp2p-protocol"
 	"github.com/vntchain/go-vnt/event"
 	"github.com/vntchain/go-vnt/log"
@@ -39,8 +41,14 @@ import (
 )
 
 const (
-	maxActiveDialTasks = 16
-	defaultDialRatio   = 3
+	maxActiveDialTasks     = 16
+	defaultDialRatio       = 3
+	defaultMaxPendingPeers = 50
+
+	// handshakeTimeout is the maximum time for an inbound peer to send its
+	// first message, after which the peer is no longer pending. The stream of
+	// the peer is kept.
+	handshakeTimeout = 5 * time.Second
 )
 
 var errServerStopped = errors.New("server stopped")
@@ -50,6 +58,8 @@ type dialFlag int
 const (
 	dynDialedDail dialFlag = 1 << iota
 	staticDialedDail
+	trustedDialedDail
+	witnessDialedDail
 )
 
 type Config struct {
@@ -88,9 +98,14 @@ type Server struct {
 
 	lock sync.Mutex
 
-	quit         chan struct{}
-	addstatic    chan *Node
-	removestatic chan *Node
+	quit          chan struct{}
+	addstatic     chan *Node
+	removestatic  chan *Node
+	addtrusted    chan *Node
+	removetrusted chan *Node
+	setwitnesses  chan []*Node
+
+	pending chan struct{} // 正在建立的被动连接，数量不超过MaxPendingPeers
 
 	addpeer chan *Stream
 	delpeer chan peerDrop
@@ -98,7 +113,7 @@ type Server struct {
 	peerOp     chan peerOpFunc
 	peerOpDone chan struct{}
 
-	protomap map[string][]Protocol
+	reputation *reputation // 节点评分及禁止列表
 }
 
 type peerOpFunc func(map[peer.ID]*Peer)
@@ -136,15 +151,14 @@ func (server *Server) Start() error {
 	server.delpeer = make(chan peerDrop)
 	server.addstatic = make(chan *Node)
 	server.removestatic = make(chan *Node)
+	server.addtrusted = make(chan *Node)
+	server.removetrusted = make(chan *Node)
+	server.setwitnesses = make(chan []*Node)
+	server.pending = make(chan struct{}, server.maxPendingPeers())
 	server.quit = make(chan struct{})
 	server.peerOp = make(chan peerOpFunc)
 	server.peerOpDone = make(chan struct{})
 
-	// 协议映射初始化
-	server.protomap = make(map[string][]Protocol)
-
-	server.protomap[PID] = server.Protocols
-
 	// Listen
 	// run
 	if server.ListenAddr == "" {
@@ -164,16 +178,32 @@ func (server *Server) Start() error {
 
 	// setStreamHandler can only handle request message
 	// it can not hear response
-	host.SetStreamHandler(PID, server.HandleStream)
+	host.SetStreamHandler(PID, server.handleInboundStream)
+	host.SetStreamHandler(CompactPID, server.handleInboundStream)
+	host.SetStreamHandler(VersionedPID, server.handleInboundStream)
 
 	server.table = NewDHTTable(vdht, host.ID())
 	server.host = host
 
+	// 禁止列表保存在节点数据库中
+	var db *LevelDB
+	if d != "" {
+		if db, err = GetDatastore(filepath.Join(d, "vntdb")); err != nil {
+			log.Error("Open datastore for peer bans failed", "error", err)
+			return err
+		}
+	}
+	server.reputation = newReputation(db)
+
 	bootnodes := server.LoadConfig(ctx)
 
 	maxdials := server.maxDialedConns()
 
-	taskState := newTaskState(maxdials, bootnodes, server.table)
+	taskState := newTaskState(maxdials, bootnodes, server.table, server.reputation)
+	for _, n := range server.TrustedNodes {
+		server.host.Peerstore().AddAddrs(n.Id, []ma.Multiaddr{n.Addr}, peerstore.PermanentAddrTTL)
+		taskState.addTrusted(n)
+	}
 
 	server.loopWG.Add(1)
 	go server.run(ctx, taskState)
@@ -206,7 +236,12 @@ func (server *Server) run(ctx context.Context, tasker taskworker) {
 		queuedTasks  []task
 		taskdone     = make(chan task, maxActiveDialTasks)
 		peers        = make(map[peer.ID]*Peer)
+		trusted      = make(map[peer.ID]bool)
+		witnesses    = make(map[peer.ID]bool)
 	)
+	for _, n := range server.TrustedNodes {
+		trusted[n.Id] = true
+	}
 
 	delTask := func(t task) {
 		for i := range runningTasks {
@@ -225,6 +260,26 @@ func (server *Server) run(ctx context.Context, tasker taskworker) {
 		}
 		return ts[i:]
 	}
+	// evict disconnects a peer neither trusted nor witness, to make room for
+	// the witness peer.
+	// untrusted counts the peers not trusted, only which are limited by MaxPeers.
+	untrusted := func() (n int) {
+		for _, p := range peers {
+			if !p.Trusted() {
+				n++
+			}
+		}
+		return n
+	}
+	evict := func() {
+		for _, p := range peers {
+			if !p.Trusted() && !p.Witness() && atomic.LoadInt32(&p.reseted) == 0 {
+				p.log.Debug("Evict peer for witness")
+				p.Disconnect(DiscTooManyPeers)
+				return
+			}
+		}
+	}
 	scheduleTasks := func() {
 		queuedTasks = append(queuedTasks[:0], startTasks(queuedTasks)...)
 		if len(runningTasks) < maxActiveDialTasks {
@@ -249,7 +304,26 @@ func (server *Server) run(ctx context.Context, tasker taskworker) {
 				p.log.Debug("Already exist peer")
 				break
 			}
+			// 可信节点不受禁止列表和最大连接数的限制
+			if !trusted[remoteID] {
+				if server.reputation.isBanned(remoteID, multiaddrIP(t.stream.Conn().RemoteMultiaddr())) {
+					log.Debug("Reject banned peer", "peer id", remoteID)
+					_ = t.stream.Reset()
+					break
+				}
+				// 连接数已满时，断开普通节点以接受见证人节点
+				if untrusted() >= server.MaxPeers {
+					if !witnesses[remoteID] {
+						log.Debug("Reject peer", "peer id", remoteID, "reason", DiscTooManyPeers)
+						_ = t.stream.Reset()
+						break
+					}
+					evict()
+				}
+			}
 			p := newPeer(t, server)
+			p.setTrusted(trusted[remoteID])
+			p.setWitness(witnesses[remoteID])
 
 			if server.EnableMsgEvents {
 				p.events = &server.peerFeed
@@ -267,6 +341,31 @@ func (server *Server) run(ctx context.Context, tasker taskworker) {
 			if p, ok := peers[t.Id]; ok {
 				p.Disconnect(DiscRequested)
 			}
+		case t := <-server.addtrusted:
+			log.Debug("Adding trusted", "peer id", t.Id)
+			trusted[t.Id] = true
+			tasker.addTrusted(t)
+			if p, ok := peers[t.Id]; ok {
+				p.setTrusted(true)
+			}
+		case t := <-server.removetrusted:
+			log.Debug("Removing trusted", "peer id", t.Id)
+			delete(trusted, t.Id)
+			tasker.removeTrusted(t)
+			if p, ok := peers[t.Id]; ok {
+				p.setTrusted(false)
+			}
+
+		case ws := <-server.setwitnesses:
+			log.Debug("Setting witnesses", "count", len(ws))
+			witnesses = make(map[peer.ID]bool, len(ws))
+			for _, n := range ws {
+				witnesses[n.Id] = true
+			}
+			tasker.setWitnesses(ws)
+			for id, p := range peers {
+				p.setWitness(witnesses[id])
+			}
 
 		case op := <-server.peerOp:
 			// This channel is used by Peers and PeerCount.
@@ -307,6 +406,42 @@ func (server *Server) RemovePeer(node *Node) {
 	}
 }
 
+// AddTrustedPeer adds the node to the trusted set. The trusted peers are always
+// accepted regardless of MaxPeers and bans, and reconnected if dropped.
+func (server *Server) AddTrustedPeer(ctx context.Context, node *Node) {
+	server.host.Peerstore().AddAddrs(node.Id, []ma.Multiaddr{node.Addr}, peerstore.PermanentAddrTTL)
+	_ = server.table.Update(ctx, node.Id)
+
+	select {
+	case <-server.quit:
+	case server.addtrusted <- node:
+	}
+}
+
+// RemoveTrustedPeer removes the node from the trusted set, the connection is
+// kept but subject to the limits of normal peers.
+func (server *Server) RemoveTrustedPeer(node *Node) {
+	select {
+	case <-server.quit:
+	case server.removetrusted <- node:
+	}
+}
+
+// SetWitnesses replaces the witness peers with nodes. The witness peers are
+// kept connected and take precedence over the regular peers when the peer
+// slots are full.
+func (server *Server) SetWitnesses(ctx context.Context, nodes []*Node) {
+	for _, node := range nodes {
+		server.host.Peerstore().AddAddrs(node.Id, []ma.Multiaddr{node.Addr}, peerstore.PermanentAddrTTL)
+		_ = server.table.Update(ctx, node.Id)
+	}
+
+	select {
+	case <-server.quit:
+	case server.setwitnesses <- nodes:
+	}
+}
+
 func (server *Server) SubscribeEvents(ch chan *PeerEvent) event.Subscription {
 	return server.peerFeed.Subscribe(ch)
 }
@@ -348,11 +483,11 @@ func (server *Server) Self() *Node {
 // GetPeerByRemoteID get specific peer by remoteID
 // if it doesn't exist, new it
 // this function guarantee get the wanted peer
-func (server *Server) GetPeerByRemoteID(s inet.Stream) *Peer {
+func (server *Server) GetPeerByRemoteID(s *Stream) *Peer {
 	var p *Peer
 
 	// always try to new this peer
-	err := server.dispatch(&Stream{stream: s, Protocols: server.protomap[PID]}, server.addpeer)
+	err := server.dispatch(s, server.addpeer)
 	if err != nil {
 		log.Error("GetPeerByRemoteID()", "new peer error", err)
 		return nil
@@ -361,7 +496,7 @@ func (server *Server) GetPeerByRemoteID(s inet.Stream) *Peer {
 	select {
 	case <-server.quit:
 	case server.peerOp <- func(peers map[peer.ID]*Peer) {
-		remoteID := s.Conn().RemotePeer()
+		remoteID := s.stream.Conn().RemotePeer()
 		if val, ok := peers[remoteID]; ok {
 			p = val
 		}
@@ -369,7 +504,7 @@ func (server *Server) GetPeerByRemoteID(s inet.Stream) *Peer {
 		<-server.peerOpDone
 	}
 
-	pid := s.Conn().RemotePeer()
+	pid := s.stream.Conn().RemotePeer()
 	log.Debug("Got peer by remote id", "peerid", pid, "peer got", p)
 
 	return p
@@ -407,15 +542,27 @@ func (server *Server) maxDialedConns() int {
 	return server.MaxPeers / r
 }
 
+func (server *Server) maxPendingPeers() int {
+	if server.MaxPendingPeers > 0 {
+		return server.MaxPendingPeers
+	}
+	return defaultMaxPendingPeers
+}
+
 // SetupStream 主动发起连接
 func (server *Server) SetupStream(ctx context.Context, target peer.ID, pid string) error {
-	s, err := server.host.NewStream(ctx, target, protocol.ID(pid))
+	// 优先协商子协议版本并使用紧凑二进制消息帧，对方不支持时依次降级
+	pids := []protocol.ID{protocol.ID(pid)}
+	if pid == PID {
+		pids = []protocol.ID{VersionedPID, CompactPID, PID}
+	}
+	s, err := server.host.NewStream(ctx, target, pids...)
 	if err != nil {
 		// fmt.Println("SetupStream NewStream Error: ", err)
 		return err
 	}
 
-	// handle response message
+	// handle response message, the peer is added once the protocols are negotiated
 	go server.HandleStream(s)
 	/* rw := bufio.NewReadWriter(bufio.NewReader(s), bufio.NewWriter(s))
 	vntMessenger := &VNTMsger{
@@ -430,11 +577,6 @@ func (server *Server) SetupStream(ctx context.Context, target peer.ID, pid strin
 		return err
 	} */
 
-	err = server.dispatch(&Stream{stream: s, Protocols: server.protomap[pid]}, server.addpeer)
-	if err != nil {
-		fmt.Println("SetupStream dispatch Error: ", err)
-		return err
-	}
 	return nil
 }
 
@@ -513,5 +655,8 @@ type taskworker interface {
 	newTasks(map[peer.ID]*Peer) []task
 	addStatic(n *Node)
 	removeStatic(n *Node)
+	addTrusted(n *Node)
+	removeTrusted(n *Node)
+	setWitnesses(nodes []*Node)
 	taskDone(t task)
 }